@@ -19,16 +19,60 @@ import (
 	"fmt"
 	"path"
 	"strings"
+
+	"go.opentelemetry.io/otel/internal/global"
 )
 
 // CleanPath returns a path with all spaces trimmed and all redundancies removed. If urlPath is empty or cleaning it results in an empty string, defaultPath is returned instead.
+//
+// A query string following the first "?" in urlPath is not cleaned and is
+// appended verbatim to the returned path.
 func CleanPath(urlPath string, defaultPath string) string {
-	tmp := path.Clean(strings.TrimSpace(urlPath))
+	urlPath, query, hasQuery := strings.Cut(strings.TrimSpace(urlPath), "?")
+	tmp := path.Clean(urlPath)
 	if tmp == "." {
-		return defaultPath
-	}
-	if !path.IsAbs(tmp) {
+		tmp = defaultPath
+	} else if !path.IsAbs(tmp) {
 		tmp = fmt.Sprintf("/%s", tmp)
 	}
+	if hasQuery {
+		tmp += "?" + query
+	}
 	return tmp
 }
+
+// CleanPathStrict returns the same path CleanPath does, unless urlPath
+// contains a ".." segment. Cleaning such a path hides the traversal (e.g.
+// "dir/../other" becomes "/other", and "dir/.." becomes defaultPath), which
+// is likely a mistaken or malicious configuration. Instead, an error is
+// logged and defaultPath is returned.
+func CleanPathStrict(urlPath string, defaultPath string) string {
+	p, _, _ := strings.Cut(strings.TrimSpace(urlPath), "?")
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			err := fmt.Errorf("URL path contains a %q segment: %q", "..", urlPath)
+			global.Error(err, "using default URL path", "default", defaultPath)
+			return defaultPath
+		}
+	}
+	return CleanPath(urlPath, defaultPath)
+}
+
+// grpcResolverSchemes are the schemes of the name resolvers built into gRPC.
+var grpcResolverSchemes = []string{"dns", "unix", "unix-abstract", "passthrough"}
+
+// HasGRPCResolverScheme returns if target starts with the scheme of a name
+// resolver built into gRPC (e.g. "dns:///collector:4317" or
+// "unix:///var/run/otel.sock"). These targets need to be passed to gRPC as is.
+func HasGRPCResolverScheme(target string) bool {
+	scheme, _, ok := strings.Cut(target, ":")
+	if !ok {
+		return false
+	}
+	for _, s := range grpcResolverSchemes {
+		if strings.EqualFold(scheme, s) {
+			return true
+		}
+	}
+	return false
+}
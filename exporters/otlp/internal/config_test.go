@@ -72,6 +72,30 @@ func TestCleanPath(t *testing.T) {
 			},
 			want: "/dir/a",
 		},
+		{
+			name: "query preserved",
+			args: args{
+				urlPath:     "/v1/metrics?tenant=a",
+				defaultPath: "DefaultMetricsPath",
+			},
+			want: "/v1/metrics?tenant=a",
+		},
+		{
+			name: "path cleaned query verbatim",
+			args: args{
+				urlPath:     " v1//./metrics/?tenant=a/../b&x=./y ",
+				defaultPath: "DefaultMetricsPath",
+			},
+			want: "/v1/metrics?tenant=a/../b&x=./y",
+		},
+		{
+			name: "query with empty path",
+			args: args{
+				urlPath:     "?tenant=a",
+				defaultPath: "/v1/metrics",
+			},
+			want: "/v1/metrics?tenant=a",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -81,3 +105,57 @@ func TestCleanPath(t *testing.T) {
 		})
 	}
 }
+
+func TestCleanPathStrict(t *testing.T) {
+	const defaultPath = "/v1/metrics"
+	tests := []struct {
+		name    string
+		urlPath string
+		want    string
+	}{
+		{name: "escapes base", urlPath: "../../etc", want: defaultPath},
+		{name: "absolute escapes base", urlPath: "/../../etc/passwd", want: defaultPath},
+		{name: "traversal within base", urlPath: "dir/../other", want: defaultPath},
+		{name: "traversal to empty", urlPath: "dir/..", want: defaultPath},
+		{name: "nested", urlPath: "/prefix/nested/v1/metrics", want: "/prefix/nested/v1/metrics"},
+		{name: "dots in name", urlPath: "/a..b/...", want: "/a..b/..."},
+		{name: "cleaned", urlPath: " v1//./metrics/ ", want: "/v1/metrics"},
+		{name: "traversal in query", urlPath: "/v1/metrics?tenant=../b", want: "/v1/metrics?tenant=../b"},
+		{name: "empty", urlPath: "", want: defaultPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanPathStrict(tt.urlPath, defaultPath); got != tt.want {
+				t.Errorf("CleanPathStrict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasGRPCResolverScheme(t *testing.T) {
+	for _, target := range []string{
+		"unix:///var/run/otel.sock",
+		"unix:otel.sock",
+		"unix-abstract:otel",
+		"dns:///collector:4317",
+		"dns://8.8.8.8/collector:4317",
+		"passthrough:///localhost:4317",
+		"DNS:///collector:4317",
+	} {
+		if !HasGRPCResolverScheme(target) {
+			t.Errorf("HasGRPCResolverScheme(%q) = false, want true", target)
+		}
+	}
+	for _, target := range []string{
+		"",
+		"localhost:4317",
+		"collector",
+		"http://localhost:4317",
+		"https://localhost:4317",
+		"xds:///collector",
+	} {
+		if HasGRPCResolverScheme(target) {
+			t.Errorf("HasGRPCResolverScheme(%q) = true, want false", target)
+		}
+	}
+}
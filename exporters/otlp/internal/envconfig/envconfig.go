@@ -166,25 +166,34 @@ func stringToHeader(value string) map[string]string {
 	headers := make(map[string]string)
 
 	for _, header := range headersPairs {
+		if strings.TrimSpace(header) == "" {
+			// Allow empty list members (e.g. a trailing comma).
+			continue
+		}
 		n, v, found := strings.Cut(header, "=")
 		if !found {
-			global.Error(errors.New("missing '="), "parse headers", "input", header)
+			global.Error(errors.New("missing '='"), "parse headers", "input", header)
 			continue
 		}
-		name, err := url.QueryUnescape(n)
+		// Surrounding whitespace is not part of the key or value. Whitespace
+		// that is part of them needs to be percent-encoded.
+		name, err := url.PathUnescape(strings.TrimSpace(n))
 		if err != nil {
 			global.Error(err, "escape header key", "key", n)
 			continue
 		}
-		trimmedName := strings.TrimSpace(name)
-		value, err := url.QueryUnescape(v)
+		if name == "" {
+			global.Error(errors.New("empty key"), "parse headers", "input", header)
+			continue
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(v))
 		if err != nil {
 			global.Error(err, "escape header value", "value", v)
 			continue
 		}
-		trimmedValue := strings.TrimSpace(value)
 
-		headers[trimmedName] = trimmedValue
+		// Duplicate keys are allowed, the last value is used.
+		headers[name] = value
 	}
 
 	return headers
@@ -451,6 +451,53 @@ func TestStringToHeader(t *testing.T) {
 				"userId": "alice",
 			},
 		},
+		{
+			name:  "encoded separators",
+			value: "authorization=Basic%20dXNlcjpwYXNz%3D%3D,list=a%2Cb,k%3Dey=v",
+			want: map[string]string{
+				"authorization": "Basic dXNlcjpwYXNz==",
+				"list":          "a,b",
+				"k=ey":          "v",
+			},
+		},
+		{
+			name:  "unencoded equals in value",
+			value: "token=abc==",
+			want:  map[string]string{"token": "abc=="},
+		},
+		{
+			name:  "plus is literal",
+			value: "token=a+b/c",
+			want:  map[string]string{"token": "a+b/c"},
+		},
+		{
+			name:  "encoded whitespace is kept",
+			value: "key= %20value%20 ",
+			want:  map[string]string{"key": " value "},
+		},
+		{
+			name:  "empty value",
+			value: "empty=,userId=alice",
+			want: map[string]string{
+				"empty":  "",
+				"userId": "alice",
+			},
+		},
+		{
+			name:  "empty key",
+			value: " =value,userId=alice",
+			want:  map[string]string{"userId": "alice"},
+		},
+		{
+			name:  "empty members",
+			value: ",userId=alice, ,",
+			want:  map[string]string{"userId": "alice"},
+		},
+		{
+			name:  "duplicate keys",
+			value: "userId=alice,userId=bob",
+			want:  map[string]string{"userId": "bob"},
+		},
 	}
 
 	for _, tt := range tests {
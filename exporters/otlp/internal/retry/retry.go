@@ -20,6 +20,9 @@ package retry // import "go.opentelemetry.io/otel/exporters/otlp/internal/retry"
 import (
 	"context"
 	"fmt"
+	"math"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -47,9 +50,31 @@ type Config struct {
 	// `MaxInterval`.
 	MaxInterval time.Duration
 	// MaxElapsedTime is the maximum amount of time (including retries) spent
-	// trying to send a request/batch.  Once this value is reached, the data
-	// is discarded.
+	// trying to send a request/batch.  Once this value is reached, no further
+	// attempts are made, the data is discarded, and the error from the last
+	// attempt is returned. A zero value means there is no limit.
 	MaxElapsedTime time.Duration
+
+	// clock is used to measure the elapsed time and wait between retries. If
+	// nil, the system clock is used. It allows tests to control time.
+	clock clock
+}
+
+// Backoff holds the settings of the exponential backoff between retries that
+// are not part of Config. The zero value uses the default settings.
+type Backoff struct {
+	// Multiplier is the factor the backoff interval is multiplied by after
+	// each failed attempt. A zero value means the default multiplier of 1.5
+	// is used.
+	Multiplier float64
+	// RandomizationFactor is the factor each backoff interval is randomized
+	// by to spread out the retries of many clients failing at the same time.
+	// The interval used is chosen at random in the range
+	// [interval*(1-RandomizationFactor), interval*(1+RandomizationFactor)].
+	// A zero value means the default factor of 0.5 is used. A negative
+	// value means the backoff intervals are not randomized. Values greater
+	// than 1 are reduced to 1.
+	RandomizationFactor float64
 }
 
 // RequestFunc wraps a request with retry logic.
@@ -63,46 +88,90 @@ type RequestFunc func(context.Context, func(context.Context) error) error
 //
 // The function must return a non-zero time.Duration if the error contains
 // explicit throttle duration that should be honored, otherwise it must return
-// a zero valued time.Duration.
+// a zero valued time.Duration. The request is retried after the greater of
+// the throttle duration and the backoff delay. A throttle duration greater
+// than the MaxInterval of the Config is reduced to MaxInterval.
 type EvaluateFunc func(error) (bool, time.Duration)
 
+// ParseRetryAfter returns the delay described by the value v of a Retry-After
+// HTTP header. Both the delay-seconds and HTTP-date forms of the value are
+// supported. If v cannot be parsed, false is returned. A date in the past
+// results in a zero delay.
+func ParseRetryAfter(v string) (time.Duration, bool) {
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		if secs > int64(math.MaxInt64/time.Second) {
+			return time.Duration(math.MaxInt64), true
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	date, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if d := time.Until(date); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
 // RequestFunc returns a RequestFunc using the evaluate function to determine
 // if requests can be retried and based on the exponential backoff
 // configuration of c.
 func (c Config) RequestFunc(evaluate EvaluateFunc) RequestFunc {
+	return c.RequestFuncWithBackoff(Backoff{}, evaluate)
+}
+
+// RequestFuncWithBackoff returns a RequestFunc like RequestFunc that uses the
+// backoff settings of b.
+func (c Config) RequestFuncWithBackoff(b Backoff, evaluate EvaluateFunc) RequestFunc {
 	if !c.Enabled {
 		return func(ctx context.Context, fn func(context.Context) error) error {
 			return fn(ctx)
 		}
 	}
 
+	clk := c.clock
+	if clk == nil {
+		clk = systemClock{}
+	}
 	return func(ctx context.Context, fn func(context.Context) error) error {
 		// Do not use NewExponentialBackOff since it calls Reset and the code here
 		// must call Reset after changing the InitialInterval (this saves an
 		// unnecessary call to Now).
-		b := &backoff.ExponentialBackOff{
+		bo := &backoff.ExponentialBackOff{
 			InitialInterval:     c.InitialInterval,
-			RandomizationFactor: backoff.DefaultRandomizationFactor,
-			Multiplier:          backoff.DefaultMultiplier,
+			RandomizationFactor: b.randomizationFactor(),
+			Multiplier:          b.multiplier(),
 			MaxInterval:         c.MaxInterval,
 			MaxElapsedTime:      c.MaxElapsedTime,
 			Stop:                backoff.Stop,
-			Clock:               backoff.SystemClock,
+			Clock:               clk,
 		}
-		b.Reset()
+		bo.Reset()
 
 		for {
 			err := fn(ctx)
 			if err == nil {
 				return nil
 			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				// The caller is done with the request, do not retry.
+				return fmt.Errorf("%w: %s", ctxErr, err)
+			}
 
 			retryable, throttle := evaluate(err)
 			if !retryable {
 				return err
 			}
+			// Do not let a server stall the request beyond MaxInterval.
+			if c.MaxInterval > 0 && throttle > c.MaxInterval {
+				throttle = c.MaxInterval
+			}
 
-			bOff := b.NextBackOff()
+			bOff := bo.NextBackOff()
 			if bOff == backoff.Stop {
 				return fmt.Errorf("max retry time elapsed: %w", err)
 			}
@@ -112,20 +181,54 @@ func (c Config) RequestFunc(evaluate EvaluateFunc) RequestFunc {
 			if bOff > throttle {
 				delay = bOff
 			} else {
-				elapsed := b.GetElapsedTime()
-				if b.MaxElapsedTime != 0 && elapsed+throttle > b.MaxElapsedTime {
+				elapsed := bo.GetElapsedTime()
+				if bo.MaxElapsedTime != 0 && elapsed+throttle > bo.MaxElapsedTime {
 					return fmt.Errorf("max retry time would elapse: %w", err)
 				}
 				delay = throttle
 			}
 
-			if ctxErr := waitFunc(ctx, delay); ctxErr != nil {
+			if ctxErr := clk.Wait(ctx, delay); ctxErr != nil {
 				return fmt.Errorf("%w: %s", ctxErr, err)
 			}
 		}
 	}
 }
 
+// multiplier returns the backoff multiplier of b.
+func (b Backoff) multiplier() float64 {
+	if b.Multiplier == 0 {
+		return backoff.DefaultMultiplier
+	}
+	return b.Multiplier
+}
+
+// randomizationFactor returns the backoff randomization factor of b limited
+// to the range [0, 1].
+func (b Backoff) randomizationFactor() float64 {
+	if b.RandomizationFactor == 0 {
+		return backoff.DefaultRandomizationFactor
+	}
+	return math.Max(0, math.Min(1, b.RandomizationFactor))
+}
+
+// clock provides the current time and waits for delays to pass.
+type clock interface {
+	backoff.Clock
+	// Wait returns nil once delay has passed, or the error of ctx if it is
+	// done before.
+	Wait(ctx context.Context, delay time.Duration) error
+}
+
+// systemClock is the clock based on the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Wait(ctx context.Context, delay time.Duration) error {
+	return waitFunc(ctx, delay)
+}
+
 // Allow override for testing.
 var waitFunc = wait
 
@@ -17,13 +17,16 @@ package retry
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
+	"net/http"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWait(t *testing.T) {
@@ -97,7 +100,7 @@ func TestThrottledRetry(t *testing.T) {
 	reqFunc := Config{
 		Enabled:         true,
 		InitialInterval: backoffDelay,
-		MaxInterval:     backoffDelay,
+		MaxInterval:     throttleDelay,
 		// Never stop retrying.
 		MaxElapsedTime: 0,
 	}.RequestFunc(ev)
@@ -121,6 +124,52 @@ func TestThrottledRetry(t *testing.T) {
 	}), assert.AnError)
 }
 
+func TestThrottledRetryCappedAtMaxInterval(t *testing.T) {
+	maxInterval := time.Second
+	ev := func(error) (bool, time.Duration) { return true, time.Hour }
+	reqFunc := Config{
+		Enabled:         true,
+		InitialInterval: time.Nanosecond,
+		MaxInterval:     maxInterval,
+		MaxElapsedTime:  0,
+	}.RequestFunc(ev)
+
+	origWait := waitFunc
+	waitFunc = func(_ context.Context, delay time.Duration) error {
+		assert.Equal(t, maxInterval, delay, "throttle not capped")
+		return assert.AnError
+	}
+	t.Cleanup(func() { waitFunc = origWait })
+
+	assert.ErrorIs(t, reqFunc(context.Background(), func(context.Context) error {
+		return errors.New("not this error")
+	}), assert.AnError)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	d, ok := ParseRetryAfter("120")
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, d)
+
+	d, ok = ParseRetryAfter("0")
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), d)
+
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	d, ok = ParseRetryAfter(date)
+	assert.True(t, ok)
+	assert.InDelta(t, time.Hour, d, float64(2*time.Second))
+
+	d, ok = ParseRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT")
+	assert.True(t, ok, "past date")
+	assert.Equal(t, time.Duration(0), d)
+
+	for _, v := range []string{"", "-1", "1.5", "soon"} {
+		_, ok = ParseRetryAfter(v)
+		assert.False(t, ok, v)
+	}
+}
+
 func TestBackoffRetry(t *testing.T) {
 	ev := func(error) (bool, time.Duration) { return true, 0 }
 
@@ -153,6 +202,94 @@ func TestBackoffRetry(t *testing.T) {
 	}), assert.AnError)
 }
 
+func TestBackoffRetryMultiplier(t *testing.T) {
+	ev := func(error) (bool, time.Duration) { return true, 0 }
+
+	delay := time.Second
+	reqFunc := Config{
+		Enabled:         true,
+		InitialInterval: delay,
+		MaxInterval:     time.Hour,
+		MaxElapsedTime:  0,
+	}.RequestFuncWithBackoff(Backoff{Multiplier: 4}, ev)
+
+	origWait := waitFunc
+	var delays []time.Duration
+	waitFunc = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		if len(delays) == 2 {
+			return assert.AnError
+		}
+		return nil
+	}
+	t.Cleanup(func() { waitFunc = origWait })
+
+	ctx := context.Background()
+	assert.ErrorIs(t, reqFunc(ctx, func(context.Context) error {
+		return errors.New("not this error")
+	}), assert.AnError)
+
+	require.Len(t, delays, 2)
+	want := 4 * delay
+	delta := math.Ceil(float64(want) * backoff.DefaultRandomizationFactor)
+	assert.InDelta(t, want, delays[1], delta, "multiplier not applied")
+}
+
+// firstDelays returns the delay before the first retry of n requests made
+// with c and b.
+func firstDelays(t *testing.T, c Config, b Backoff, n int) []time.Duration {
+	t.Helper()
+
+	origWait := waitFunc
+	var delays []time.Duration
+	waitFunc = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return assert.AnError
+	}
+	t.Cleanup(func() { waitFunc = origWait })
+
+	ev := func(error) (bool, time.Duration) { return true, 0 }
+	reqFunc := c.RequestFuncWithBackoff(b, ev)
+	for i := 0; i < n; i++ {
+		require.ErrorIs(t, reqFunc(context.Background(), func(context.Context) error {
+			return errors.New("not this error")
+		}), assert.AnError)
+	}
+	return delays
+}
+
+func TestBackoffRetryRandomizationFactor(t *testing.T) {
+	const n = 1000
+	delay := time.Second
+	c := Config{
+		Enabled:         true,
+		InitialInterval: delay,
+		MaxInterval:     time.Hour,
+	}
+
+	delays := firstDelays(t, c, Backoff{RandomizationFactor: 0.5}, n)
+	require.Len(t, delays, n)
+	distinct := make(map[time.Duration]struct{})
+	for _, d := range delays {
+		assert.GreaterOrEqual(t, d, delay/2)
+		assert.LessOrEqual(t, d, delay*3/2)
+		distinct[d] = struct{}{}
+	}
+	assert.Greater(t, len(distinct), 1, "delays not randomized")
+
+	for _, d := range firstDelays(t, c, Backoff{RandomizationFactor: -1}, 10) {
+		assert.Equal(t, delay, d, "delay randomized")
+	}
+}
+
+func TestBackoffRandomizationFactor(t *testing.T) {
+	assert.Equal(t, 1.0, Backoff{RandomizationFactor: 2}.randomizationFactor())
+	assert.Equal(t, 0.0, Backoff{RandomizationFactor: -1}.randomizationFactor())
+	// A zero factor uses the default, so configurations without it keep
+	// randomizing their backoff intervals.
+	assert.Equal(t, 0.5, Backoff{}.randomizationFactor())
+}
+
 func TestBackoffRetryCanceledContext(t *testing.T) {
 	ev := func(error) (bool, time.Duration) { return true, 0 }
 
@@ -178,6 +315,36 @@ func TestBackoffRetryCanceledContext(t *testing.T) {
 	assert.Equal(t, 1, count)
 }
 
+func TestCanceledDuringRequest(t *testing.T) {
+	var evaluated int
+	ev := func(error) (bool, time.Duration) {
+		evaluated++
+		return true, time.Hour
+	}
+	clk := newFakeClock()
+	reqFunc := withClock(Config{
+		Enabled:         true,
+		InitialInterval: time.Second,
+		MaxInterval:     time.Hour,
+		MaxElapsedTime:  time.Minute,
+	}, clk).RequestFunc(ev)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var count int
+	err := reqFunc(ctx, func(context.Context) error {
+		count++
+		cancel()
+		return assert.AnError
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, assert.AnError.Error())
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 0, evaluated, "error of canceled request evaluated")
+	assert.Empty(t, clk.delays, "waited to retry canceled request")
+}
+
 func TestThrottledRetryGreaterThanMaxElapsedTime(t *testing.T) {
 	// Ensure the throttle delay is used by making longer than backoff delay.
 	tDelay, bDelay := time.Hour, time.Nanosecond
@@ -185,7 +352,7 @@ func TestThrottledRetryGreaterThanMaxElapsedTime(t *testing.T) {
 	reqFunc := Config{
 		Enabled:         true,
 		InitialInterval: bDelay,
-		MaxInterval:     bDelay,
+		MaxInterval:     tDelay,
 		MaxElapsedTime:  tDelay - (time.Nanosecond),
 	}.RequestFunc(ev)
 
@@ -211,6 +378,32 @@ func TestMaxElapsedTime(t *testing.T) {
 	}).Error(), "max retry time elapsed: ")
 }
 
+func TestMaxElapsedTimeBoundsRetries(t *testing.T) {
+	ev := func(error) (bool, time.Duration) { return true, 0 }
+	maxElapsed := 50 * time.Millisecond
+	reqFunc := Config{
+		Enabled:         true,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     5 * time.Millisecond,
+		MaxElapsedTime:  maxElapsed,
+	}.RequestFunc(ev)
+
+	var attempts int
+	lastErr := errors.New("last error")
+	start := time.Now()
+	err := reqFunc(context.Background(), func(context.Context) error {
+		attempts++
+		return fmt.Errorf("attempt %d: %w", attempts, lastErr)
+	})
+	elapsed := time.Since(start)
+
+	assert.ErrorIs(t, err, lastErr)
+	assert.ErrorContains(t, err, fmt.Sprintf("attempt %d", attempts), "not last error")
+	assert.Greater(t, attempts, 1, "request not retried")
+	// Allow for the last backoff delay and scheduling overhead.
+	assert.Less(t, elapsed, maxElapsed+100*time.Millisecond, "retries not bounded")
+}
+
 func TestRetryNotEnabled(t *testing.T) {
 	ev := func(error) (bool, time.Duration) {
 		t.Error("evaluated retry when not enabled")
@@ -256,3 +449,107 @@ func TestConcurrentRetry(t *testing.T) {
 
 	wg.Wait()
 }
+
+// fakeClock is a clock whose time only advances when it is waited on.
+type fakeClock struct {
+	now    time.Time
+	delays []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Wait(ctx context.Context, delay time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.delays = append(c.delays, delay)
+	c.now = c.now.Add(delay)
+	return nil
+}
+
+// withClock returns a copy of c using clk to measure and wait for time.
+func withClock(c Config, clk clock) Config {
+	c.clock = clk
+	return c
+}
+
+func TestBackoffSequence(t *testing.T) {
+	clk := newFakeClock()
+	reqFunc := withClock(Config{
+		Enabled:         true,
+		InitialInterval: time.Second,
+		MaxInterval:     8 * time.Second,
+		MaxElapsedTime:  30 * time.Second,
+	}, clk).RequestFuncWithBackoff(Backoff{
+		Multiplier: 2,
+		// Not randomized so the delays are deterministic.
+		RandomizationFactor: -1,
+	}, func(error) (bool, time.Duration) { return true, 0 })
+
+	var attempts int
+	start := time.Now()
+	err := reqFunc(context.Background(), func(context.Context) error {
+		attempts++
+		return assert.AnError
+	})
+	assert.Less(t, time.Since(start), time.Second, "real time waited")
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.ErrorContains(t, err, "max retry time elapsed")
+	want := []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		8 * time.Second,
+	}
+	assert.Equal(t, want, clk.delays)
+	assert.Equal(t, len(want)+1, attempts)
+	assert.Equal(t, 23*time.Second, clk.Now().Sub(time.Unix(0, 0)))
+}
+
+func TestBackoffSequenceThrottled(t *testing.T) {
+	clk := newFakeClock()
+	reqFunc := withClock(Config{
+		Enabled:         true,
+		InitialInterval: time.Second,
+		MaxInterval:     10 * time.Second,
+		MaxElapsedTime:  time.Minute,
+	}, clk).RequestFuncWithBackoff(Backoff{
+		Multiplier: 2,
+		// Not randomized so the delays are deterministic.
+		RandomizationFactor: -1,
+	}, func(error) (bool, time.Duration) {
+		return true, 5 * time.Second
+	})
+
+	var attempts int
+	err := reqFunc(context.Background(), func(context.Context) error {
+		attempts++
+		if attempts == 5 {
+			return nil
+		}
+		return assert.AnError
+	})
+	require.NoError(t, err)
+	// The throttle delay is used until the backoff delay exceeds it.
+	want := []time.Duration{
+		5 * time.Second,
+		5 * time.Second,
+		5 * time.Second,
+		8 * time.Second,
+	}
+	assert.Equal(t, want, clk.delays)
+}
+
+func TestConfigComparable(t *testing.T) {
+	// Config is the underlying type of the RetryConfig of stable exporters,
+	// which users compare and use as map keys.
+	m := map[Config]bool{DefaultConfig: true}
+	assert.True(t, m[DefaultConfig])
+	assert.False(t, DefaultConfig == Config{})
+}
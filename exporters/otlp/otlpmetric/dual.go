@@ -0,0 +1,106 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlpmetric // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// Transport identifies the transport an export is sent with.
+type Transport int
+
+const (
+	// HTTPTransport identifies the OTLP/HTTP transport.
+	HTTPTransport Transport = iota
+	// GRPCTransport identifies the OTLP/gRPC transport.
+	GRPCTransport
+)
+
+// dualExporter dispatches each export to one of two exporters.
+type dualExporter struct {
+	httpExp  metric.Exporter
+	grpcExp  metric.Exporter
+	selector func(*metricdata.ResourceMetrics) Transport
+}
+
+// NewDualTransportExporter returns an Exporter that sends each export with
+// either httpExp or grpcExp. The Transport returned by selector for the
+// metric data being exported determines which of the two is used. If selector
+// returns an unknown Transport, httpExp is used.
+//
+// ForceFlush and Shutdown are called on both exporters.
+//
+// The Temporality and Aggregation of the returned Exporter are those of
+// httpExp. Both exporters should be configured with the same temporality and
+// aggregation selectors so exported data is consistent regardless of the
+// transport used.
+func NewDualTransportExporter(httpExp, grpcExp metric.Exporter, selector func(*metricdata.ResourceMetrics) Transport) metric.Exporter {
+	return &dualExporter{
+		httpExp:  httpExp,
+		grpcExp:  grpcExp,
+		selector: selector,
+	}
+}
+
+// Temporality returns the Temporality to use for an instrument kind.
+func (e *dualExporter) Temporality(k metric.InstrumentKind) metricdata.Temporality {
+	return e.httpExp.Temporality(k)
+}
+
+// Aggregation returns the Aggregation to use for an instrument kind.
+func (e *dualExporter) Aggregation(k metric.InstrumentKind) aggregation.Aggregation {
+	return e.httpExp.Aggregation(k)
+}
+
+// Export exports rm with the exporter of the Transport selected for rm.
+func (e *dualExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	if e.selector(rm) == GRPCTransport {
+		return e.grpcExp.Export(ctx, rm)
+	}
+	return e.httpExp.Export(ctx, rm)
+}
+
+// ForceFlush flushes both exporters.
+func (e *dualExporter) ForceFlush(ctx context.Context) error {
+	return unifyErrors(e.httpExp.ForceFlush(ctx), e.grpcExp.ForceFlush(ctx))
+}
+
+// Shutdown shuts down both exporters.
+func (e *dualExporter) Shutdown(ctx context.Context) error {
+	return unifyErrors(e.httpExp.Shutdown(ctx), e.grpcExp.Shutdown(ctx))
+}
+
+// unifyErrors combines multiple errors into a single error.
+func unifyErrors(errs ...error) error {
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	default:
+		return fmt.Errorf("%v", nonNil)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlpmetric_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+type countingExporter struct {
+	exports, flushes, shutdowns int
+	err                         error
+}
+
+func (e *countingExporter) Temporality(metric.InstrumentKind) metricdata.Temporality {
+	return metricdata.DeltaTemporality
+}
+
+func (e *countingExporter) Aggregation(k metric.InstrumentKind) aggregation.Aggregation {
+	return metric.DefaultAggregationSelector(k)
+}
+
+func (e *countingExporter) Export(context.Context, *metricdata.ResourceMetrics) error {
+	e.exports++
+	return e.err
+}
+
+func (e *countingExporter) ForceFlush(context.Context) error {
+	e.flushes++
+	return e.err
+}
+
+func (e *countingExporter) Shutdown(context.Context) error {
+	e.shutdowns++
+	return e.err
+}
+
+func batch(n int) *metricdata.ResourceMetrics {
+	return &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: make([]metricdata.Metrics, n),
+		}},
+	}
+}
+
+func TestDualTransportExporter(t *testing.T) {
+	ctx := context.Background()
+	httpExp, grpcExp := &countingExporter{}, &countingExporter{}
+	bySize := func(rm *metricdata.ResourceMetrics) otlpmetric.Transport {
+		var n int
+		for _, sm := range rm.ScopeMetrics {
+			n += len(sm.Metrics)
+		}
+		if n > 10 {
+			return otlpmetric.GRPCTransport
+		}
+		return otlpmetric.HTTPTransport
+	}
+	exp := otlpmetric.NewDualTransportExporter(httpExp, grpcExp, bySize)
+
+	assert.Equal(t, metricdata.DeltaTemporality, exp.Temporality(metric.InstrumentKindCounter))
+
+	assert.NoError(t, exp.Export(ctx, batch(1)))
+	assert.NoError(t, exp.Export(ctx, batch(5)))
+	assert.NoError(t, exp.Export(ctx, batch(100)))
+	assert.Equal(t, 2, httpExp.exports, "small batches not sent with HTTP")
+	assert.Equal(t, 1, grpcExp.exports, "large batch not sent with gRPC")
+
+	assert.NoError(t, exp.ForceFlush(ctx))
+	assert.Equal(t, 1, httpExp.flushes)
+	assert.Equal(t, 1, grpcExp.flushes)
+
+	assert.NoError(t, exp.Shutdown(ctx))
+	assert.Equal(t, 1, httpExp.shutdowns)
+	assert.Equal(t, 1, grpcExp.shutdowns)
+}
+
+func TestDualTransportExporterErrors(t *testing.T) {
+	ctx := context.Background()
+	httpErr, grpcErr := errors.New("http"), errors.New("grpc")
+	httpExp := &countingExporter{err: httpErr}
+	grpcExp := &countingExporter{err: grpcErr}
+	toGRPC := func(*metricdata.ResourceMetrics) otlpmetric.Transport {
+		return otlpmetric.GRPCTransport
+	}
+	exp := otlpmetric.NewDualTransportExporter(httpExp, grpcExp, toGRPC)
+
+	assert.ErrorIs(t, exp.Export(ctx, batch(1)), grpcErr)
+
+	err := exp.Shutdown(ctx)
+	assert.ErrorContains(t, err, httpErr.Error())
+	assert.ErrorContains(t, err, grpcErr.Error())
+	assert.Equal(t, 1, httpExp.shutdowns, "both exporters not shutdown")
+	assert.Equal(t, 1, grpcExp.shutdowns, "both exporters not shutdown")
+}
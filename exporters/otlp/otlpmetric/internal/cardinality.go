@@ -0,0 +1,248 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// overflowSet is the attribute set of the series that data points in excess
+// of a cardinality limit are aggregated into.
+var overflowSet = attribute.NewSet(attribute.Bool("otel.metric.overflow", true))
+
+// metricKey uniquely identifies a metric stream.
+type metricKey struct {
+	scope instrumentation.Scope
+	name  string
+}
+
+// cardinalityState tracks the attribute sets admitted for each metric.
+type cardinalityState struct {
+	limit int
+
+	mu       sync.Mutex
+	admitted map[metricKey]map[attribute.Distinct]struct{}
+}
+
+// CardinalityTransform returns a Transform that limits the number of
+// distinct attribute sets exported for each metric to limit. The data points
+// of the first limit attribute sets seen for a metric are exported as is. The
+// data points of all other attribute sets are aggregated into a single data
+// point with the otel.metric.overflow=true attribute.
+//
+// Gauge data points are aggregated by keeping the latest value, sum data
+// points by adding their values, and histogram data points by merging their
+// buckets. Other aggregations are not limited.
+//
+// If limit is not positive, nil is returned.
+func CardinalityTransform(limit int) Transform {
+	if limit <= 0 {
+		return nil
+	}
+	s := &cardinalityState{
+		limit:    limit,
+		admitted: make(map[metricKey]map[attribute.Distinct]struct{}),
+	}
+	return s.transform
+}
+
+func (s *cardinalityState) transform(rm *metricdata.ResourceMetrics) *metricdata.ResourceMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := &metricdata.ResourceMetrics{
+		Resource:     rm.Resource,
+		ScopeMetrics: make([]metricdata.ScopeMetrics, len(rm.ScopeMetrics)),
+	}
+	for i, sm := range rm.ScopeMetrics {
+		metrics := make([]metricdata.Metrics, len(sm.Metrics))
+		for j, m := range sm.Metrics {
+			admit := s.admitFunc(metricKey{scope: sm.Scope, name: m.Name})
+			switch a := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				a.DataPoints = limitPoints(a.DataPoints, admit, mergeGauge[int64])
+				m.Data = a
+			case metricdata.Gauge[float64]:
+				a.DataPoints = limitPoints(a.DataPoints, admit, mergeGauge[float64])
+				m.Data = a
+			case metricdata.Sum[int64]:
+				a.DataPoints = limitPoints(a.DataPoints, admit, mergeSum[int64])
+				m.Data = a
+			case metricdata.Sum[float64]:
+				a.DataPoints = limitPoints(a.DataPoints, admit, mergeSum[float64])
+				m.Data = a
+			case metricdata.Histogram[int64]:
+				a.DataPoints = limitPoints(a.DataPoints, admit, mergeHistogram[int64])
+				m.Data = a
+			case metricdata.Histogram[float64]:
+				a.DataPoints = limitPoints(a.DataPoints, admit, mergeHistogram[float64])
+				m.Data = a
+			}
+			metrics[j] = m
+		}
+		out.ScopeMetrics[i] = metricdata.ScopeMetrics{
+			Scope:   sm.Scope,
+			Metrics: metrics,
+		}
+	}
+	return out
+}
+
+// admitFunc returns a function that reports if a data point with an
+// attribute set is exported as is for the metric identified by key. Attribute
+// sets are admitted in the order they are first seen until the limit is
+// reached, and remain admitted afterwards.
+func (s *cardinalityState) admitFunc(key metricKey) func(attribute.Set) bool {
+	return func(set attribute.Set) bool {
+		admitted, ok := s.admitted[key]
+		if !ok {
+			admitted = make(map[attribute.Distinct]struct{})
+			s.admitted[key] = admitted
+		}
+		d := set.Equivalent()
+		if _, ok := admitted[d]; ok {
+			return true
+		}
+		if len(admitted) < s.limit {
+			admitted[d] = struct{}{}
+			return true
+		}
+		return false
+	}
+}
+
+// pointAttributes is implemented by the data points of the aggregations
+// limited by a CardinalityTransform.
+type pointAttributes interface {
+	metricdata.DataPoint[int64] | metricdata.DataPoint[float64] |
+		metricdata.HistogramDataPoint[int64] | metricdata.HistogramDataPoint[float64]
+}
+
+// limitPoints returns the data points in dps with an admitted attribute set
+// followed, if any were not admitted, by the result of merging all the other
+// data points with merge.
+func limitPoints[T pointAttributes](dps []T, admit func(attribute.Set) bool, merge func(dst *T, src T, first bool)) []T {
+	out := make([]T, 0, len(dps))
+	var (
+		overflow T
+		n        int
+	)
+	for _, dp := range dps {
+		if admit(attributesOf(dp)) {
+			out = append(out, dp)
+			continue
+		}
+		merge(&overflow, dp, n == 0)
+		n++
+	}
+	if n > 0 {
+		out = append(out, overflow)
+	}
+	return out
+}
+
+// attributesOf returns the attribute set of dp.
+func attributesOf[T pointAttributes](dp T) attribute.Set {
+	switch p := any(dp).(type) {
+	case metricdata.DataPoint[int64]:
+		return p.Attributes
+	case metricdata.DataPoint[float64]:
+		return p.Attributes
+	case metricdata.HistogramDataPoint[int64]:
+		return p.Attributes
+	case metricdata.HistogramDataPoint[float64]:
+		return p.Attributes
+	}
+	return *attribute.EmptySet()
+}
+
+// mergeGauge merges src into dst keeping the latest value.
+func mergeGauge[N int64 | float64](dst *metricdata.DataPoint[N], src metricdata.DataPoint[N], first bool) {
+	if first || !src.Time.Before(dst.Time) {
+		*dst = metricdata.DataPoint[N]{
+			Attributes: overflowSet,
+			StartTime:  src.StartTime,
+			Time:       src.Time,
+			Value:      src.Value,
+		}
+	}
+}
+
+// mergeSum merges src into dst adding their values.
+func mergeSum[N int64 | float64](dst *metricdata.DataPoint[N], src metricdata.DataPoint[N], first bool) {
+	if first {
+		*dst = metricdata.DataPoint[N]{
+			Attributes: overflowSet,
+			StartTime:  src.StartTime,
+			Time:       src.Time,
+			Value:      src.Value,
+		}
+		return
+	}
+	if src.StartTime.Before(dst.StartTime) {
+		dst.StartTime = src.StartTime
+	}
+	if src.Time.After(dst.Time) {
+		dst.Time = src.Time
+	}
+	dst.Value += src.Value
+}
+
+// mergeHistogram merges src into dst adding their counts, sums, and bucket
+// counts. The buckets are only merged if src and dst have the same number of
+// them.
+func mergeHistogram[N int64 | float64](dst *metricdata.HistogramDataPoint[N], src metricdata.HistogramDataPoint[N], first bool) {
+	if first {
+		*dst = metricdata.HistogramDataPoint[N]{
+			Attributes:   overflowSet,
+			StartTime:    src.StartTime,
+			Time:         src.Time,
+			Count:        src.Count,
+			Bounds:       append([]float64(nil), src.Bounds...),
+			BucketCounts: append([]uint64(nil), src.BucketCounts...),
+			Min:          src.Min,
+			Max:          src.Max,
+			Sum:          src.Sum,
+		}
+		return
+	}
+	if src.StartTime.Before(dst.StartTime) {
+		dst.StartTime = src.StartTime
+	}
+	if src.Time.After(dst.Time) {
+		dst.Time = src.Time
+	}
+	dst.Count += src.Count
+	dst.Sum += src.Sum
+	if len(src.BucketCounts) == len(dst.BucketCounts) {
+		for i, c := range src.BucketCounts {
+			dst.BucketCounts[i] += c
+		}
+	}
+	if v, ok := src.Min.Value(); ok {
+		if cur, ok := dst.Min.Value(); !ok || v < cur {
+			dst.Min = metricdata.NewExtrema(v)
+		}
+	}
+	if v, ok := src.Max.Value(); ok {
+		if cur, ok := dst.Max.Value(); !ok || v > cur {
+			dst.Max = metricdata.NewExtrema(v)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var (
+	start = time.Unix(1000, 0)
+	end   = time.Unix(1010, 0)
+)
+
+func userSet(id string) attribute.Set {
+	return attribute.NewSet(attribute.String("user", id))
+}
+
+func cardinalityInput(users ...string) *metricdata.ResourceMetrics {
+	sums := make([]metricdata.DataPoint[int64], len(users))
+	hists := make([]metricdata.HistogramDataPoint[float64], len(users))
+	for i, u := range users {
+		sums[i] = metricdata.DataPoint[int64]{
+			Attributes: userSet(u),
+			StartTime:  start,
+			Time:       end,
+			Value:      int64(i + 1),
+		}
+		hists[i] = metricdata.HistogramDataPoint[float64]{
+			Attributes:   userSet(u),
+			StartTime:    start,
+			Time:         end,
+			Count:        1,
+			Bounds:       []float64{10},
+			BucketCounts: []uint64{1, 0},
+			Min:          metricdata.NewExtrema(float64(i)),
+			Max:          metricdata.NewExtrema(float64(i)),
+			Sum:          float64(i),
+		}
+	}
+	return &metricdata.ResourceMetrics{
+		Resource: testResource,
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Scope: testScope,
+			Metrics: []metricdata.Metrics{
+				{
+					Name: "sum",
+					Data: metricdata.Sum[int64]{
+						DataPoints:  sums,
+						Temporality: metricdata.CumulativeTemporality,
+						IsMonotonic: true,
+					},
+				},
+				{
+					Name: "histogram",
+					Data: metricdata.Histogram[float64]{
+						DataPoints:  hists,
+						Temporality: metricdata.CumulativeTemporality,
+					},
+				},
+			},
+		}},
+	}
+}
+
+func TestCardinalityTransformDisabled(t *testing.T) {
+	assert.Nil(t, CardinalityTransform(0))
+	assert.Nil(t, CardinalityTransform(-1))
+}
+
+func TestCardinalityTransformUnderLimit(t *testing.T) {
+	in := cardinalityInput("a", "b", "c")
+	got := CardinalityTransform(3)(in)
+	assert.Equal(t, cardinalityInput("a", "b", "c"), got)
+}
+
+func TestCardinalityTransformOverflow(t *testing.T) {
+	in := cardinalityInput("a", "b", "c", "d")
+	got := CardinalityTransform(2)(in)
+	assert.Equal(t, cardinalityInput("a", "b", "c", "d"), in, "input modified")
+
+	sum := got.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	require.Len(t, sum.DataPoints, 3)
+	assert.Equal(t, userSet("a"), sum.DataPoints[0].Attributes)
+	assert.Equal(t, userSet("b"), sum.DataPoints[1].Attributes)
+	assert.Equal(t, metricdata.DataPoint[int64]{
+		Attributes: overflowSet,
+		StartTime:  start,
+		Time:       end,
+		// The values of "c" and "d".
+		Value: 3 + 4,
+	}, sum.DataPoints[2])
+
+	hist := got.ScopeMetrics[0].Metrics[1].Data.(metricdata.Histogram[float64])
+	require.Len(t, hist.DataPoints, 3)
+	assert.Equal(t, metricdata.HistogramDataPoint[float64]{
+		Attributes:   overflowSet,
+		StartTime:    start,
+		Time:         end,
+		Count:        2,
+		Bounds:       []float64{10},
+		BucketCounts: []uint64{2, 0},
+		Min:          metricdata.NewExtrema(2.0),
+		Max:          metricdata.NewExtrema(3.0),
+		Sum:          2 + 3,
+	}, hist.DataPoints[2])
+}
+
+func TestCardinalityTransformStable(t *testing.T) {
+	tr := CardinalityTransform(1)
+	got := tr(cardinalityInput("a", "b"))
+	sum := got.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	require.Len(t, sum.DataPoints, 2)
+	assert.Equal(t, userSet("a"), sum.DataPoints[0].Attributes)
+	assert.Equal(t, overflowSet, sum.DataPoints[1].Attributes)
+
+	// The attribute set admitted first stays admitted even if it is not the
+	// first in later exports.
+	got = tr(cardinalityInput("b", "a"))
+	sum = got.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	require.Len(t, sum.DataPoints, 2)
+	assert.Equal(t, userSet("a"), sum.DataPoints[0].Attributes)
+	assert.Equal(t, int64(2), sum.DataPoints[0].Value)
+	assert.Equal(t, overflowSet, sum.DataPoints[1].Attributes)
+	assert.Equal(t, int64(1), sum.DataPoints[1].Value)
+}
+
+func TestCardinalityTransformGauge(t *testing.T) {
+	in := &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{{
+				Name: "gauge",
+				Data: metricdata.Gauge[float64]{
+					DataPoints: []metricdata.DataPoint[float64]{
+						{Attributes: userSet("a"), Time: end, Value: 1},
+						{Attributes: userSet("b"), Time: end.Add(time.Second), Value: 2},
+						{Attributes: userSet("c"), Time: end, Value: 3},
+					},
+				},
+			}},
+		}},
+	}
+	got := CardinalityTransform(1)(in)
+	gauge := got.ScopeMetrics[0].Metrics[0].Data.(metricdata.Gauge[float64])
+	require.Len(t, gauge.DataPoints, 2)
+	// The latest value of "b" and "c".
+	assert.Equal(t, metricdata.DataPoint[float64]{
+		Attributes: overflowSet,
+		Time:       end.Add(time.Second),
+		Value:      2,
+	}, gauge.DataPoints[1])
+}
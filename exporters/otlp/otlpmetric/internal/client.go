@@ -56,3 +56,29 @@ type Client interface {
 	// after this is called so the Client can be garbage collected.
 	Shutdown(context.Context) error
 }
+
+// NewNoopClient returns a Client that discards all metric data. It uses the
+// temporality and aggregation selectors to determine the Temporality and
+// Aggregation of instrument kinds.
+func NewNoopClient(temporality metric.TemporalitySelector, aggregation metric.AggregationSelector) Client {
+	return noopClient{temporality: temporality, aggregation: aggregation}
+}
+
+type noopClient struct {
+	temporality metric.TemporalitySelector
+	aggregation metric.AggregationSelector
+}
+
+func (c noopClient) Temporality(k metric.InstrumentKind) metricdata.Temporality {
+	return c.temporality(k)
+}
+
+func (c noopClient) Aggregation(k metric.InstrumentKind) aggregation.Aggregation {
+	return c.aggregation(k)
+}
+
+func (noopClient) UploadMetrics(context.Context, *mpb.ResourceMetrics) error { return nil }
+
+func (noopClient) ForceFlush(context.Context) error { return nil }
+
+func (noopClient) Shutdown(context.Context) error { return nil }
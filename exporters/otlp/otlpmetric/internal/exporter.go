@@ -18,8 +18,14 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
+	"google.golang.org/protobuf/proto"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/transform"
+	"go.opentelemetry.io/otel/internal/global"
+	metricapi "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/aggregation"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
@@ -29,9 +35,26 @@ import (
 // exporter exports metrics data as OTLP.
 type exporter struct {
 	// Ensure synchronous access to the client across all functionality.
-	clientMu sync.Mutex
+	// Uploads only hold a read lock when exportSem limits their number,
+	// otherwise they hold the lock and are made one at a time.
+	clientMu sync.RWMutex
 	client   Client
 
+	// transforms are applied in order to all exported metric data.
+	transforms []Transform
+	// limiter, if not nil, limits the bytes of exports in flight.
+	limiter *byteLimiter
+	// selfMetrics, if not nil, records metrics about the exports made.
+	selfMetrics *selfMetrics
+	// maxPayloadBytes, if positive, is the maximum serialized size of a
+	// single upload. Larger exports are split into multiple uploads.
+	maxPayloadBytes int
+	// exportSem, if not nil, limits the number of exports in flight to its
+	// capacity.
+	exportSem chan struct{}
+	// forceFlushTimeout, if positive, is the maximum time ForceFlush waits.
+	forceFlushTimeout time.Duration
+
 	shutdownOnce sync.Once
 }
 
@@ -51,26 +74,120 @@ func (e *exporter) Aggregation(k metric.InstrumentKind) aggregation.Aggregation
 
 // Export transforms and transmits metric data to an OTLP receiver.
 func (e *exporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	if e.selfMetrics == nil {
+		_, err := e.export(ctx, rm)
+		return err
+	}
+
+	start := time.Now()
+	e.selfMetrics.attempts.Add(context.Background(), 1)
+	reason, err := e.export(ctx, rm)
+	e.selfMetrics.recordExport(start, reason)
+	return err
+}
+
+// export transforms and transmits rm. If the export fails, the reason it
+// failed is returned along with the error.
+func (e *exporter) export(ctx context.Context, rm *metricdata.ResourceMetrics) (string, error) {
+	if e.exportSem != nil {
+		select {
+		case e.exportSem <- struct{}{}:
+			defer func() { <-e.exportSem }()
+		case <-ctx.Done():
+			return reasonInFlightLimit, fmt.Errorf("failed to upload metrics: %w", ctx.Err())
+		}
+	}
+	for _, t := range e.transforms {
+		rm = t(rm)
+	}
 	otlpRm, err := transform.ResourceMetrics(rm)
+	if e.limiter != nil {
+		n := int64(proto.Size(otlpRm))
+		if lErr := e.limiter.acquire(ctx, n); lErr != nil {
+			return reasonInFlightLimit, fmt.Errorf("failed to upload metrics: %w", lErr)
+		}
+		defer e.limiter.release(n)
+	}
 	// Best effort upload of transformable metrics.
-	e.clientMu.Lock()
-	upErr := e.client.UploadMetrics(ctx, otlpRm)
-	e.clientMu.Unlock()
+	upErr := e.upload(ctx, otlpRm)
 	if upErr != nil {
+		reason := uploadFailureReason(upErr)
 		if err == nil {
-			return fmt.Errorf("failed to upload metrics: %w", upErr)
+			return reason, fmt.Errorf("failed to upload metrics: %w", upErr)
 		}
 		// Merge the two errors.
-		return fmt.Errorf("failed to upload incomplete metrics (%s): %w", err, upErr)
+		return reason, fmt.Errorf("failed to upload incomplete metrics (%s): %w", err, upErr)
+	}
+	if err != nil {
+		return reasonTransform, err
+	}
+	return "", nil
+}
+
+// upload transmits rm with the client. If rm exceeds the maximum payload size
+// it is split and each part is uploaded in order. All parts are attempted and
+// the first error encountered is returned.
+func (e *exporter) upload(ctx context.Context, rm *mpb.ResourceMetrics) error {
+	if e.exportSem != nil {
+		// The number of concurrent uploads is bounded by exportSem.
+		e.clientMu.RLock()
+		defer e.clientMu.RUnlock()
+	} else {
+		e.clientMu.Lock()
+		defer e.clientMu.Unlock()
+	}
+
+	if e.maxPayloadBytes <= 0 {
+		return e.client.UploadMetrics(ctx, rm)
+	}
+
+	parts := splitResourceMetrics(rm, e.maxPayloadBytes)
+	var (
+		failed int
+		err    error
+	)
+	for _, part := range parts {
+		if upErr := e.client.UploadMetrics(ctx, part); upErr != nil {
+			failed++
+			if err == nil {
+				err = upErr
+			}
+		}
+	}
+	if failed > 0 && len(parts) > 1 {
+		return fmt.Errorf("%d of %d requests failed: %w", failed, len(parts), err)
 	}
 	return err
 }
 
-// ForceFlush flushes any metric data held by an exporter.
+// ForceFlush flushes any metric data held by an exporter. It waits for the
+// uploads in progress, if any, to complete unless ctx is done first or the
+// force flush timeout of the exporter elapses.
 func (e *exporter) ForceFlush(ctx context.Context) error {
-	// The Exporter does not hold data, forward the command to the client.
-	e.clientMu.Lock()
+	if e.forceFlushTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.forceFlushTimeout)
+		defer cancel()
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		e.clientMu.Lock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-ctx.Done():
+		// Release the lock once the uploads in progress complete.
+		go func() {
+			<-locked
+			e.clientMu.Unlock()
+		}()
+		return ctx.Err()
+	}
 	defer e.clientMu.Unlock()
+
+	// The Exporter does not hold data, forward the command to the client.
 	return e.client.ForceFlush(ctx)
 }
 
@@ -93,11 +210,97 @@ func (e *exporter) Shutdown(ctx context.Context) error {
 	return err
 }
 
+// Option configures an Exporter returned by New.
+type Option func(*exporter)
+
+// WithTransforms returns an Option that applies transforms in order to all
+// metric data before it is transformed into OTLP.
+func WithTransforms(transforms ...Transform) Option {
+	return func(e *exporter) {
+		e.transforms = append(e.transforms, transforms...)
+	}
+}
+
+// WithMaxConcurrentExports returns an Option that allows up to n exports to
+// be in flight and their uploads to be made concurrently. An export exceeding
+// the limit waits until another export completes or its context is done. If n
+// is not positive, no limit is applied and uploads are made one at a time.
+func WithMaxConcurrentExports(n int) Option {
+	return func(e *exporter) {
+		if n > 0 {
+			e.exportSem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithForceFlushTimeout returns an Option that limits the time ForceFlush
+// waits to d. If d is not positive, ForceFlush waits until its context is
+// done.
+func WithForceFlushTimeout(d time.Duration) Option {
+	return func(e *exporter) {
+		if d > 0 {
+			e.forceFlushTimeout = d
+		}
+	}
+}
+
+// WithMaxInFlightBytes returns an Option that limits the serialized size of
+// all exports in flight to n bytes. If drop is true, an export that would
+// exceed the limit fails with ErrInFlightLimit. Otherwise, the export waits
+// for enough in-flight exports to complete. If n is not positive, no limit is
+// applied.
+func WithMaxInFlightBytes(n int64, drop bool) Option {
+	return func(e *exporter) {
+		if n > 0 {
+			e.limiter = newByteLimiter(n, drop)
+		}
+	}
+}
+
+// WithSelfMetrics returns an Option that records metrics about the exports
+// made with instruments created by mp. The instrument names start with
+// prefix. If mp is nil, no metrics are recorded.
+//
+// The metrics are recorded synchronously during each export. If mp is the
+// MeterProvider whose metric data is exported, every export produces new
+// metric data for the next one.
+func WithSelfMetrics(mp metricapi.MeterProvider, prefix string) Option {
+	return func(e *exporter) {
+		if mp == nil {
+			return
+		}
+		meter := mp.Meter(
+			SelfMetricsScopeName,
+			metricapi.WithInstrumentationVersion(otlpmetric.Version()),
+		)
+		m, err := newSelfMetrics(meter, prefix)
+		if err != nil {
+			global.Error(err, "self-metrics disabled")
+			return
+		}
+		e.selfMetrics = m
+	}
+}
+
+// WithMaxPayloadBytes returns an Option that splits exports whose serialized
+// size exceeds n bytes into multiple uploads. The resource and the grouping of
+// metrics by scope are preserved in each upload. A data point that exceeds n
+// bytes on its own is dropped. If n is not positive, exports are not split.
+func WithMaxPayloadBytes(n int) Option {
+	return func(e *exporter) {
+		e.maxPayloadBytes = n
+	}
+}
+
 // New return an Exporter that uses client to transmits the OTLP data it
 // produces. The client is assumed to be fully started and able to communicate
 // with its OTLP receiving endpoint.
-func New(client Client) metric.Exporter {
-	return &exporter{client: client}
+func New(client Client, opts ...Option) metric.Exporter {
+	e := &exporter{client: client}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 type shutdownClient struct {
@@ -17,9 +17,12 @@ package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/i
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/aggregation"
@@ -98,3 +101,130 @@ func TestExporterClientConcurrency(t *testing.T) {
 	close(done)
 	wg.Wait()
 }
+
+// inFlightClient tracks the number of exports in flight. An export is counted
+// from when it is transformed until its upload completes.
+type inFlightClient struct {
+	client
+
+	inFlight, max atomic.Int64
+}
+
+func (c *inFlightClient) transform(rm *metricdata.ResourceMetrics) *metricdata.ResourceMetrics {
+	n := c.inFlight.Add(1)
+	for {
+		m := c.max.Load()
+		if n <= m || c.max.CompareAndSwap(m, n) {
+			return rm
+		}
+	}
+}
+
+func (c *inFlightClient) UploadMetrics(context.Context, *mpb.ResourceMetrics) error {
+	time.Sleep(time.Millisecond)
+	c.inFlight.Add(-1)
+	return nil
+}
+
+func TestExporterMaxConcurrentExports(t *testing.T) {
+	const (
+		limit      = 2
+		goroutines = 10
+	)
+
+	c := new(inFlightClient)
+	exp := New(c, WithTransforms(c.transform), WithMaxConcurrentExports(limit))
+	rm := new(metricdata.ResourceMetrics)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				assert.NoError(t, exp.Export(ctx, rm))
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.max.Load(), int64(limit))
+	assert.Equal(t, int64(0), c.inFlight.Load())
+}
+
+func TestExporterMaxConcurrentExportsUploadsConcurrently(t *testing.T) {
+	const limit = 2
+
+	c := &blockingClient{
+		started: make(chan struct{}, limit),
+		unblock: make(chan struct{}),
+	}
+	exp := New(c, WithMaxConcurrentExports(limit))
+	rm := new(metricdata.ResourceMetrics)
+	ctx := context.Background()
+
+	done := make(chan error, limit)
+	for i := 0; i < limit; i++ {
+		go func() { done <- exp.Export(ctx, rm) }()
+	}
+	// Both uploads start before either is unblocked.
+	for i := 0; i < limit; i++ {
+		select {
+		case <-c.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("uploads are not made concurrently")
+		}
+	}
+
+	close(c.unblock)
+	for i := 0; i < limit; i++ {
+		assert.NoError(t, <-done)
+	}
+}
+
+func TestExporterMaxConcurrentExportsCanceled(t *testing.T) {
+	c := &blockingClient{
+		started: make(chan struct{}, 1),
+		unblock: make(chan struct{}),
+	}
+	exp := New(c, WithMaxConcurrentExports(1))
+	rm := new(metricdata.ResourceMetrics)
+	ctx := context.Background()
+
+	done := make(chan error, 1)
+	go func() { done <- exp.Export(ctx, rm) }()
+	<-c.started
+
+	// The limit is reached, waiting exports return when canceled.
+	canceled, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, exp.Export(canceled, rm), context.DeadlineExceeded)
+
+	close(c.unblock)
+	require.NoError(t, <-done)
+	assert.NoError(t, exp.Export(ctx, rm), "completion did not free the limit")
+}
+
+func TestExporterForceFlushTimeout(t *testing.T) {
+	c := &blockingClient{
+		started: make(chan struct{}, 1),
+		unblock: make(chan struct{}),
+	}
+	exp := New(c, WithForceFlushTimeout(10*time.Millisecond))
+	rm := new(metricdata.ResourceMetrics)
+	ctx := context.Background()
+
+	// The export is not bounded by the force flush timeout.
+	done := make(chan error, 1)
+	go func() { done <- exp.Export(ctx, rm) }()
+	<-c.started
+
+	start := time.Now()
+	assert.ErrorIs(t, exp.ForceFlush(ctx), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	close(c.unblock)
+	require.NoError(t, <-done)
+	assert.NoError(t, exp.ForceFlush(ctx), "upload did not release the client")
+}
@@ -0,0 +1,90 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrInFlightLimit is returned when an export is dropped because the limit of
+// in-flight bytes has been reached.
+var ErrInFlightLimit = errors.New("in-flight bytes limit reached")
+
+// byteLimiter limits the number of serialized bytes of exports that are in
+// flight at the same time.
+type byteLimiter struct {
+	limit int64
+	// drop is true if exports exceeding the limit are dropped instead of
+	// waiting for in-flight exports to complete.
+	drop bool
+
+	mu   sync.Mutex
+	used int64
+	// freed is closed, and replaced, each time bytes are released.
+	freed chan struct{}
+}
+
+func newByteLimiter(limit int64, drop bool) *byteLimiter {
+	return &byteLimiter{limit: limit, drop: drop, freed: make(chan struct{})}
+}
+
+// acquire reserves n bytes. If the reservation would exceed the limit, either
+// ErrInFlightLimit is returned if exports are dropped, or this blocks until
+// enough bytes are released or ctx is done.
+//
+// An export larger than the limit is allowed when no other export is in
+// flight so it does not wait forever.
+func (l *byteLimiter) acquire(ctx context.Context, n int64) error {
+	for {
+		l.mu.Lock()
+		if l.used == 0 || l.used+n <= l.limit {
+			l.used += n
+			l.mu.Unlock()
+			return nil
+		}
+		if l.drop {
+			used := l.used
+			l.mu.Unlock()
+			return fmt.Errorf("%w: %d bytes in flight, %d bytes requested, limit %d bytes", ErrInFlightLimit, used, n, l.limit)
+		}
+		freed := l.freed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-freed:
+		}
+	}
+}
+
+// release frees n previously acquired bytes.
+func (l *byteLimiter) release(n int64) {
+	l.mu.Lock()
+	l.used -= n
+	close(l.freed)
+	l.freed = make(chan struct{})
+	l.mu.Unlock()
+}
+
+// inUse returns the number of bytes currently acquired.
+func (l *byteLimiter) inUse() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.used
+}
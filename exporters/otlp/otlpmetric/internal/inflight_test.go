@@ -0,0 +1,119 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	mpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+func TestByteLimiterDrop(t *testing.T) {
+	ctx := context.Background()
+	l := newByteLimiter(10, true)
+
+	require.NoError(t, l.acquire(ctx, 6))
+	assert.ErrorIs(t, l.acquire(ctx, 6), ErrInFlightLimit)
+	assert.NoError(t, l.acquire(ctx, 4))
+	assert.Equal(t, int64(10), l.inUse())
+
+	l.release(6)
+	assert.NoError(t, l.acquire(ctx, 6), "completion did not free budget")
+	l.release(6)
+	l.release(4)
+	assert.Equal(t, int64(0), l.inUse())
+
+	// An oversized request is allowed if nothing else is in flight.
+	assert.NoError(t, l.acquire(ctx, 100))
+	assert.ErrorIs(t, l.acquire(ctx, 1), ErrInFlightLimit)
+}
+
+func TestByteLimiterBlock(t *testing.T) {
+	ctx := context.Background()
+	l := newByteLimiter(10, false)
+	require.NoError(t, l.acquire(ctx, 8))
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- l.acquire(ctx, 8) }()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquire did not block at limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	l.release(8)
+	select {
+	case err := <-acquired:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("completion did not free budget")
+	}
+	assert.Equal(t, int64(8), l.inUse())
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, l.acquire(canceled, 8), context.Canceled)
+}
+
+// blockingClient blocks uploads until unblock is closed.
+type blockingClient struct {
+	client
+
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (c *blockingClient) UploadMetrics(context.Context, *mpb.ResourceMetrics) error {
+	c.started <- struct{}{}
+	<-c.unblock
+	return nil
+}
+
+func TestExporterMaxInFlightBytes(t *testing.T) {
+	ctx := context.Background()
+	c := &blockingClient{
+		started: make(chan struct{}, 1),
+		unblock: make(chan struct{}),
+	}
+	exp := New(c, WithMaxInFlightBytes(1, true))
+	rm := &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{{
+				Name: "requests",
+				Data: metricdata.Gauge[int64]{
+					DataPoints: []metricdata.DataPoint[int64]{{Value: 1}},
+				},
+			}},
+		}},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- exp.Export(ctx, rm) }()
+	<-c.started
+
+	// The first export is in flight and uses all the budget.
+	assert.ErrorIs(t, exp.Export(ctx, rm), ErrInFlightLimit)
+
+	close(c.unblock)
+	require.NoError(t, <-done)
+	assert.NoError(t, exp.Export(ctx, rm), "completion did not free budget")
+}
@@ -0,0 +1,180 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/internal/global"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// MonotonicViolationPolicy describes how a cumulative monotonic sum data
+// point with a value less than the previously exported value of its series is
+// handled.
+type MonotonicViolationPolicy int
+
+const (
+	// PassThroughMonotonicViolation exports the decreased value.
+	PassThroughMonotonicViolation MonotonicViolationPolicy = iota
+	// DropMonotonicViolation does not export the data point.
+	DropMonotonicViolation
+	// ClampMonotonicViolation exports the previous value of the series
+	// instead of the decreased value.
+	ClampMonotonicViolation
+)
+
+func (p MonotonicViolationPolicy) String() string {
+	switch p {
+	case DropMonotonicViolation:
+		return "drop"
+	case ClampMonotonicViolation:
+		return "clamp"
+	default:
+		return "pass-through"
+	}
+}
+
+// seriesKey uniquely identifies a timeseries.
+type seriesKey struct {
+	scope instrumentation.Scope
+	name  string
+	attrs attribute.Distinct
+}
+
+// series is the last exported state of a timeseries.
+type series[N int64 | float64] struct {
+	start time.Time
+	value N
+	// warned is true if a decrease below value has been logged.
+	warned bool
+}
+
+// monotonicState tracks the last exported value of cumulative monotonic sum
+// timeseries.
+type monotonicState struct {
+	policy MonotonicViolationPolicy
+
+	mu     sync.Mutex
+	ints   map[seriesKey]series[int64]
+	floats map[seriesKey]series[float64]
+}
+
+// MonotonicTransform returns a Transform that handles cumulative monotonic
+// sum data points with a value less than the last exported value of their
+// series according to policy. A warning is logged the first time a series
+// decreases below its last exported value. Metrics and scopes left without
+// data by dropped data points are removed.
+//
+// A data point with a start time different from the last exported data point
+// of its series is considered a reset of the series, not a violation.
+func MonotonicTransform(policy MonotonicViolationPolicy) Transform {
+	s := &monotonicState{
+		policy: policy,
+		ints:   make(map[seriesKey]series[int64]),
+		floats: make(map[seriesKey]series[float64]),
+	}
+	return s.transform
+}
+
+func (s *monotonicState) transform(rm *metricdata.ResourceMetrics) *metricdata.ResourceMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := &metricdata.ResourceMetrics{
+		Resource:     rm.Resource,
+		ScopeMetrics: make([]metricdata.ScopeMetrics, 0, len(rm.ScopeMetrics)),
+	}
+	for _, sm := range rm.ScopeMetrics {
+		metrics := make([]metricdata.Metrics, 0, len(sm.Metrics))
+		for _, m := range sm.Metrics {
+			switch a := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				if a.IsMonotonic && a.Temporality == metricdata.CumulativeTemporality {
+					n := len(a.DataPoints)
+					a.DataPoints = checkMonotonic(s.ints, s.policy, sm.Scope, m.Name, a.DataPoints)
+					if n > 0 && len(a.DataPoints) == 0 {
+						// All data points were dropped.
+						continue
+					}
+					m.Data = a
+				}
+			case metricdata.Sum[float64]:
+				if a.IsMonotonic && a.Temporality == metricdata.CumulativeTemporality {
+					n := len(a.DataPoints)
+					a.DataPoints = checkMonotonic(s.floats, s.policy, sm.Scope, m.Name, a.DataPoints)
+					if n > 0 && len(a.DataPoints) == 0 {
+						// All data points were dropped.
+						continue
+					}
+					m.Data = a
+				}
+			}
+			metrics = append(metrics, m)
+		}
+		if len(sm.Metrics) > 0 && len(metrics) == 0 {
+			// All metrics were removed.
+			continue
+		}
+		out.ScopeMetrics = append(out.ScopeMetrics, metricdata.ScopeMetrics{
+			Scope:   sm.Scope,
+			Metrics: metrics,
+		})
+	}
+	return out
+}
+
+// checkMonotonic returns a copy of dps with violations handled according to
+// policy. The state of each series in last is updated.
+func checkMonotonic[N int64 | float64](last map[seriesKey]series[N], policy MonotonicViolationPolicy, scope instrumentation.Scope, name string, dps []metricdata.DataPoint[N]) []metricdata.DataPoint[N] {
+	out := make([]metricdata.DataPoint[N], 0, len(dps))
+	for _, dp := range dps {
+		key := seriesKey{scope: scope, name: name, attrs: dp.Attributes.Equivalent()}
+		prev, ok := last[key]
+		if !ok || !prev.start.Equal(dp.StartTime) || dp.Value >= prev.value {
+			last[key] = series[N]{start: dp.StartTime, value: dp.Value}
+			out = append(out, dp)
+			continue
+		}
+
+		if !prev.warned {
+			// Only warn once until the series recovers, a series that stays
+			// below its last exported value would otherwise warn on every
+			// export.
+			global.Warn(
+				"monotonic sum decreased",
+				"metric", name,
+				"previous", prev.value,
+				"value", dp.Value,
+				"policy", policy.String(),
+			)
+			prev.warned = true
+			last[key] = prev
+		}
+		switch policy {
+		case DropMonotonicViolation:
+			continue
+		case ClampMonotonicViolation:
+			dp.Value = prev.value
+		default:
+			last[key] = series[N]{start: dp.StartTime, value: dp.Value}
+		}
+		out = append(out, dp)
+	}
+	return out
+}
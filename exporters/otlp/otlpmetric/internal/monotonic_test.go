@@ -0,0 +1,185 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/go-logr/stdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+var (
+	monoScope = instrumentation.Scope{Name: "test"}
+	monoAttrs = attribute.NewSet(attribute.String("user", "alice"))
+	monoStart = time.Unix(1000, 0)
+)
+
+func cumulativeSum(start time.Time, v int64) *metricdata.ResourceMetrics {
+	return &metricdata.ResourceMetrics{
+		Resource: resource.Empty(),
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Scope: monoScope,
+			Metrics: []metricdata.Metrics{{
+				Name: "requests",
+				Data: metricdata.Sum[int64]{
+					Temporality: metricdata.CumulativeTemporality,
+					IsMonotonic: true,
+					DataPoints: []metricdata.DataPoint[int64]{{
+						Attributes: monoAttrs,
+						StartTime:  start,
+						Value:      v,
+					}},
+				},
+			}},
+		}},
+	}
+}
+
+// exported returns the values of the data points exported by t for in. If
+// nothing is exported, nil is returned.
+func exported(t *testing.T, tr Transform, in *metricdata.ResourceMetrics) []int64 {
+	t.Helper()
+
+	out := tr(in)
+	if len(out.ScopeMetrics) == 0 {
+		return nil
+	}
+	require.Len(t, out.ScopeMetrics, 1)
+	require.Len(t, out.ScopeMetrics[0].Metrics, 1)
+	var values []int64
+	for _, dp := range out.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64]).DataPoints {
+		values = append(values, dp.Value)
+	}
+	return values
+}
+
+func TestMonotonicTransform(t *testing.T) {
+	tests := []struct {
+		policy MonotonicViolationPolicy
+		// want are the values exported for the inputs 10, 5, 7, 12.
+		want [][]int64
+	}{
+		{
+			policy: PassThroughMonotonicViolation,
+			// 7 is an increase from the passed through 5.
+			want: [][]int64{{10}, {5}, {7}, {12}},
+		},
+		{
+			policy: DropMonotonicViolation,
+			want:   [][]int64{{10}, nil, nil, {12}},
+		},
+		{
+			policy: ClampMonotonicViolation,
+			want:   [][]int64{{10}, {10}, {10}, {12}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			tr := MonotonicTransform(test.policy)
+			for i, v := range []int64{10, 5, 7, 12} {
+				in := cumulativeSum(monoStart, v)
+				assert.Equal(t, test.want[i], exported(t, tr, in), "input %d", v)
+				// The input is never modified.
+				dp := in.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64]).DataPoints[0]
+				assert.Equal(t, v, dp.Value)
+			}
+		})
+	}
+}
+
+func TestMonotonicTransformReset(t *testing.T) {
+	tr := MonotonicTransform(DropMonotonicViolation)
+	assert.Equal(t, []int64{10}, exported(t, tr, cumulativeSum(monoStart, 10)))
+	// A new start time is a reset of the series, not a violation.
+	restart := monoStart.Add(time.Hour)
+	assert.Equal(t, []int64{1}, exported(t, tr, cumulativeSum(restart, 1)))
+	assert.Equal(t, []int64(nil), exported(t, tr, cumulativeSum(restart, 0)))
+}
+
+func TestMonotonicTransformIgnoresNonMonotonic(t *testing.T) {
+	tr := MonotonicTransform(DropMonotonicViolation)
+	in := cumulativeSum(monoStart, 10)
+	nonMono := func(v int64) *metricdata.ResourceMetrics {
+		rm := cumulativeSum(monoStart, v)
+		sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+		sum.IsMonotonic = false
+		rm.ScopeMetrics[0].Metrics[0].Data = sum
+		return rm
+	}
+	assert.Equal(t, []int64{10}, exported(t, tr, in))
+	assert.Equal(t, []int64{5}, exported(t, tr, nonMono(5)))
+}
+
+func TestMonotonicTransformDropRemovesEmpty(t *testing.T) {
+	tr := MonotonicTransform(DropMonotonicViolation)
+	_ = tr(cumulativeSum(monoStart, 10))
+
+	in := cumulativeSum(monoStart, 5)
+	gauge := metricdata.Metrics{
+		Name: "temperature",
+		Data: metricdata.Gauge[int64]{
+			DataPoints: []metricdata.DataPoint[int64]{{Value: 20}},
+		},
+	}
+	other := metricdata.ScopeMetrics{
+		Scope:   instrumentation.Scope{Name: "other"},
+		Metrics: []metricdata.Metrics{gauge},
+	}
+	in.ScopeMetrics = append(in.ScopeMetrics, other)
+
+	out := tr(in)
+	// The scope whose only metric had all its data points dropped is removed.
+	require.Len(t, out.ScopeMetrics, 1)
+	assert.Equal(t, other, out.ScopeMetrics[0])
+
+	// Other metrics of a scope are kept.
+	in = cumulativeSum(monoStart, 5)
+	in.ScopeMetrics[0].Metrics = append(in.ScopeMetrics[0].Metrics, gauge)
+	out = tr(in)
+	require.Len(t, out.ScopeMetrics, 1)
+	assert.Equal(t, []metricdata.Metrics{gauge}, out.ScopeMetrics[0].Metrics)
+}
+
+func TestMonotonicTransformWarnsOnce(t *testing.T) {
+	var logged []string
+	otel.SetLogger(funcr.New(func(prefix, args string) {
+		logged = append(logged, args)
+	}, funcr.Options{Verbosity: 1}))
+	t.Cleanup(func() { otel.SetLogger(stdr.New(log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile))) })
+
+	tr := MonotonicTransform(ClampMonotonicViolation)
+	for _, v := range []int64{10, 5, 7, 5} {
+		_ = tr(cumulativeSum(monoStart, v))
+	}
+	assert.Len(t, logged, 1, "series below its last value warned more than once")
+
+	// An increase ends the violation, a new decrease is warned about.
+	_ = tr(cumulativeSum(monoStart, 12))
+	_ = tr(cumulativeSum(monoStart, 11))
+	assert.Len(t, logged, 2)
+}
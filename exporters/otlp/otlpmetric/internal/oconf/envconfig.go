@@ -17,13 +17,18 @@ package oconf // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/inte
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
+	"fmt"
 	"net/url"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel/exporters/otlp/internal/envconfig"
+	"go.opentelemetry.io/otel/internal/global"
+	"go.opentelemetry.io/otel/sdk/metric"
 )
 
 // DefaultEnvOptionsReader is the default environments reader.
@@ -83,19 +88,24 @@ func getOptionsFromEnv() []GenericOption {
 				return cfg
 			}, withEndpointForGRPC(u)))
 		}),
-		envconfig.WithCertPool("CERTIFICATE", func(p *x509.CertPool) { tlsConf.RootCAs = p }),
-		envconfig.WithCertPool("METRICS_CERTIFICATE", func(p *x509.CertPool) { tlsConf.RootCAs = p }),
+		withEnvCertPool("CERTIFICATE", func(p *x509.CertPool) { tlsConf.RootCAs = p }),
+		withEnvCertPool("METRICS_CERTIFICATE", func(p *x509.CertPool) { tlsConf.RootCAs = p }),
 		envconfig.WithClientCert("CLIENT_CERTIFICATE", "CLIENT_KEY", func(c tls.Certificate) { tlsConf.Certificates = []tls.Certificate{c} }),
 		envconfig.WithClientCert("METRICS_CLIENT_CERTIFICATE", "METRICS_CLIENT_KEY", func(c tls.Certificate) { tlsConf.Certificates = []tls.Certificate{c} }),
-		envconfig.WithBool("INSECURE", func(b bool) { opts = append(opts, withInsecure(b)) }),
-		envconfig.WithBool("METRICS_INSECURE", func(b bool) { opts = append(opts, withInsecure(b)) }),
+		withEnvInsecure("INSECURE", func(b bool) { opts = append(opts, withInsecure(b)) }),
+		withEnvInsecure("METRICS_INSECURE", func(b bool) { opts = append(opts, withInsecure(b)) }),
 		withTLSConfig(tlsConf, func(c *tls.Config) { opts = append(opts, WithTLSClientConfig(c)) }),
-		envconfig.WithHeaders("HEADERS", func(h map[string]string) { opts = append(opts, WithHeaders(h)) }),
-		envconfig.WithHeaders("METRICS_HEADERS", func(h map[string]string) { opts = append(opts, WithHeaders(h)) }),
+		envconfig.WithHeaders("HEADERS", func(h map[string]string) { opts = append(opts, WithHeadersReplace(expandHeaders(h))) }),
+		envconfig.WithHeaders("METRICS_HEADERS", func(h map[string]string) { opts = append(opts, WithHeadersReplace(expandHeaders(h))) }),
 		WithEnvCompression("COMPRESSION", func(c Compression) { opts = append(opts, WithCompression(c)) }),
 		WithEnvCompression("METRICS_COMPRESSION", func(c Compression) { opts = append(opts, WithCompression(c)) }),
 		envconfig.WithDuration("TIMEOUT", func(d time.Duration) { opts = append(opts, WithTimeout(d)) }),
 		envconfig.WithDuration("METRICS_TIMEOUT", func(d time.Duration) { opts = append(opts, WithTimeout(d)) }),
+		withEnvProtocol("PROTOCOL", func(p string) { opts = append(opts, withProtocolEncoding(p)) }),
+		withEnvProtocol("METRICS_PROTOCOL", func(p string) { opts = append(opts, withProtocolEncoding(p)) }),
+		withEnvTemporalityPreference("METRICS_TEMPORALITY_PREFERENCE", func(s metric.TemporalitySelector) { opts = append(opts, WithTemporalitySelector(s)) }),
+		withEnvCardinalityLimit("METRICS_CARDINALITY_LIMIT", func(n int) { opts = append(opts, WithCardinalityLimit(n)) }),
+		withEnvAggPreference("METRICS_DEFAULT_HISTOGRAM_AGGREGATION", func(s metric.AggregationSelector) { opts = append(opts, WithAggregationSelector(s)) }),
 	)
 
 	return opts
@@ -105,25 +115,212 @@ func withEndpointForGRPC(u *url.URL) func(cfg Config) Config {
 	return func(cfg Config) Config {
 		// For OTLP/gRPC endpoints, this is the target to which the
 		// exporter is going to send telemetry.
+		if isResolverTarget(u.String()) {
+			// Name resolver targets need to be passed to gRPC intact.
+			cfg.Metrics.Endpoint = u.String()
+			return cfg
+		}
 		cfg.Metrics.Endpoint = path.Join(u.Host, u.Path)
 		return cfg
 	}
 }
 
+// expandHeaders returns a copy of headers with all "${VAR}" references in the
+// header values replaced with the value of the VAR environment variable. The
+// sequence "$$" is replaced with a literal "$". Headers that reference an
+// unset environment variable are not included and an error is logged.
+func expandHeaders(headers map[string]string) map[string]string {
+	expanded := make(map[string]string, len(headers))
+	for k, v := range headers {
+		val, err := expandEnv(v, DefaultEnvOptionsReader.GetEnv)
+		if err != nil {
+			// Do not log the value, it likely contains a secret.
+			global.Error(err, "expand header value", "header", k)
+			continue
+		}
+		expanded[k] = val
+	}
+	return expanded
+}
+
+// expandEnv replaces all "${VAR}" references in s with the value returned
+// from getEnv for VAR, and all "$$" sequences with "$". An error is returned
+// if a reference is not terminated or a referenced variable is empty.
+func expandEnv(s string, getEnv func(string) string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable reference: %q", s[i:])
+			}
+			name := s[i+2 : i+2+end]
+			v := getEnv(name)
+			if v == "" {
+				return "", fmt.Errorf("environment variable %q is not set", name)
+			}
+			b.WriteString(v)
+			i += end + 2
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
 // WithEnvCompression retrieves the specified config and passes it to ConfigFn as a Compression.
+// Values other than "gzip" or "none" (case-insensitive) are logged and
+// ignored.
 func WithEnvCompression(n string, fn func(Compression)) func(e *envconfig.EnvOptionsReader) {
 	return func(e *envconfig.EnvOptionsReader) {
 		if v, ok := e.GetEnvValue(n); ok {
-			cp := NoCompression
-			if v == "gzip" {
-				cp = GzipCompression
+			switch strings.ToLower(v) {
+			case "gzip":
+				fn(GzipCompression)
+			case "none":
+				fn(NoCompression)
+			default:
+				err := fmt.Errorf("invalid compression: %q", v)
+				global.Error(err, "parse compression", "variable", e.Namespace+"_"+n)
 			}
+		}
+	}
+}
 
-			fn(cp)
+// withEnvInsecure retrieves the specified config and passes it to fn as a
+// bool. Values other than "true" or "false" (case-insensitive) are logged and
+// ignored.
+func withEnvInsecure(n string, fn func(bool)) func(e *envconfig.EnvOptionsReader) {
+	return func(e *envconfig.EnvOptionsReader) {
+		if v, ok := e.GetEnvValue(n); ok {
+			switch strings.ToLower(v) {
+			case "true":
+				fn(true)
+			case "false":
+				fn(false)
+			default:
+				err := fmt.Errorf("invalid bool value: %q", v)
+				global.Error(err, "parse insecure", "variable", e.Namespace+"_"+n)
+			}
+		}
+	}
+}
+
+// withEnvTemporalityPreference retrieves the specified config and passes the
+// matching temporality preset to fn. Invalid values are logged and ignored.
+func withEnvTemporalityPreference(n string, fn func(metric.TemporalitySelector)) func(e *envconfig.EnvOptionsReader) {
+	return func(e *envconfig.EnvOptionsReader) {
+		if v, ok := e.GetEnvValue(n); ok {
+			switch strings.ToLower(v) {
+			case "cumulative":
+				fn(CumulativeTemporality)
+			case "delta":
+				fn(DeltaTemporality)
+			case "lowmemory":
+				fn(LowMemoryTemporality)
+			default:
+				global.Warn("OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE is set to an invalid value, ignoring.", "value", v)
+			}
 		}
 	}
 }
 
+// withEnvCertPool retrieves the specified config and passes the pool of
+// certificates it references to fn. The config is the path of either a PEM
+// encoded file or a directory of such files, in which case the certificates
+// of all files in the directory are added to the pool.
+func withEnvCertPool(n string, fn func(*x509.CertPool)) func(e *envconfig.EnvOptionsReader) {
+	return func(e *envconfig.EnvOptionsReader) {
+		v, ok := e.GetEnvValue(n)
+		if !ok {
+			return
+		}
+		if info, err := os.Stat(v); err != nil || !info.IsDir() {
+			envconfig.WithCertPool(n, fn)(e)
+			return
+		}
+		p, err := ReadCertPoolFromDir(v)
+		if err != nil {
+			global.Error(err, "create tls cert pool", "dir", v)
+			return
+		}
+		fn(p)
+	}
+}
+
+// withEnvCardinalityLimit retrieves the specified config and passes it to fn
+// as a positive integer. Invalid values are logged and ignored.
+func withEnvCardinalityLimit(n string, fn func(int)) func(e *envconfig.EnvOptionsReader) {
+	return func(e *envconfig.EnvOptionsReader) {
+		if v, ok := e.GetEnvValue(n); ok {
+			limit, err := strconv.Atoi(strings.TrimSpace(v))
+			if err == nil && limit <= 0 {
+				err = fmt.Errorf("non-positive value: %d", limit)
+			}
+			if err != nil {
+				global.Error(err, "OTEL_EXPORTER_OTLP_METRICS_CARDINALITY_LIMIT ignored", "value", v)
+				return
+			}
+			fn(limit)
+		}
+	}
+}
+
+// withEnvAggPreference retrieves the specified config and passes an
+// AggregationSelector using the matching default histogram aggregation to fn.
+// Invalid or unsupported values, including blank ones, are logged and
+// ignored.
+func withEnvAggPreference(n string, fn func(metric.AggregationSelector)) func(e *envconfig.EnvOptionsReader) {
+	return func(e *envconfig.EnvOptionsReader) {
+		const msg = "OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION ignored"
+		v, ok := e.GetEnvValue(n)
+		if !ok {
+			if e.GetEnv(e.Namespace+"_"+n) != "" {
+				// The variable is set, but only contains whitespace.
+				global.Error(errors.New("empty value"), msg)
+			}
+			return
+		}
+		switch strings.ToLower(v) {
+		case "explicit_bucket_histogram":
+			fn(metric.DefaultAggregationSelector)
+		case "base2_exponential_bucket_histogram":
+			// The SDK does not yet provide an exponential histogram
+			// aggregation. Keep the default until it does.
+			err := errors.New("base2 exponential bucket histogram aggregation is not supported")
+			global.Error(err, msg, "value", v)
+		default:
+			global.Error(fmt.Errorf("invalid value: %q", v), msg, "value", v)
+		}
+	}
+}
+
+// withProtocolEncoding returns an option that sets the HTTP encoding used by
+// the OTLP transport protocol p. It has no effect on gRPC.
+func withProtocolEncoding(p string) GenericOption {
+	return newSplitOption(func(cfg Config) Config {
+		switch p {
+		case ProtocolHTTPJSON:
+			cfg.Metrics.Encoding = JSONEncoding
+		case ProtocolHTTPProtobuf:
+			cfg.Metrics.Encoding = ProtobufEncoding
+		}
+		return cfg
+	}, func(cfg Config) Config { return cfg })
+}
+
 func withEndpointScheme(u *url.URL) GenericOption {
 	switch strings.ToLower(u.Scheme) {
 	case "http", "unix":
@@ -0,0 +1,62 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oconf // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
+
+import (
+	"google.golang.org/grpc/resolver"
+)
+
+// failoverScheme is the scheme of the gRPC target resolved to multiple
+// endpoints by a failoverResolver.
+const failoverScheme = "otlp-failover"
+
+// failoverResolver is a gRPC name resolver builder that resolves any target
+// to a fixed, ordered, list of endpoints. The default pick_first load
+// balancing policy connects to the first of these endpoints that can be
+// reached, failing over to the next endpoint when a connection fails.
+type failoverResolver struct {
+	addrs []resolver.Address
+}
+
+var _ resolver.Builder = failoverResolver{}
+
+// newFailoverResolver returns a failoverResolver for endpoints, each a host
+// and port.
+func newFailoverResolver(endpoints []string) failoverResolver {
+	addrs := make([]resolver.Address, len(endpoints))
+	for i, endpoint := range endpoints {
+		// Verify the certificate of each endpoint against its own name
+		// instead of the one of the target.
+		addrs[i] = resolver.Address{Addr: endpoint, ServerName: endpoint}
+	}
+	return failoverResolver{addrs: addrs}
+}
+
+func (r failoverResolver) Build(_ resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	// The addresses are fixed, resolving them again would not resolve any
+	// error updating the state.
+	_ = cc.UpdateState(resolver.State{Addresses: r.addrs})
+	return nopResolver{}, nil
+}
+
+func (failoverResolver) Scheme() string { return failoverScheme }
+
+// nopResolver is a resolver.Resolver that never updates the resolved
+// addresses.
+type nopResolver struct{}
+
+func (nopResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (nopResolver) Close() {}
@@ -0,0 +1,69 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oconf // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// ReadHeadersFromFile reads the headers defined in the file at path. Each
+// line of the file defines one header as a "key: value" or "key=value" pair.
+// Blank lines and lines starting with "#" are ignored. An error is returned
+// if the file cannot be read or a line is malformed.
+func ReadHeadersFromFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	headers, err := parseHeaders(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return headers, nil
+}
+
+// parseHeaders parses the header lines read from r.
+func parseHeaders(r io.Reader) (map[string]string, error) {
+	headers := make(map[string]string)
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// The first separator ends the key, values may contain both.
+		i := strings.IndexAny(line, ":=")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: missing \":\" or \"=\" separator", n)
+		}
+		key := strings.TrimSpace(line[:i])
+		if !httpguts.ValidHeaderFieldName(key) {
+			return nil, fmt.Errorf("line %d: invalid header name %q", n, key)
+		}
+		headers[key] = strings.TrimSpace(line[i+1:])
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
@@ -0,0 +1,84 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oconf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:  "ColonAndEquals",
+			input: "Authorization: Bearer dG9rZW4=\nx-tenant=team-a:prod\n",
+			want:  map[string]string{"Authorization": "Bearer dG9rZW4=", "x-tenant": "team-a:prod"},
+		},
+		{
+			name:  "CommentsAndBlankLines",
+			input: "# Collector credentials.\n\n  \n  # indented comment\napi-key = secret\n",
+			want:  map[string]string{"api-key": "secret"},
+		},
+		{
+			name:  "EmptyValue",
+			input: "x-empty:",
+			want:  map[string]string{"x-empty": ""},
+		},
+		{
+			name:  "LastValueWins",
+			input: "key=1\nkey=2",
+			want:  map[string]string{"key": "2"},
+		},
+		{
+			name:  "Empty",
+			input: "",
+			want:  map[string]string{},
+		},
+		{
+			name:    "MissingSeparator",
+			input:   "key=value\nmalformed\n",
+			wantErr: "line 2: missing",
+		},
+		{
+			name:    "InvalidName",
+			input:   "bad key: value",
+			wantErr: "line 1: invalid header name",
+		},
+		{
+			name:    "EmptyName",
+			input:   ": value",
+			wantErr: "line 1: invalid header name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHeaders(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
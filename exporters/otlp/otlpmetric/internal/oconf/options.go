@@ -15,20 +15,39 @@
 package oconf // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
 
 import (
+	compressgzip "compress/gzip"
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/protobuf/proto"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/internal"
 	"go.opentelemetry.io/otel/exporters/otlp/internal/retry"
 	ominternal "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
 	"go.opentelemetry.io/otel/internal/global"
+	otelmetric "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/aggregation"
 )
@@ -47,6 +66,9 @@ const (
 	// DefaultTimeout is a default max waiting time for the backend to process
 	// each span or metrics batch.
 	DefaultTimeout time.Duration = 10 * time.Second
+	// MinReconnectionPeriod is the minimum amount of time between connection
+	// attempts of a gRPC client.
+	MinReconnectionPeriod time.Duration = 100 * time.Millisecond
 )
 
 type (
@@ -59,6 +81,122 @@ type (
 		Timeout     time.Duration
 		URLPath     string
 
+		// GzipLevel is the compression level used with GzipCompression by
+		// the HTTP exporter. If zero, gzip.DefaultCompression is used.
+		GzipLevel int
+
+		// EndpointScheme is the "http" or "https" scheme explicitly included
+		// in the endpoint passed to WithEndpoint, if any.
+		EndpointScheme string
+		// UserAgentSuffix is appended to the User-Agent of the exporter.
+		UserAgentSuffix string
+
+		// HeadersFunc, if set, is called for each export to get the headers
+		// sent with it instead of using Headers.
+		HeadersFunc func() map[string]string
+		// ContextHeadersFunc, if set, is called with the context of each
+		// export to get headers sent with it in addition to, and overriding,
+		// Headers or those returned from HeadersFunc.
+		ContextHeadersFunc func(context.Context) map[string]string
+		// RequestEditor, if set, is called with the headers and the encoded,
+		// and possibly compressed, body of each HTTP export to get headers
+		// sent with it in addition to, and overriding, all other headers.
+		RequestEditor func(ctx context.Context, headers map[string]string, body []byte) (map[string]string, error)
+		// ResponseHandler, if set, is called with the status code and the
+		// body of each HTTP export response. If it returns an error, the
+		// export attempt fails with that error.
+		ResponseHandler func(status int, body []byte) error
+
+		// MinAttemptWindow is the minimum amount of time that needs to remain
+		// before the deadline of an export context for an export to be
+		// attempted.
+		MinAttemptWindow time.Duration
+
+		// FileStreamPath is the path of a file exports are appended to as
+		// length-delimited OTLP requests instead of being sent to Endpoint.
+		FileStreamPath string
+		// FileStreamFsyncEvery is the number of exports appended to the
+		// FileStreamPath file between each fsync of that file.
+		FileStreamFsyncEvery int
+
+		// Endpoints are all the endpoints exports can be sent to. If empty,
+		// only Endpoint is used.
+		Endpoints []string
+		// EndpointRotation is how exports are distributed across Endpoints.
+		EndpointRotation RotationPolicy
+
+		// SchemaTransform, if set, is applied to all resource and data point
+		// attributes of exported metric data.
+		SchemaTransform func(attribute.KeyValue) (attribute.KeyValue, bool)
+		// MetricNameFilter, if set, reports if a metric with the name is
+		// exported. It is applied before all other transforms.
+		MetricNameFilter func(name string) bool
+		// ResourceAttributeFilter, if set, reports if a resource attribute
+		// is exported. It is applied after all other transforms.
+		ResourceAttributeFilter func(attribute.KeyValue) bool
+
+		// MonotonicViolationPolicy is how decreasing values of cumulative
+		// monotonic sums are handled.
+		MonotonicViolationPolicy ominternal.MonotonicViolationPolicy
+
+		// CardinalityLimit is the maximum number of distinct attribute sets
+		// exported for each metric. Data points of other attribute sets are
+		// aggregated into an overflow data point. If not positive, there is
+		// no limit.
+		CardinalityLimit int
+
+		// MaxInFlightBytes is the maximum serialized size of all exports in
+		// flight. If not positive, there is no limit.
+		MaxInFlightBytes int64
+		// InFlightBackpressure is what is done with an export that would
+		// exceed MaxInFlightBytes.
+		InFlightBackpressure BackpressurePolicy
+		// MaxPayloadBytes is the maximum serialized size of a single
+		// request. Larger exports are split into multiple requests. If not
+		// positive, exports are not split.
+		MaxPayloadBytes int
+		// MaxConcurrentExports is the maximum number of exports in flight.
+		// If not positive, there is no limit.
+		MaxConcurrentExports int
+		// ForceFlushTimeout is the maximum time a force flush waits. If not
+		// positive, Timeout is used.
+		ForceFlushTimeout time.Duration
+
+		// SelfMetricsPrefix is the prefix of the names of the metrics the
+		// exporter records about itself.
+		SelfMetricsPrefix string
+		// SelfMetricsProvider, if not nil, is the MeterProvider the exporter
+		// records metrics about itself with.
+		SelfMetricsProvider otelmetric.MeterProvider
+
+		// Producers are the producers of external metrics to register with
+		// the reader paired with the exporter. They are not used by the
+		// exporter itself.
+		Producers []metric.Producer
+
+		// HTTP configurations
+		Proxy func(*http.Request) (*url.URL, error)
+		// HTTPClient, if set, is the client used to send requests instead of
+		// one built from the TLS and proxy configuration.
+		HTTPClient *http.Client
+		// Encoding is the encoding of the payloads sent.
+		Encoding Encoding
+		// ProtoMarshalOptions are the options used to marshal payloads with
+		// the protobuf encoding.
+		ProtoMarshalOptions proto.MarshalOptions
+		// StrictURLPath is true if a URLPath containing a ".." segment is
+		// rejected instead of cleaned.
+		StrictURLPath bool
+
+		// TLSMinVersion, if not zero, is the minimum TLS version used.
+		TLSMinVersion uint16
+		// TLSCipherSuites, if not nil, are the cipher suites enabled for TLS
+		// versions up to TLS 1.2.
+		TLSCipherSuites []uint16
+		// TLSServerName, if not empty, is the server name used to verify the
+		// certificate of the endpoint and sent for SNI.
+		TLSServerName string
+
 		// gRPC configurations
 		GRPCCredentials credentials.TransportCredentials
 
@@ -70,59 +208,116 @@ type (
 		// Signal specific configurations
 		Metrics SignalConfig
 
+		// Errs are the errors found when applying options that need to fail
+		// the construction of an exporter.
+		Errs []error
+
 		RetryConfig retry.Config
+		// RetryBackoff holds the backoff settings of retries that are not
+		// part of RetryConfig.
+		RetryBackoff retry.Backoff
+		// RetryableStatusFunc, if set, reports if an HTTP request that
+		// failed with the status code is retried instead of the default
+		// classification.
+		RetryableStatusFunc func(code int) bool
+		// RetryableGRPCCodeFunc, if set, reports if a gRPC request that
+		// failed with the status code is retried instead of the default
+		// classification.
+		RetryableGRPCCodeFunc func(code codes.Code) bool
 
 		// gRPC configurations
 		ReconnectionPeriod time.Duration
 		ServiceConfig      string
 		DialOptions        []grpc.DialOption
 		GRPCConn           *grpc.ClientConn
+		GRPCJSONFallback   bool
+		KeepaliveParams    *keepalive.ClientParameters
+		WaitForReady       bool
+		// QuietDefaults is true if the use of default settings is not
+		// logged.
+		QuietDefaults bool
+		// MaxRecvMsgSize and MaxSendMsgSize, if positive, are the maximum
+		// sizes in bytes of the messages received and sent by gRPC calls.
+		MaxRecvMsgSize int
+		MaxSendMsgSize int
+
+		// GRPCDialOptions are user provided options appended to
+		// DialOptions after all options derived from the configuration.
+		GRPCDialOptions []grpc.DialOption
+		// GRPCResolvers are name resolver builders used to resolve the
+		// target of the gRPC connection in addition to the ones registered
+		// with gRPC.
+		GRPCResolvers []resolver.Builder
+
+		// StartTimeout is the maximum time to wait for the gRPC connection
+		// to be established when an exporter is created. If not positive,
+		// the connection is established lazily.
+		StartTimeout time.Duration
+
+		// StartupPing is true if an empty export is made when an exporter is
+		// created to check the endpoint can be reached.
+		StartupPing bool
 	}
 )
 
-// NewHTTPConfig returns a new Config with all settings applied from opts and
-// any unset setting using the default HTTP config values.
-func NewHTTPConfig(opts ...HTTPOption) Config {
-	cfg := Config{
+// Defaults returns the default Config shared by the HTTP and gRPC exporters
+// before any environment variable or option is applied. It is the basis
+// NewHTTPConfig and NewGRPCConfig build on, only the endpoint is not set, its
+// default port depends on the protocol used.
+func Defaults() Config {
+	return Config{
 		Metrics: SignalConfig{
-			Endpoint:    fmt.Sprintf("%s:%d", DefaultCollectorHost, DefaultCollectorHTTPPort),
 			URLPath:     DefaultMetricsPath,
 			Compression: NoCompression,
 			Timeout:     DefaultTimeout,
 
+			SelfMetricsPrefix: ominternal.DefaultSelfMetricsPrefix,
+
 			TemporalitySelector: metric.DefaultTemporalitySelector,
 			AggregationSelector: metric.DefaultAggregationSelector,
 		},
 		RetryConfig: retry.DefaultConfig,
 	}
+}
+
+// NewHTTPConfig returns a new Config with all settings applied from opts and
+// any unset setting using the default HTTP config values.
+func NewHTTPConfig(opts ...HTTPOption) Config {
+	cfg := Defaults()
+	cfg.Metrics.Endpoint = fmt.Sprintf("%s:%d", DefaultCollectorHost, DefaultCollectorHTTPPort)
 	cfg = ApplyHTTPEnvConfigs(cfg)
 	for _, opt := range opts {
 		cfg = opt.ApplyHTTPOption(cfg)
 	}
-	cfg.Metrics.URLPath = internal.CleanPath(cfg.Metrics.URLPath, DefaultMetricsPath)
+	cfg = resolveEndpointScheme(cfg)
+	checkRetryElapsedTime(cfg)
+	if cfg.Metrics.TLSMinVersion != 0 || cfg.Metrics.TLSCipherSuites != nil || cfg.Metrics.TLSServerName != "" {
+		cfg.Metrics.TLSCfg = cfg.Metrics.tlsConfig()
+	}
+	if cfg.Metrics.StrictURLPath {
+		cfg.Metrics.URLPath = internal.CleanPathStrict(cfg.Metrics.URLPath, DefaultMetricsPath)
+	} else {
+		cfg.Metrics.URLPath = internal.CleanPath(cfg.Metrics.URLPath, DefaultMetricsPath)
+	}
 	return cfg
 }
 
 // NewGRPCConfig returns a new Config with all settings applied from opts and
 // any unset setting using the default gRPC config values.
 func NewGRPCConfig(opts ...GRPCOption) Config {
-	cfg := Config{
-		Metrics: SignalConfig{
-			Endpoint:    fmt.Sprintf("%s:%d", DefaultCollectorHost, DefaultCollectorGRPCPort),
-			URLPath:     DefaultMetricsPath,
-			Compression: NoCompression,
-			Timeout:     DefaultTimeout,
-
-			TemporalitySelector: metric.DefaultTemporalitySelector,
-			AggregationSelector: metric.DefaultAggregationSelector,
-		},
-		RetryConfig: retry.DefaultConfig,
-		DialOptions: []grpc.DialOption{grpc.WithUserAgent(ominternal.GetUserAgentHeader())},
-	}
+	cfg := Defaults()
+	cfg.Metrics.Endpoint = fmt.Sprintf("%s:%d", DefaultCollectorHost, DefaultCollectorGRPCPort)
 	cfg = ApplyGRPCEnvConfigs(cfg)
+	envCfg := cfg
 	for _, opt := range opts {
 		cfg = opt.ApplyGRPCOption(cfg)
 	}
+	checkGRPCConnConflict(envCfg, cfg)
+	checkRetryElapsedTime(cfg)
+	cfg = resolveEndpointScheme(cfg)
+	cfg = resolveGRPCTLS(cfg)
+
+	cfg.DialOptions = append([]grpc.DialOption{grpc.WithUserAgent(cfg.Metrics.UserAgent())}, cfg.DialOptions...)
 
 	if cfg.ServiceConfig != "" {
 		cfg.DialOptions = append(cfg.DialOptions, grpc.WithDefaultServiceConfig(cfg.ServiceConfig))
@@ -134,6 +329,9 @@ func NewGRPCConfig(opts ...GRPCOption) Config {
 		cfg.DialOptions = append(cfg.DialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	} else {
 		// Default to using the host's root CA.
+		if cfg.GRPCConn == nil && !cfg.QuietDefaults {
+			infoRootCAsDefault()
+		}
 		creds := credentials.NewTLS(nil)
 		cfg.Metrics.GRPCCredentials = creds
 		cfg.DialOptions = append(cfg.DialOptions, grpc.WithTransportCredentials(creds))
@@ -141,9 +339,6 @@ func NewGRPCConfig(opts ...GRPCOption) Config {
 	if cfg.Metrics.Compression == GzipCompression {
 		cfg.DialOptions = append(cfg.DialOptions, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
 	}
-	if len(cfg.DialOptions) != 0 {
-		cfg.DialOptions = append(cfg.DialOptions, cfg.DialOptions...)
-	}
 	if cfg.ReconnectionPeriod != 0 {
 		p := grpc.ConnectParams{
 			Backoff:           backoff.DefaultConfig,
@@ -151,10 +346,340 @@ func NewGRPCConfig(opts ...GRPCOption) Config {
 		}
 		cfg.DialOptions = append(cfg.DialOptions, grpc.WithConnectParams(p))
 	}
+	if cfg.KeepaliveParams != nil {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithKeepaliveParams(*cfg.KeepaliveParams))
+	}
+	if cfg.WaitForReady {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithDefaultCallOptions(grpc.WaitForReady(true)))
+	}
+	if cfg.MaxRecvMsgSize > 0 {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(cfg.MaxRecvMsgSize)))
+	}
+	if cfg.MaxSendMsgSize > 0 {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(cfg.MaxSendMsgSize)))
+	}
+	if len(cfg.Metrics.Endpoints) > 1 {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithResolvers(newFailoverResolver(cfg.Metrics.Endpoints)))
+	}
+	if len(cfg.GRPCResolvers) > 0 {
+		cfg.DialOptions = append(cfg.DialOptions, grpc.WithResolvers(cfg.GRPCResolvers...))
+	}
+	if opt, ok := grpcProxyDialOption(cfg.GRPCTarget()); ok {
+		cfg.DialOptions = append(cfg.DialOptions, opt)
+	}
+	cfg.DialOptions = append(cfg.DialOptions, cfg.GRPCDialOptions...)
+
+	return cfg
+}
+
+// checkGRPCConnConflict logs an error if cfg has a gRPC connection and also
+// has endpoint or TLS options set that are not in base. These options are
+// ignored, the connection already determines the endpoint and transport
+// security.
+func checkGRPCConnConflict(base, cfg Config) {
+	if cfg.GRPCConn == nil {
+		return
+	}
+	var ignored []string
+	if cfg.Metrics.Endpoint != base.Metrics.Endpoint || len(cfg.Metrics.Endpoints) != len(base.Metrics.Endpoints) {
+		ignored = append(ignored, "endpoint")
+	}
+	if cfg.Metrics.TLSCfg != base.Metrics.TLSCfg ||
+		(base.Metrics.GRPCCredentials == nil && cfg.Metrics.GRPCCredentials != nil) {
+		ignored = append(ignored, "TLS")
+	}
+	if len(ignored) > 0 {
+		err := errors.New("endpoint and TLS options are ignored when a gRPC connection is provided")
+		global.Error(err, "conflicting options", "ignored", ignored)
+	}
+}
+
+// checkRetryElapsedTime logs a warning if retries are enabled for cfg but its
+// maximum elapsed time is shorter than the initial retry interval. The
+// maximum elapsed time is reached before the first retry is made, retries
+// are effectively disabled.
+//
+// The timeout of cfg is not compared, it only limits each attempt and does
+// not prevent retries.
+func checkRetryElapsedTime(cfg Config) {
+	r := cfg.RetryConfig
+	if !r.Enabled || r.MaxElapsedTime <= 0 || r.MaxElapsedTime >= r.InitialInterval {
+		return
+	}
+	global.Warn(
+		"retry max elapsed time is shorter than the initial retry interval, failed exports will not be retried",
+		"max_elapsed_time", r.MaxElapsedTime,
+		"initial_interval", r.InitialInterval,
+	)
+}
 
+// tlsConfig returns a copy of the TLS configuration of c, or a new one if
+// there is none, with the TLS minimum version, cipher suites, and server name
+// of c applied.
+func (c SignalConfig) tlsConfig() *tls.Config {
+	var tlsCfg *tls.Config
+	if c.TLSCfg != nil {
+		tlsCfg = c.TLSCfg.Clone()
+	} else {
+		tlsCfg = &tls.Config{}
+	}
+	if c.TLSMinVersion != 0 {
+		tlsCfg.MinVersion = c.TLSMinVersion
+	}
+	if c.TLSCipherSuites != nil {
+		tlsCfg.CipherSuites = append([]uint16(nil), c.TLSCipherSuites...)
+	}
+	if c.TLSServerName != "" {
+		tlsCfg.ServerName = c.TLSServerName
+	}
+	return tlsCfg
+}
+
+// resolveGRPCTLS rebuilds the gRPC transport credentials of cfg with the TLS
+// minimum version, cipher suites, and server name applied, if any are set.
+// Credentials not built from a TLS configuration only have their server name
+// overridden, they are otherwise used as is.
+func resolveGRPCTLS(cfg Config) Config {
+	m := cfg.Metrics
+	if m.TLSMinVersion == 0 && m.TLSCipherSuites == nil && m.TLSServerName == "" {
+		return cfg
+	}
+	if m.GRPCCredentials == nil && m.Insecure {
+		// No TLS is used.
+		return cfg
+	}
+	if m.GRPCCredentials != nil && m.TLSCfg == nil {
+		if m.TLSServerName != "" {
+			creds := m.GRPCCredentials.Clone()
+			// nolint:staticcheck // The credentials are not built by the exporter, this is the only way to set their server name.
+			if err := creds.OverrideServerName(m.TLSServerName); err != nil {
+				global.Error(err, "ignoring TLS server name")
+			} else {
+				cfg.Metrics.GRPCCredentials = creds
+			}
+		}
+		if m.TLSMinVersion != 0 || m.TLSCipherSuites != nil {
+			err := errors.New("gRPC transport credentials not created from a TLS configuration")
+			global.Error(err, "ignoring TLS minimum version and cipher suites")
+		}
+		return cfg
+	}
+	cfg.Metrics.TLSCfg = m.tlsConfig()
+	cfg.Metrics.GRPCCredentials = credentials.NewTLS(cfg.Metrics.TLSCfg)
+	return cfg
+}
+
+// resolveEndpointScheme returns cfg with client security matching the scheme
+// explicitly included in its endpoint. An explicit scheme takes precedence
+// over WithInsecure and WithSecure regardless of the order they are passed
+// in. If they conflict, an error is logged.
+func resolveEndpointScheme(cfg Config) Config {
+	switch cfg.Metrics.EndpointScheme {
+	case "https":
+		if cfg.Metrics.Insecure {
+			err := errors.New("insecure connection requested for an https endpoint")
+			global.Error(err, "using a secure connection", "endpoint", cfg.Metrics.Endpoint)
+			cfg.Metrics.Insecure = false
+		}
+	case "http":
+		cfg.Metrics.Insecure = true
+	}
 	return cfg
 }
 
+// GRPCTarget returns the target a gRPC client created with c dials. This is
+// the endpoint after any "http://" or "https://" scheme is removed and the
+// default port is added. Targets using the scheme of a gRPC name resolver
+// (e.g. "unix:///var/run/otel.sock") are returned as is. Client security
+// does not change the target. If c has multiple endpoints, a target resolved
+// to all of them by the failover resolver is returned. If c has a GRPCConn,
+// its target is returned.
+func (c Config) GRPCTarget() string {
+	if c.GRPCConn != nil {
+		return c.GRPCConn.Target()
+	}
+	if len(c.Metrics.Endpoints) > 1 {
+		return failoverScheme + ":///" + c.Metrics.Endpoint
+	}
+	return c.Metrics.Endpoint
+}
+
+// Clone returns a deep copy of c. Its maps, slices, TLS configuration, and
+// keepalive parameters are copied, so modifying them does not affect c.
+// Values provided to be shared, like the HTTP client, gRPC connection,
+// credentials, and functions, are not copied.
+func (c Config) Clone() Config {
+	c.Metrics = c.Metrics.Clone()
+	c.Errs = cloneSlice(c.Errs)
+	c.DialOptions = cloneSlice(c.DialOptions)
+	c.GRPCDialOptions = cloneSlice(c.GRPCDialOptions)
+	c.GRPCResolvers = cloneSlice(c.GRPCResolvers)
+	if c.KeepaliveParams != nil {
+		kp := *c.KeepaliveParams
+		c.KeepaliveParams = &kp
+	}
+	return c
+}
+
+// Clone returns a deep copy of c. Its maps, slices, and TLS configuration
+// are copied, so modifying them does not affect c.
+func (c SignalConfig) Clone() SignalConfig {
+	if c.Headers != nil {
+		headers := make(map[string]string, len(c.Headers))
+		for k, v := range c.Headers {
+			headers[k] = v
+		}
+		c.Headers = headers
+	}
+	c.Endpoints = cloneSlice(c.Endpoints)
+	c.TLSCipherSuites = cloneSlice(c.TLSCipherSuites)
+	c.Producers = cloneSlice(c.Producers)
+	if c.TLSCfg != nil {
+		c.TLSCfg = c.TLSCfg.Clone()
+	}
+	return c
+}
+
+// cloneSlice returns a copy of s, or nil if s is nil.
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+// Err returns an error combining all the errors in c.Errs, or nil if there
+// are none.
+func (c Config) Err() error {
+	switch len(c.Errs) {
+	case 0:
+		return nil
+	case 1:
+		return c.Errs[0]
+	default:
+		return fmt.Errorf("%v", c.Errs)
+	}
+}
+
+// redacted replaces secret values in the output of Config.String.
+const redacted = "****"
+
+// String returns a description of the resolved settings of c. Secret values,
+// like header values and certificates, are not included.
+func (c Config) String() string {
+	var b strings.Builder
+	m := c.Metrics
+	fmt.Fprintf(&b, "endpoint=%q", m.Endpoint)
+	if len(m.Endpoints) > 0 {
+		fmt.Fprintf(&b, " endpoints=%q", m.Endpoints)
+	}
+	fmt.Fprintf(&b, " url_path=%q insecure=%t", m.URLPath, m.Insecure)
+	fmt.Fprintf(&b, " compression=%s timeout=%s", m.Compression, m.Timeout)
+
+	keys := make([]string, 0, len(m.Headers))
+	for k := range m.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	b.WriteString(" headers={")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s=%s", k, redacted)
+	}
+	b.WriteString("}")
+	if m.HeadersFunc != nil {
+		b.WriteString(" headers_func=set")
+	}
+	if m.ContextHeadersFunc != nil {
+		b.WriteString(" context_headers_func=set")
+	}
+	if m.RequestEditor != nil {
+		b.WriteString(" request_editor=set")
+	}
+	if m.ResponseHandler != nil {
+		b.WriteString(" response_handler=set")
+	}
+
+	tlsSet := m.TLSCfg != nil
+	fmt.Fprintf(&b, " tls_config_set=%t", tlsSet)
+	if tlsSet {
+		fmt.Fprintf(&b, " tls_root_cas_set=%t tls_client_certificates=%d", m.TLSCfg.RootCAs != nil, len(m.TLSCfg.Certificates))
+	}
+	fmt.Fprintf(&b, " grpc_credentials_set=%t", m.GRPCCredentials != nil)
+
+	r := c.RetryConfig
+	fmt.Fprintf(
+		&b, " retry={enabled=%t initial_interval=%s max_interval=%s max_elapsed_time=%s multiplier=%v randomization_factor=%v}",
+		r.Enabled, r.InitialInterval, r.MaxInterval, r.MaxElapsedTime, c.RetryBackoff.Multiplier, c.RetryBackoff.RandomizationFactor,
+	)
+	return b.String()
+}
+
+// Transforms returns the transforms that need to be applied to all metric
+// data exported with c.
+func (c SignalConfig) Transforms() []ominternal.Transform {
+	var transforms []ominternal.Transform
+	if c.MetricNameFilter != nil {
+		transforms = append(transforms, ominternal.MetricNameTransform(c.MetricNameFilter))
+	}
+	if c.SchemaTransform != nil {
+		transforms = append(transforms, ominternal.AttributeTransform(c.SchemaTransform))
+	}
+	if c.MonotonicViolationPolicy != ominternal.PassThroughMonotonicViolation {
+		transforms = append(transforms, ominternal.MonotonicTransform(c.MonotonicViolationPolicy))
+	}
+	if c.CardinalityLimit > 0 {
+		transforms = append(transforms, ominternal.CardinalityTransform(c.CardinalityLimit))
+	}
+	if c.ResourceAttributeFilter != nil {
+		transforms = append(transforms, ominternal.ResourceAttributeTransform(c.ResourceAttributeFilter))
+	}
+	return transforms
+}
+
+// forceFlushTimeout returns the maximum time a force flush waits with c.
+func (c SignalConfig) forceFlushTimeout() time.Duration {
+	if c.ForceFlushTimeout > 0 {
+		return c.ForceFlushTimeout
+	}
+	return c.Timeout
+}
+
+// UserAgent returns the User-Agent the exporter sends with c. It is the
+// User-Agent of the exporter followed by the UserAgentSuffix, if any.
+func (c SignalConfig) UserAgent() string {
+	ua := ominternal.GetUserAgentHeader()
+	if c.UserAgentSuffix != "" {
+		ua += " " + c.UserAgentSuffix
+	}
+	return ua
+}
+
+// ExporterOptions returns the options of the exporter that exports the
+// metric data of c.
+func (c SignalConfig) ExporterOptions() []ominternal.Option {
+	opts := []ominternal.Option{ominternal.WithTransforms(c.Transforms()...)}
+	if c.MaxInFlightBytes > 0 {
+		drop := c.InFlightBackpressure == DropBackpressure
+		opts = append(opts, ominternal.WithMaxInFlightBytes(c.MaxInFlightBytes, drop))
+	}
+	if c.MaxPayloadBytes > 0 {
+		opts = append(opts, ominternal.WithMaxPayloadBytes(c.MaxPayloadBytes))
+	}
+	if d := c.forceFlushTimeout(); d > 0 {
+		opts = append(opts, ominternal.WithForceFlushTimeout(d))
+	}
+	if c.MaxConcurrentExports > 0 {
+		opts = append(opts, ominternal.WithMaxConcurrentExports(c.MaxConcurrentExports))
+	}
+	if c.SelfMetricsProvider != nil {
+		opts = append(opts, ominternal.WithSelfMetrics(c.SelfMetricsProvider, c.SelfMetricsPrefix))
+	}
+	return opts
+}
+
 type (
 	// GenericOption applies an option to the HTTP or gRPC driver.
 	GenericOption interface {
@@ -262,8 +787,167 @@ func NewGRPCOption(fn func(cfg Config) Config) GRPCOption {
 // Generic Options
 
 func WithEndpoint(endpoint string) GenericOption {
-	return newGenericOption(func(cfg Config) Config {
-		cfg.Metrics.Endpoint = endpoint
+	scheme, hostport := splitEndpointScheme(endpoint)
+	return newSplitOption(func(cfg Config) Config {
+		host, basePath := hostport, ""
+		if !isResolverTarget(endpoint) {
+			host, basePath = splitEndpointBasePath(hostport)
+		}
+		if err := validateEndpoint(host); err != nil {
+			global.Error(err, "ignoring endpoint", "endpoint", endpoint)
+			return cfg
+		}
+		cfg.Metrics.Endpoint = withDefaultPort(host, DefaultCollectorHTTPPort)
+		cfg.Metrics.EndpointScheme = scheme
+		if basePath != "" {
+			// Like for the OTEL_EXPORTER_OTLP_ENDPOINT environment variable,
+			// the path is the base the metrics path is relative to. Joining
+			// the paths drops any trailing slash of the base.
+			cfg.Metrics.URLPath = path.Join(basePath, DefaultMetricsPath)
+		}
+		// Replace any previously set endpoints.
+		cfg.Metrics.Endpoints = nil
+		return cfg
+	}, func(cfg Config) Config {
+		if isResolverTarget(endpoint) {
+			cfg.Metrics.Endpoint = endpoint
+		} else {
+			if err := validateEndpoint(hostport); err != nil {
+				global.Error(err, "ignoring endpoint", "endpoint", endpoint)
+				return cfg
+			}
+			cfg.Metrics.Endpoint = withDefaultPort(hostport, DefaultCollectorGRPCPort)
+		}
+		cfg.Metrics.EndpointScheme = scheme
+		// Replace any previously set endpoints.
+		cfg.Metrics.Endpoints = nil
+		return cfg
+	})
+}
+
+// splitEndpointBasePath returns the host and optional port endpoint starts
+// with, and the URL path following them, if any.
+func splitEndpointBasePath(endpoint string) (hostport, basePath string) {
+	if i := strings.IndexByte(endpoint, '/'); i >= 0 {
+		return endpoint[:i], endpoint[i:]
+	}
+	return endpoint, ""
+}
+
+// splitEndpointScheme returns the lowercase "http" or "https" scheme endpoint
+// starts with, if any, and the rest of endpoint.
+func splitEndpointScheme(endpoint string) (scheme, rest string) {
+	for _, s := range []string{"http", "https"} {
+		prefix := s + "://"
+		if len(endpoint) >= len(prefix) && strings.EqualFold(endpoint[:len(prefix)], prefix) {
+			return s, endpoint[len(prefix):]
+		}
+	}
+	return "", endpoint
+}
+
+// isResolverTarget returns if endpoint is a gRPC target that needs to be
+// passed to gRPC as is. That is either a target with the scheme of a name
+// resolver built into gRPC, or a target with any other scheme than http or
+// https followed by "://" (e.g. "xds:///collector"). The latter are resolved
+// by resolvers registered with gRPC or passed with WithGRPCResolvers.
+func isResolverTarget(endpoint string) bool {
+	if internal.HasGRPCResolverScheme(endpoint) {
+		return true
+	}
+	scheme, _, ok := strings.Cut(endpoint, "://")
+	if !ok || scheme == "" || strings.EqualFold(scheme, "http") || strings.EqualFold(scheme, "https") {
+		return false
+	}
+	// Only characters allowed in a scheme by RFC 3986.
+	for i, r := range scheme {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && ('0' <= r && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validateEndpoint returns an error if endpoint does not have a host or is an
+// ambiguous IPv6 address.
+func validateEndpoint(endpoint string) error {
+	host := endpoint
+	if h, _, err := net.SplitHostPort(endpoint); err == nil {
+		host = h
+	}
+	if strings.TrimSpace(host) == "" {
+		return fmt.Errorf("endpoint %q has no host", endpoint)
+	}
+	return validateIPv6Endpoint(endpoint)
+}
+
+// validateIPv6Endpoint returns an error if endpoint is an IPv6 address that
+// is not enclosed in square brackets. Whether the last part of such an
+// address is a port or part of the address (e.g. "::1:4317") is ambiguous.
+func validateIPv6Endpoint(endpoint string) error {
+	if strings.HasPrefix(endpoint, "[") || strings.Count(endpoint, ":") < 2 {
+		return nil
+	}
+	return fmt.Errorf("ambiguous IPv6 endpoint %q: enclose the address in square brackets (e.g. \"[::1]:4317\")", endpoint)
+}
+
+// withDefaultPort returns endpoint with port appended if endpoint is a host
+// without a port. Otherwise, endpoint is returned unchanged.
+func withDefaultPort(endpoint string, port uint16) string {
+	if endpoint == "" {
+		return endpoint
+	}
+	_, _, err := net.SplitHostPort(endpoint)
+	var addrErr *net.AddrError
+	if !errors.As(err, &addrErr) || addrErr.Err != "missing port in address" {
+		// Either a port is set or endpoint is not a valid host.
+		return endpoint
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(endpoint, "["), "]")
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
+
+// validateHostPort returns an error if endpoint is not a host and optional
+// port, e.g. if it includes a scheme or a path.
+func validateHostPort(endpoint string) error {
+	if scheme, _ := splitEndpointScheme(endpoint); scheme != "" || strings.Contains(endpoint, "/") {
+		return fmt.Errorf("endpoint %q is not a host and optional port", endpoint)
+	}
+	return validateEndpoint(endpoint)
+}
+
+func WithEndpoints(endpoints ...string) GenericOption {
+	withEndpoints := func(validate func(string) error, port uint16) func(Config) Config {
+		return func(cfg Config) Config {
+			valid := make([]string, 0, len(endpoints))
+			for _, endpoint := range endpoints {
+				if err := validate(endpoint); err != nil {
+					global.Error(err, "ignoring endpoint", "endpoint", endpoint)
+					continue
+				}
+				valid = append(valid, withDefaultPort(endpoint, port))
+			}
+			if len(valid) == 0 {
+				return cfg
+			}
+			cfg.Metrics.Endpoint = valid[0]
+			cfg.Metrics.Endpoints = valid
+			cfg.Metrics.EndpointScheme = ""
+			return cfg
+		}
+	}
+	return newSplitOption(
+		withEndpoints(validateHostPort, DefaultCollectorHTTPPort),
+		withEndpoints(validateEndpoint, DefaultCollectorGRPCPort),
+	)
+}
+
+func WithEndpointRotation(policy RotationPolicy) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Metrics.EndpointRotation = policy
 		return cfg
 	})
 }
@@ -275,6 +959,18 @@ func WithCompression(compression Compression) GenericOption {
 	})
 }
 
+func WithGzipLevel(level int) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		if level < compressgzip.BestSpeed || level > compressgzip.BestCompression {
+			err := fmt.Errorf("invalid gzip level %d: must be between %d and %d", level, compressgzip.BestSpeed, compressgzip.BestCompression)
+			cfg.Errs = append(cfg.Errs, err)
+			return cfg
+		}
+		cfg.Metrics.GzipLevel = level
+		return cfg
+	})
+}
+
 func WithURLPath(urlPath string) GenericOption {
 	return newGenericOption(func(cfg Config) Config {
 		cfg.Metrics.URLPath = urlPath
@@ -282,6 +978,21 @@ func WithURLPath(urlPath string) GenericOption {
 	})
 }
 
+func WithUserAgent(suffix string) GenericOption {
+	suffix = strings.TrimSpace(suffix)
+	if strings.ContainsAny(suffix, "\r\n\x00") {
+		err := fmt.Errorf("invalid User-Agent suffix: %q", suffix)
+		return newGenericOption(func(cfg Config) Config {
+			global.Error(err, "User-Agent suffix not applied")
+			return cfg
+		})
+	}
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.UserAgentSuffix = suffix
+		return cfg
+	})
+}
+
 func WithRetry(rc retry.Config) GenericOption {
 	return newGenericOption(func(cfg Config) Config {
 		cfg.RetryConfig = rc
@@ -289,16 +1000,173 @@ func WithRetry(rc retry.Config) GenericOption {
 	})
 }
 
+func WithRetryableStatusFunc(fn func(code int) bool) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.RetryableStatusFunc = fn
+		return cfg
+	})
+}
+
+func WithRetryableGRPCCodeFunc(fn func(code codes.Code) bool) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		cfg.RetryableGRPCCodeFunc = fn
+		return cfg
+	})
+}
+
+func WithRetryDisabled() GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.RetryConfig.Enabled = false
+		return cfg
+	})
+}
+
+func WithRetryInitialInterval(d time.Duration) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.RetryConfig.InitialInterval = d
+		return cfg
+	})
+}
+
+func WithRetryMaxInterval(d time.Duration) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.RetryConfig.MaxInterval = d
+		return cfg
+	})
+}
+
+func WithRetryMultiplier(m float64) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		if m < 1 {
+			err := fmt.Errorf("invalid retry multiplier %v: must be at least 1", m)
+			global.Error(err, "retry multiplier not applied")
+			return cfg
+		}
+		cfg.RetryBackoff.Multiplier = m
+		return cfg
+	})
+}
+
+func WithRetryRandomizationFactor(f float64) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.RetryBackoff.RandomizationFactor = f
+		return cfg
+	})
+}
+
 func WithTLSClientConfig(tlsCfg *tls.Config) GenericOption {
+	if tlsCfg == nil {
+		return newGenericOption(func(cfg Config) Config {
+			global.Error(errors.New("nil TLS configuration"), "ignoring TLS client configuration")
+			return cfg
+		})
+	}
 	return newSplitOption(func(cfg Config) Config {
 		cfg.Metrics.TLSCfg = tlsCfg.Clone()
 		return cfg
 	}, func(cfg Config) Config {
+		cfg.Metrics.TLSCfg = tlsCfg.Clone()
 		cfg.Metrics.GRPCCredentials = credentials.NewTLS(tlsCfg)
 		return cfg
 	})
 }
 
+func WithTLSClientCertFromFile(certPath, keyPath, caPath string) GenericOption {
+	fromFile := func(apply func(GenericOption, Config) Config) func(Config) Config {
+		return func(cfg Config) Config {
+			tlsCfg, err := ReadTLSClientConfigFromFiles(certPath, keyPath, caPath)
+			if err != nil {
+				global.Error(err, "load tls client config", "cert", certPath, "key", keyPath, "ca", caPath)
+				return cfg
+			}
+			return apply(WithTLSClientConfig(tlsCfg), cfg)
+		}
+	}
+	return newSplitOption(
+		fromFile(GenericOption.ApplyHTTPOption),
+		fromFile(GenericOption.ApplyGRPCOption),
+	)
+}
+
+func WithTLSCertPool(dir string) GenericOption {
+	fromDir := func(apply func(Config, *x509.CertPool) Config) func(Config) Config {
+		return func(cfg Config) Config {
+			pool, err := ReadCertPoolFromDir(dir)
+			if err != nil {
+				global.Error(err, "load tls certificate pool", "dir", dir)
+				return cfg
+			}
+			return apply(cfg, pool)
+		}
+	}
+	// withRootCAs sets the pool on a copy of the TLS configuration, keeping
+	// any client certificates already set.
+	withRootCAs := func(cfg Config, pool *x509.CertPool) Config {
+		if cfg.Metrics.TLSCfg != nil {
+			cfg.Metrics.TLSCfg = cfg.Metrics.TLSCfg.Clone()
+		} else {
+			cfg.Metrics.TLSCfg = &tls.Config{}
+		}
+		cfg.Metrics.TLSCfg.RootCAs = pool
+		return cfg
+	}
+	return newSplitOption(fromDir(withRootCAs), fromDir(func(cfg Config, pool *x509.CertPool) Config {
+		cfg = withRootCAs(cfg, pool)
+		cfg.Metrics.GRPCCredentials = credentials.NewTLS(cfg.Metrics.TLSCfg.Clone())
+		return cfg
+	}))
+}
+
+// tlsVersions are the TLS versions that can be used as a minimum version.
+var tlsVersions = map[uint16]bool{
+	tls.VersionTLS10: true,
+	tls.VersionTLS11: true,
+	tls.VersionTLS12: true,
+	tls.VersionTLS13: true,
+}
+
+func WithTLSMinVersion(version uint16) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		if !tlsVersions[version] {
+			err := fmt.Errorf("unknown TLS version: %#04x", version)
+			global.Error(err, "ignoring TLS minimum version")
+			return cfg
+		}
+		cfg.Metrics.TLSMinVersion = version
+		return cfg
+	})
+}
+
+func WithTLSServerName(name string) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.TLSServerName = name
+		return cfg
+	})
+}
+
+func WithTLSCipherSuites(suites []uint16) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		known := make(map[uint16]bool)
+		for _, cs := range tls.CipherSuites() {
+			known[cs.ID] = true
+		}
+		for _, cs := range tls.InsecureCipherSuites() {
+			known[cs.ID] = true
+		}
+		valid := make([]uint16, 0, len(suites))
+		for _, id := range suites {
+			if !known[id] {
+				err := fmt.Errorf("unknown TLS cipher suite: %#04x", id)
+				global.Error(err, "ignoring TLS cipher suite")
+				continue
+			}
+			valid = append(valid, id)
+		}
+		cfg.Metrics.TLSCipherSuites = valid
+		return cfg
+	})
+}
+
 func WithInsecure() GenericOption {
 	return newGenericOption(func(cfg Config) Config {
 		cfg.Metrics.Insecure = true
@@ -314,12 +1182,79 @@ func WithSecure() GenericOption {
 }
 
 func WithHeaders(headers map[string]string) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		if len(headers) == 0 {
+			return cfg
+		}
+		merged := make(map[string]string, len(cfg.Metrics.Headers)+len(headers))
+		for k, v := range cfg.Metrics.Headers {
+			merged[k] = v
+		}
+		for k, v := range headers {
+			// Header keys are case-insensitive, replace any previous value
+			// set with a key only differing in case.
+			for prev := range merged {
+				if prev != k && strings.EqualFold(prev, k) {
+					delete(merged, prev)
+				}
+			}
+			merged[k] = v
+		}
+		cfg.Metrics.Headers = merged
+		return cfg
+	})
+}
+
+func WithHeader(key, value string) GenericOption {
+	return WithHeaders(map[string]string{key: value})
+}
+
+func WithHeadersFromFile(path string) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		headers, err := ReadHeadersFromFile(path)
+		if err != nil {
+			global.Error(err, "load headers", "path", path)
+			return cfg
+		}
+		return WithHeaders(headers).ApplyHTTPOption(cfg)
+	})
+}
+
+func WithHeadersReplace(headers map[string]string) GenericOption {
 	return newGenericOption(func(cfg Config) Config {
 		cfg.Metrics.Headers = headers
 		return cfg
 	})
 }
 
+func WithHeadersFunc(fn func() map[string]string) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.HeadersFunc = fn
+		return cfg
+	})
+}
+
+func WithContextHeaders(fn func(context.Context) map[string]string) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.ContextHeadersFunc = fn
+		return cfg
+	})
+}
+
+func WithRequestEditor(fn func(ctx context.Context, headers map[string]string, body []byte) (map[string]string, error)) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Metrics.RequestEditor = fn
+		return cfg
+	})
+}
+
+func WithHTTPResponseHandler(fn func(status int, body []byte) error) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Metrics.ResponseHandler = fn
+		return cfg
+	})
+}
+
 func WithTimeout(duration time.Duration) GenericOption {
 	return newGenericOption(func(cfg Config) Config {
 		cfg.Metrics.Timeout = duration
@@ -327,6 +1262,262 @@ func WithTimeout(duration time.Duration) GenericOption {
 	})
 }
 
+func WithForceFlushTimeout(d time.Duration) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.ForceFlushTimeout = d
+		return cfg
+	})
+}
+
+func WithMinAttemptWindow(d time.Duration) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.MinAttemptWindow = d
+		return cfg
+	})
+}
+
+func WithFileStream(path string, fsyncEvery int) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Metrics.FileStreamPath = path
+		cfg.Metrics.FileStreamFsyncEvery = fsyncEvery
+		return cfg
+	})
+}
+
+func WithSchemaTransform(fn func(attribute.KeyValue) (attribute.KeyValue, bool)) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.SchemaTransform = fn
+		return cfg
+	})
+}
+
+func WithMetricNameFilter(keep func(name string) bool) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.MetricNameFilter = keep
+		return cfg
+	})
+}
+
+func WithResourceAttributeFilter(keep func(attribute.KeyValue) bool) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.ResourceAttributeFilter = keep
+		return cfg
+	})
+}
+
+func WithSelfMetricsPrefix(prefix string) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		if err := ominternal.ValidateSelfMetricsPrefix(prefix); err != nil {
+			global.Error(err, "self-metrics prefix not applied")
+			return cfg
+		}
+		cfg.Metrics.SelfMetricsPrefix = prefix
+		return cfg
+	})
+}
+
+func WithServiceConfigFromFile(path string) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			global.Error(err, "read gRPC service config", "path", path)
+			return cfg
+		}
+		if !json.Valid(b) {
+			global.Error(errors.New("invalid JSON"), "parse gRPC service config", "path", path)
+			return cfg
+		}
+		cfg.ServiceConfig = string(b)
+		return cfg
+	})
+}
+
+func WithStartTimeout(d time.Duration) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		cfg.StartTimeout = d
+		return cfg
+	})
+}
+
+func WithStartupPing(enabled bool) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.StartupPing = enabled
+		return cfg
+	})
+}
+
+func WithReconnectionPeriod(rp time.Duration) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		if rp != 0 && rp < MinReconnectionPeriod {
+			global.Info("reconnection period too small, using minimum", "period", rp, "minimum", MinReconnectionPeriod)
+			rp = MinReconnectionPeriod
+		}
+		cfg.ReconnectionPeriod = rp
+		return cfg
+	})
+}
+
+func WithGRPCDialOption(opts ...grpc.DialOption) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		cfg.GRPCDialOptions = append(cfg.GRPCDialOptions, opts...)
+		return cfg
+	})
+}
+
+func WithGRPCResolvers(builders ...resolver.Builder) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		cfg.GRPCResolvers = append(cfg.GRPCResolvers, builders...)
+		return cfg
+	})
+}
+
+func WithGRPCConn(conn *grpc.ClientConn) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		cfg.GRPCConn = conn
+		return cfg
+	})
+}
+
+func WithGRPCCredentials(creds credentials.TransportCredentials) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		cfg.Metrics.GRPCCredentials = creds
+		// The credentials are not created from a known TLS configuration.
+		cfg.Metrics.TLSCfg = nil
+		return cfg
+	})
+}
+
+func WithGRPCWaitForReady(waitForReady bool) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		cfg.WaitForReady = waitForReady
+		return cfg
+	})
+}
+
+func WithGRPCMaxRecvMsgSize(n int) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		cfg.MaxRecvMsgSize = n
+		return cfg
+	})
+}
+
+func WithGRPCMaxSendMsgSize(n int) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		cfg.MaxSendMsgSize = n
+		return cfg
+	})
+}
+
+func WithQuietDefaults() GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		cfg.QuietDefaults = true
+		return cfg
+	})
+}
+
+func WithGRPCKeepalive(params keepalive.ClientParameters) GRPCOption {
+	return NewGRPCOption(func(cfg Config) Config {
+		cfg.KeepaliveParams = &params
+		return cfg
+	})
+}
+
+func WithMonotonicViolationPolicy(policy ominternal.MonotonicViolationPolicy) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.MonotonicViolationPolicy = policy
+		return cfg
+	})
+}
+
+func WithCardinalityLimit(n int) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.CardinalityLimit = n
+		return cfg
+	})
+}
+
+func WithMaxInFlightBytes(n int64, policy BackpressurePolicy) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.MaxInFlightBytes = n
+		cfg.Metrics.InFlightBackpressure = policy
+		return cfg
+	})
+}
+
+func WithMaxPayloadBytes(n int) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.MaxPayloadBytes = n
+		return cfg
+	})
+}
+
+func WithMaxConcurrentExports(n int) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.MaxConcurrentExports = n
+		return cfg
+	})
+}
+
+func WithSelfObservability(mp otelmetric.MeterProvider) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.SelfMetricsProvider = mp
+		return cfg
+	})
+}
+
+func WithProducers(producers ...metric.Producer) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		cfg.Metrics.Producers = append(cfg.Metrics.Producers, producers...)
+		return cfg
+	})
+}
+
+func WithProxy(fn func(*http.Request) (*url.URL, error)) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Metrics.Proxy = fn
+		return cfg
+	})
+}
+
+func WithMetricsURLPath(urlPath string) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		if cfg.Metrics.StrictURLPath {
+			cfg.Metrics.URLPath = internal.CleanPathStrict(urlPath, DefaultMetricsPath)
+		} else {
+			cfg.Metrics.URLPath = internal.CleanPath(urlPath, DefaultMetricsPath)
+		}
+		return cfg
+	})
+}
+
+func WithStrictURLPath() HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Metrics.StrictURLPath = true
+		return cfg
+	})
+}
+
+func WithHTTPEncoding(enc Encoding) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Metrics.Encoding = enc
+		return cfg
+	})
+}
+
+func WithProtoMarshalOptions(opts proto.MarshalOptions) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Metrics.ProtoMarshalOptions = opts
+		return cfg
+	})
+}
+
+func WithHTTPClient(c *http.Client) HTTPOption {
+	return NewHTTPOption(func(cfg Config) Config {
+		cfg.Metrics.HTTPClient = c
+		return cfg
+	})
+}
+
 func WithTemporalitySelector(selector metric.TemporalitySelector) GenericOption {
 	return newGenericOption(func(cfg Config) Config {
 		cfg.Metrics.TemporalitySelector = selector
@@ -334,6 +1525,39 @@ func WithTemporalitySelector(selector metric.TemporalitySelector) GenericOption
 	})
 }
 
+// instrumentKinds are all the instrument kinds an aggregation is selected for.
+var instrumentKinds = []struct {
+	kind metric.InstrumentKind
+	name string
+}{
+	{metric.InstrumentKindCounter, "Counter"},
+	{metric.InstrumentKindUpDownCounter, "UpDownCounter"},
+	{metric.InstrumentKindHistogram, "Histogram"},
+	{metric.InstrumentKindObservableCounter, "ObservableCounter"},
+	{metric.InstrumentKindObservableUpDownCounter, "ObservableUpDownCounter"},
+	{metric.InstrumentKindObservableGauge, "ObservableGauge"},
+}
+
+func WithAggregationSelectorStrict(selector metric.AggregationSelector) GenericOption {
+	return newGenericOption(func(cfg Config) Config {
+		var invalid []string
+		for _, ik := range instrumentKinds {
+			a := selector(ik.kind)
+			if a == nil {
+				invalid = append(invalid, fmt.Sprintf("%s: no aggregation", ik.name))
+			} else if err := a.Err(); err != nil {
+				invalid = append(invalid, fmt.Sprintf("%s: %s", ik.name, err))
+			}
+		}
+		if len(invalid) > 0 {
+			err := fmt.Errorf("invalid aggregation selected for instrument kinds: %s", strings.Join(invalid, "; "))
+			cfg.Errs = append(cfg.Errs, err)
+			return cfg
+		}
+		return WithAggregationSelector(selector).ApplyHTTPOption(cfg)
+	})
+}
+
 func WithAggregationSelector(selector metric.AggregationSelector) GenericOption {
 	// Deep copy and validate before using.
 	wrapped := func(ik metric.InstrumentKind) aggregation.Aggregation {
@@ -15,17 +15,49 @@
 package oconf_test
 
 import (
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-logr/logr/funcr"
+	"github.com/go-logr/stdr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/protobuf/proto"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/internal/envconfig"
+	"go.opentelemetry.io/otel/exporters/otlp/internal/retry"
+	ominternal "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/aggregation"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	mpb "go.opentelemetry.io/proto/otlp/metrics/v1"
 )
 
 const (
@@ -69,6 +101,8 @@ func (f *fileReader) readFile(filename string) ([]byte, error) {
 func TestConfigs(t *testing.T) {
 	tlsCert, err := oconf.CreateTLSConfig([]byte(WeakCertificate))
 	assert.NoError(t, err)
+	clientCert, err := tls.X509KeyPair([]byte(WeakCertificate), []byte(WeakPrivateKey))
+	assert.NoError(t, err)
 
 	tests := []struct {
 		name       string
@@ -98,7 +132,42 @@ func TestConfigs(t *testing.T) {
 				oconf.WithEndpoint("someendpoint"),
 			},
 			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
-				assert.Equal(t, "someendpoint", c.Metrics.Endpoint)
+				if grpcOption {
+					assert.Equal(t, "someendpoint:4317", c.Metrics.Endpoint)
+				} else {
+					assert.Equal(t, "someendpoint:4318", c.Metrics.Endpoint)
+				}
+			},
+		},
+		{
+			name: "Test With Endpoint with port",
+			opts: []oconf.GenericOption{
+				oconf.WithEndpoint("someendpoint:1234"),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, "someendpoint:1234", c.Metrics.Endpoint)
+			},
+		},
+		{
+			name: "Test With Endpoint IPv6",
+			opts: []oconf.GenericOption{
+				oconf.WithEndpoint("[::1]"),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				if grpcOption {
+					assert.Equal(t, "[::1]:4317", c.Metrics.Endpoint)
+				} else {
+					assert.Equal(t, "[::1]:4318", c.Metrics.Endpoint)
+				}
+			},
+		},
+		{
+			name: "Test With Endpoint IPv6 with port",
+			opts: []oconf.GenericOption{
+				oconf.WithEndpoint("[::1]:1234"),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, "[::1]:1234", c.Metrics.Endpoint)
 			},
 		},
 		{
@@ -130,6 +199,60 @@ func TestConfigs(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "Test Environment Endpoint with unix scheme",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "unix:///var/run/otel.sock",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.True(t, c.Metrics.Insecure)
+				if grpcOption {
+					assert.Equal(t, "unix:///var/run/otel.sock", c.Metrics.Endpoint)
+				}
+			},
+		},
+		{
+			name: "Test Environment Signal Specific Endpoint with dns scheme",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT": "dns:///collector:4317",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				if grpcOption {
+					assert.Equal(t, "dns:///collector:4317", c.Metrics.Endpoint)
+				}
+			},
+		},
+		{
+			name: "Test Environment Endpoint with passthrough scheme",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "passthrough:///localhost:4317",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				if grpcOption {
+					assert.Equal(t, "passthrough:///localhost:4317", c.Metrics.Endpoint)
+				}
+			},
+		},
+		{
+			name: "Test Environment Endpoint with custom resolver scheme",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT": "xds:///collector:4317",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				if grpcOption {
+					assert.Equal(t, "xds:///collector:4317", c.Metrics.Endpoint)
+				}
+			},
+		},
+		{
+			name: "Test With Endpoint with resolver schemes",
+			opts: []oconf.GenericOption{
+				oconf.WithEndpoint("unix:///var/run/otel.sock"),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, "unix:///var/run/otel.sock", c.Metrics.Endpoint)
+			},
+		},
 		{
 			name: "Test Mixed Environment and With Endpoint",
 			opts: []oconf.GenericOption{
@@ -139,7 +262,11 @@ func TestConfigs(t *testing.T) {
 				"OTEL_EXPORTER_OTLP_ENDPOINT": "env_endpoint",
 			},
 			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
-				assert.Equal(t, "metrics_endpoint", c.Metrics.Endpoint)
+				if grpcOption {
+					assert.Equal(t, "metrics_endpoint:4317", c.Metrics.Endpoint)
+				} else {
+					assert.Equal(t, "metrics_endpoint:4318", c.Metrics.Endpoint)
+				}
 			},
 		},
 		{
@@ -265,7 +392,118 @@ func TestConfigs(t *testing.T) {
 			},
 		},
 
+		{
+			name: "Test Environment Client Certificate",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE": "client_cert_path",
+				"OTEL_EXPORTER_OTLP_CLIENT_KEY":         "client_key_path",
+			},
+			fileReader: fileReader{
+				"client_cert_path": []byte(WeakCertificate),
+				"client_key_path":  []byte(WeakPrivateKey),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				if grpcOption {
+					assert.NotNil(t, c.Metrics.GRPCCredentials)
+				} else {
+					assert.Equal(t, []tls.Certificate{clientCert}, c.Metrics.TLSCfg.Certificates)
+				}
+			},
+		},
+		{
+			name: "Test Environment Signal Specific Client Certificate",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE":         "invalid_cert",
+				"OTEL_EXPORTER_OTLP_CLIENT_KEY":                 "invalid_key",
+				"OTEL_EXPORTER_OTLP_METRICS_CLIENT_CERTIFICATE": "client_cert_path",
+				"OTEL_EXPORTER_OTLP_METRICS_CLIENT_KEY":         "client_key_path",
+			},
+			fileReader: fileReader{
+				"client_cert_path": []byte(WeakCertificate),
+				"client_key_path":  []byte(WeakPrivateKey),
+				"invalid_cert":     []byte("invalid certificate file."),
+				"invalid_key":      []byte("invalid key file."),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				if grpcOption {
+					assert.NotNil(t, c.Metrics.GRPCCredentials)
+				} else {
+					assert.Equal(t, []tls.Certificate{clientCert}, c.Metrics.TLSCfg.Certificates)
+				}
+			},
+		},
+		{
+			name: "Test Environment Invalid Signal Specific Client Certificate",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE":         "client_cert_path",
+				"OTEL_EXPORTER_OTLP_CLIENT_KEY":                 "client_key_path",
+				"OTEL_EXPORTER_OTLP_METRICS_CLIENT_CERTIFICATE": "client_key_path",
+				"OTEL_EXPORTER_OTLP_METRICS_CLIENT_KEY":         "client_cert_path",
+			},
+			fileReader: fileReader{
+				"client_cert_path": []byte(WeakCertificate),
+				"client_key_path":  []byte(WeakPrivateKey),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				if grpcOption {
+					assert.NotNil(t, c.Metrics.GRPCCredentials)
+				} else {
+					assert.Equal(t, []tls.Certificate{clientCert}, c.Metrics.TLSCfg.Certificates)
+				}
+			},
+		},
+
 		// Headers tests
+		// Insecure Tests
+		{
+			name: "Test Environment Insecure",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_INSECURE": "true"},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.True(t, c.Metrics.Insecure)
+			},
+		},
+		{
+			name: "Test Environment Signal Specific Insecure",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_INSECURE":         "true",
+				"OTEL_EXPORTER_OTLP_METRICS_INSECURE": "false",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.False(t, c.Metrics.Insecure)
+			},
+		},
+		{
+			name: "Test Environment Signal Specific Insecure Case Insensitive",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_INSECURE":         "false",
+				"OTEL_EXPORTER_OTLP_METRICS_INSECURE": "TRUE",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.True(t, c.Metrics.Insecure)
+			},
+		},
+		{
+			name: "Test Environment Invalid Insecure",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_INSECURE":         "true",
+				"OTEL_EXPORTER_OTLP_METRICS_INSECURE": "yes",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				// The invalid value is ignored.
+				assert.True(t, c.Metrics.Insecure)
+			},
+		},
+		{
+			name: "Test Environment Insecure Overrides Endpoint Scheme",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT":         "https://env_endpoint",
+				"OTEL_EXPORTER_OTLP_METRICS_INSECURE": "true",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.True(t, c.Metrics.Insecure)
+			},
+		},
+
 		{
 			name: "Test With Headers",
 			opts: []oconf.GenericOption{
@@ -275,6 +513,18 @@ func TestConfigs(t *testing.T) {
 				assert.Equal(t, map[string]string{"h1": "v1"}, c.Metrics.Headers)
 			},
 		},
+		{
+			name: "Test With HeadersFunc",
+			opts: []oconf.GenericOption{
+				oconf.WithHeadersFunc(func() map[string]string {
+					return map[string]string{"h1": "v1"}
+				}),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				require.NotNil(t, c.Metrics.HeadersFunc)
+				assert.Equal(t, map[string]string{"h1": "v1"}, c.Metrics.HeadersFunc())
+			},
+		},
 		{
 			name: "Test Environment Headers",
 			env:  map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "h1=v1,h2=v2"},
@@ -298,10 +548,145 @@ func TestConfigs(t *testing.T) {
 			opts: []oconf.GenericOption{
 				oconf.WithHeaders(map[string]string{"m1": "mv1"}),
 			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, map[string]string{"h1": "v1", "h2": "v2", "m1": "mv1"}, c.Metrics.Headers)
+			},
+		},
+		{
+			name: "Test With Headers Precedence",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "h1=v1,h2=v2"},
+			opts: []oconf.GenericOption{
+				oconf.WithHeaders(map[string]string{"h1": "override", "m1": "mv1"}),
+				oconf.WithHeaders(map[string]string{"m1": "mv2"}),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, map[string]string{"h1": "override", "h2": "v2", "m1": "mv2"}, c.Metrics.Headers)
+			},
+		},
+		{
+			name: "Test With Nil Headers",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "h1=v1"},
+			opts: []oconf.GenericOption{
+				oconf.WithHeaders(nil),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, map[string]string{"h1": "v1"}, c.Metrics.Headers)
+			},
+		},
+		{
+			name: "Test With Headers Without Existing Headers",
+			opts: []oconf.GenericOption{
+				oconf.WithHeaders(map[string]string{"m1": "mv1"}),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, map[string]string{"m1": "mv1"}, c.Metrics.Headers)
+			},
+		},
+		{
+			name: "Test With Cardinality Limit",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_METRICS_CARDINALITY_LIMIT": "100"},
+			opts: []oconf.GenericOption{
+				oconf.WithCardinalityLimit(10),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, 10, c.Metrics.CardinalityLimit)
+				assert.Len(t, c.Metrics.Transforms(), 1)
+			},
+		},
+		{
+			name: "Test Environment Cardinality Limit",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_METRICS_CARDINALITY_LIMIT": " 100 "},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, 100, c.Metrics.CardinalityLimit)
+			},
+		},
+		{
+			name: "Test Environment Invalid Cardinality Limit",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_METRICS_CARDINALITY_LIMIT": "-1"},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, 0, c.Metrics.CardinalityLimit)
+				assert.Len(t, c.Metrics.Transforms(), 0)
+			},
+		},
+		{
+			name: "Test With Mixed Case Headers",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "content-type=a,h1=v1"},
+			opts: []oconf.GenericOption{
+				oconf.WithHeaders(map[string]string{"Content-Type": "b"}),
+				oconf.WithHeader("H1", "v2"),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, map[string]string{"Content-Type": "b", "H1": "v2"}, c.Metrics.Headers)
+			},
+		},
+		{
+			name: "Test With Header Accumulates",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "h1=v1"},
+			opts: []oconf.GenericOption{
+				oconf.WithHeader("X-Scope-OrgID", "team-a"),
+				oconf.WithHeader("m1", "mv1"),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				want := map[string]string{"h1": "v1", "X-Scope-OrgID": "team-a", "m1": "mv1"}
+				assert.Equal(t, want, c.Metrics.Headers)
+			},
+		},
+		{
+			name: "Test With Header Override",
+			opts: []oconf.GenericOption{
+				oconf.WithHeaders(map[string]string{"X-Scope-OrgID": "team-a", "m1": "mv1"}),
+				oconf.WithHeader("X-Scope-OrgID", "team-b"),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				want := map[string]string{"X-Scope-OrgID": "team-b", "m1": "mv1"}
+				assert.Equal(t, want, c.Metrics.Headers)
+			},
+		},
+		{
+			name: "Test With Headers Replace",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "h1=v1,h2=v2"},
+			opts: []oconf.GenericOption{
+				oconf.WithHeadersReplace(map[string]string{"m1": "mv1"}),
+			},
 			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
 				assert.Equal(t, map[string]string{"m1": "mv1"}, c.Metrics.Headers)
 			},
 		},
+		{
+			name: "Test With Headers Replace Nil",
+			env:  map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "h1=v1,h2=v2"},
+			opts: []oconf.GenericOption{
+				oconf.WithHeadersReplace(nil),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Nil(t, c.Metrics.Headers)
+			},
+		},
+
+		{
+			name: "Test Environment Headers Expansion",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_HEADERS": "authorization=Bearer ${TOKEN},price=$$5,plain=v",
+				"TOKEN":                      "secret",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				want := map[string]string{
+					"authorization": "Bearer secret",
+					"price":         "$5",
+					"plain":         "v",
+				}
+				assert.Equal(t, want, c.Metrics.Headers)
+			},
+		},
+		{
+			name: "Test Environment Headers Expansion Unset Variable",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_HEADERS": "authorization=Bearer ${TOKEN},h1=v1,h2=${UNTERMINATED",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, map[string]string{"h1": "v1"}, c.Metrics.Headers)
+			},
+		},
 
 		// Compression Tests
 		{
@@ -331,6 +716,35 @@ func TestConfigs(t *testing.T) {
 				assert.Equal(t, oconf.GzipCompression, c.Metrics.Compression)
 			},
 		},
+		{
+			name: "Test Environment Signal Specific Compression Precedence",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_COMPRESSION":         "gzip",
+				"OTEL_EXPORTER_OTLP_METRICS_COMPRESSION": "none",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, oconf.NoCompression, c.Metrics.Compression)
+			},
+		},
+		{
+			name: "Test Environment Invalid Compression",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_COMPRESSION": "zstd",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, oconf.NoCompression, c.Metrics.Compression)
+			},
+		},
+		{
+			name: "Test Environment Invalid Signal Specific Compression",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_COMPRESSION":         "gzip",
+				"OTEL_EXPORTER_OTLP_METRICS_COMPRESSION": "zstd",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, oconf.GzipCompression, c.Metrics.Compression)
+			},
+		},
 		{
 			name: "Test Mixed Environment and With Compression",
 			opts: []oconf.GenericOption{
@@ -387,16 +801,45 @@ func TestConfigs(t *testing.T) {
 			},
 		},
 
-		// Temporality Selector Tests
 		{
-			name: "WithTemporalitySelector",
-			opts: []oconf.GenericOption{
-				oconf.WithTemporalitySelector(deltaSelector),
+			name: "Test Environment Invalid Timeout",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_TIMEOUT": "15s",
 			},
 			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
-				// Function value comparisons are disallowed, test non-default
-				// behavior of a TemporalitySelector here to ensure our "catch
-				// all" was set.
+				assert.Equal(t, oconf.DefaultTimeout, c.Metrics.Timeout)
+			},
+		},
+		{
+			name: "Test Environment Invalid Signal Specific Timeout",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_TIMEOUT":         "15000",
+				"OTEL_EXPORTER_OTLP_METRICS_TIMEOUT": "28.5",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, 15*time.Second, c.Metrics.Timeout)
+			},
+		},
+		{
+			name: "Test Environment Only Signal Specific Timeout",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_METRICS_TIMEOUT": "28000",
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				assert.Equal(t, 28*time.Second, c.Metrics.Timeout)
+			},
+		},
+
+		// Temporality Selector Tests
+		{
+			name: "WithTemporalitySelector",
+			opts: []oconf.GenericOption{
+				oconf.WithTemporalitySelector(deltaSelector),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				// Function value comparisons are disallowed, test non-default
+				// behavior of a TemporalitySelector here to ensure our "catch
+				// all" was set.
 				var undefinedKind metric.InstrumentKind
 				got := c.Metrics.TemporalitySelector
 				assert.Equal(t, metricdata.DeltaTemporality, got(undefinedKind))
@@ -416,6 +859,44 @@ func TestConfigs(t *testing.T) {
 				var undefinedKind metric.InstrumentKind
 				got := c.Metrics.AggregationSelector
 				assert.Equal(t, aggregation.Drop{}, got(undefinedKind))
+				assert.NoError(t, c.Err())
+			},
+		},
+		{
+			name: "WithAggregationSelectorStrict",
+			opts: []oconf.GenericOption{
+				oconf.WithAggregationSelectorStrict(dropSelector),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				var undefinedKind metric.InstrumentKind
+				got := c.Metrics.AggregationSelector
+				assert.Equal(t, aggregation.Drop{}, got(undefinedKind))
+				assert.NoError(t, c.Err())
+			},
+		},
+		{
+			name: "WithAggregationSelectorStrict Invalid",
+			opts: []oconf.GenericOption{
+				oconf.WithAggregationSelectorStrict(func(ik metric.InstrumentKind) aggregation.Aggregation {
+					switch ik {
+					case metric.InstrumentKindHistogram:
+						return aggregation.ExplicitBucketHistogram{Boundaries: []float64{2, 1}}
+					case metric.InstrumentKindObservableGauge:
+						return nil
+					}
+					return metric.DefaultAggregationSelector(ik)
+				}),
+			},
+			asserts: func(t *testing.T, c *oconf.Config, grpcOption bool) {
+				err := c.Err()
+				require.Error(t, err)
+				// All invalid kinds are reported together.
+				assert.ErrorContains(t, err, "Histogram: ")
+				assert.ErrorContains(t, err, "ObservableGauge: no aggregation")
+				assert.NotContains(t, err.Error(), "UpDownCounter")
+				// The selector is not used.
+				got := c.Metrics.AggregationSelector
+				assert.Equal(t, aggregation.Sum{}, got(metric.InstrumentKindCounter))
 			},
 		},
 	}
@@ -464,3 +945,1297 @@ func asGRPCOptions(opts []oconf.GenericOption) []oconf.GRPCOption {
 	}
 	return converted
 }
+
+func TestWithTLSClientCertFromFile(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certPath, []byte(WeakCertificate), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, []byte(WeakPrivateKey), 0o600))
+
+	t.Run("Valid", func(t *testing.T) {
+		opt := oconf.WithTLSClientCertFromFile(certPath, keyPath, certPath)
+
+		cfg := oconf.NewHTTPConfig(opt)
+		require.NotNil(t, cfg.Metrics.TLSCfg)
+		assert.Len(t, cfg.Metrics.TLSCfg.Certificates, 1)
+		// nolint:staticcheck // ignoring RootCAs.Subjects is deprecated ERR because cert does not come from SystemCertPool.
+		assert.Len(t, cfg.Metrics.TLSCfg.RootCAs.Subjects(), 1)
+
+		cfg = oconf.NewGRPCConfig(opt)
+		require.NotNil(t, cfg.Metrics.GRPCCredentials)
+		assert.Equal(t, "tls", cfg.Metrics.GRPCCredentials.Info().SecurityProtocol)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var logged []string
+		otel.SetLogger(funcr.New(func(prefix, args string) {
+			logged = append(logged, args)
+		}, funcr.Options{}))
+		t.Cleanup(func() { otel.SetLogger(stdr.New(log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile))) })
+		errLogged := func() bool {
+			for _, l := range logged {
+				if strings.Contains(l, "load tls client config") {
+					return true
+				}
+			}
+			return false
+		}
+
+		tlsCfg := &tls.Config{ServerName: "unchanged"}
+		for _, paths := range [][3]string{
+			{filepath.Join(dir, "missing.pem"), keyPath, certPath},
+			{certPath, filepath.Join(dir, "missing.pem"), certPath},
+			{certPath, keyPath, filepath.Join(dir, "missing.pem")},
+			{keyPath, certPath, certPath},
+		} {
+			opt := oconf.WithTLSClientCertFromFile(paths[0], paths[1], paths[2])
+
+			logged = logged[:0]
+			cfg := oconf.NewHTTPConfig(oconf.WithTLSClientConfig(tlsCfg), opt)
+			require.NotNil(t, cfg.Metrics.TLSCfg)
+			assert.Equal(t, "unchanged", cfg.Metrics.TLSCfg.ServerName)
+			assert.True(t, errLogged(), "HTTP error logged")
+
+			logged = logged[:0]
+			cfg = oconf.NewGRPCConfig(opt)
+			assert.Nil(t, cfg.Metrics.TLSCfg)
+			assert.True(t, errLogged(), "gRPC error logged")
+
+			logged = logged[:0]
+			cfg = oconf.NewGRPCConfig(oconf.WithTLSClientConfig(tlsCfg), opt)
+			require.NotNil(t, cfg.Metrics.TLSCfg)
+			assert.Equal(t, "unchanged", cfg.Metrics.TLSCfg.ServerName)
+			assert.True(t, errLogged(), "gRPC error logged")
+		}
+	})
+}
+
+func TestWithSchemaTransform(t *testing.T) {
+	assert.Len(t, oconf.NewHTTPConfig().Metrics.Transforms(), 0)
+
+	keep := func(kv attribute.KeyValue) (attribute.KeyValue, bool) { return kv, true }
+	opt := oconf.WithSchemaTransform(keep)
+	assert.Len(t, oconf.NewHTTPConfig(opt).Metrics.Transforms(), 1)
+	assert.Len(t, oconf.NewGRPCConfig(opt).Metrics.Transforms(), 1)
+}
+
+func TestWithMetricNameFilter(t *testing.T) {
+	keep := func(string) bool { return true }
+	opt := oconf.WithMetricNameFilter(keep)
+	assert.Len(t, oconf.NewHTTPConfig(opt).Metrics.Transforms(), 1)
+	assert.Len(t, oconf.NewGRPCConfig(opt).Metrics.Transforms(), 1)
+}
+
+func TestWithResourceAttributeFilter(t *testing.T) {
+	keep := func(attribute.KeyValue) bool { return true }
+	opt := oconf.WithResourceAttributeFilter(keep)
+	assert.Len(t, oconf.NewHTTPConfig(opt).Metrics.Transforms(), 1)
+	assert.Len(t, oconf.NewGRPCConfig(opt).Metrics.Transforms(), 1)
+}
+
+func TestWithSelfMetricsPrefix(t *testing.T) {
+	assert.Equal(t, ominternal.DefaultSelfMetricsPrefix, oconf.NewHTTPConfig().Metrics.SelfMetricsPrefix)
+	assert.Equal(t, ominternal.DefaultSelfMetricsPrefix, oconf.NewGRPCConfig().Metrics.SelfMetricsPrefix)
+
+	opt := oconf.WithSelfMetricsPrefix("myapp.otlp.")
+	assert.Equal(t, "myapp.otlp.", oconf.NewHTTPConfig(opt).Metrics.SelfMetricsPrefix)
+	assert.Equal(t, "myapp.otlp.", oconf.NewGRPCConfig(opt).Metrics.SelfMetricsPrefix)
+
+	// An invalid prefix is not applied.
+	opt = oconf.WithSelfMetricsPrefix("my app")
+	assert.Equal(t, ominternal.DefaultSelfMetricsPrefix, oconf.NewHTTPConfig(opt).Metrics.SelfMetricsPrefix)
+}
+
+func TestWithSelfObservability(t *testing.T) {
+	cfg := oconf.NewHTTPConfig()
+	assert.Nil(t, cfg.Metrics.SelfMetricsProvider)
+
+	mp := metric.NewMeterProvider()
+	cfg = oconf.NewGRPCConfig(oconf.WithSelfObservability(mp))
+	assert.Same(t, mp, cfg.Metrics.SelfMetricsProvider)
+}
+
+type producer struct{ name string }
+
+func (producer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	return nil, nil
+}
+
+func TestWithProducers(t *testing.T) {
+	assert.Nil(t, oconf.NewHTTPConfig().Metrics.Producers)
+
+	p0, p1 := &producer{name: "0"}, &producer{name: "1"}
+	cfg := oconf.NewGRPCConfig(oconf.WithProducers(p0), oconf.WithProducers(p1))
+	require.Len(t, cfg.Metrics.Producers, 2)
+	assert.Same(t, p0, cfg.Metrics.Producers[0])
+	assert.Same(t, p1, cfg.Metrics.Producers[1])
+
+	cfg = oconf.NewHTTPConfig(oconf.WithProducers(p0, p1))
+	assert.Equal(t, []metric.Producer{p0, p1}, cfg.Metrics.Producers)
+
+	clone := cfg.Clone()
+	clone.Metrics.Producers[0] = p1
+	assert.Same(t, p0, cfg.Metrics.Producers[0])
+}
+
+func TestWithGRPCKeepalive(t *testing.T) {
+	params := keepalive.ClientParameters{
+		Time:                time.Minute,
+		Timeout:             10 * time.Second,
+		PermitWithoutStream: true,
+	}
+	base := oconf.NewGRPCConfig()
+	assert.Nil(t, base.KeepaliveParams)
+
+	cfg := oconf.NewGRPCConfig(oconf.WithGRPCKeepalive(params))
+	require.NotNil(t, cfg.KeepaliveParams)
+	assert.Equal(t, params, *cfg.KeepaliveParams)
+	assert.Len(t, cfg.DialOptions, len(base.DialOptions)+1, "keepalive dial option not added")
+}
+
+func TestWithGRPCMaxMsgSize(t *testing.T) {
+	base := oconf.NewGRPCConfig()
+	assert.Zero(t, base.MaxRecvMsgSize)
+	assert.Zero(t, base.MaxSendMsgSize)
+
+	cfg := oconf.NewGRPCConfig(oconf.WithGRPCMaxRecvMsgSize(8 << 20))
+	assert.Equal(t, 8<<20, cfg.MaxRecvMsgSize)
+	assert.Len(t, cfg.DialOptions, len(base.DialOptions)+1, "max receive size call option not added")
+
+	cfg = oconf.NewGRPCConfig(oconf.WithGRPCMaxRecvMsgSize(8<<20), oconf.WithGRPCMaxSendMsgSize(1<<20))
+	assert.Equal(t, 8<<20, cfg.MaxRecvMsgSize)
+	assert.Equal(t, 1<<20, cfg.MaxSendMsgSize)
+	assert.Len(t, cfg.DialOptions, len(base.DialOptions)+2, "max size call options not added")
+
+	// Non-positive sizes use the gRPC defaults.
+	cfg = oconf.NewGRPCConfig(oconf.WithGRPCMaxRecvMsgSize(0), oconf.WithGRPCMaxSendMsgSize(-1))
+	assert.Len(t, cfg.DialOptions, len(base.DialOptions))
+}
+
+func TestWithGRPCWaitForReady(t *testing.T) {
+	base := oconf.NewGRPCConfig()
+	assert.False(t, base.WaitForReady)
+
+	cfg := oconf.NewGRPCConfig(oconf.WithGRPCWaitForReady(true))
+	assert.True(t, cfg.WaitForReady)
+	assert.Len(t, cfg.DialOptions, len(base.DialOptions)+1, "wait for ready call option not added")
+
+	cfg = oconf.NewGRPCConfig(oconf.WithGRPCWaitForReady(true), oconf.WithGRPCWaitForReady(false))
+	assert.False(t, cfg.WaitForReady)
+	assert.Len(t, cfg.DialOptions, len(base.DialOptions))
+}
+
+func TestNewGRPCConfigDialOptions(t *testing.T) {
+	// User-agent and default transport credentials.
+	assert.Len(t, oconf.NewGRPCConfig().DialOptions, 2)
+
+	cfg := oconf.NewGRPCConfig(
+		oconf.WithInsecure(),
+		oconf.WithCompression(oconf.GzipCompression),
+		oconf.NewGRPCOption(func(cfg oconf.Config) oconf.Config {
+			cfg.ServiceConfig = "{}"
+			return cfg
+		}),
+		oconf.WithReconnectionPeriod(time.Second),
+		oconf.WithGRPCKeepalive(keepalive.ClientParameters{Time: time.Minute}),
+	)
+	// User-agent, service config, insecure credentials, compressor,
+	// connection parameters, and keepalive parameters, each exactly once.
+	assert.Len(t, cfg.DialOptions, 6)
+
+	cfg = oconf.NewGRPCConfig(oconf.NewGRPCOption(func(cfg oconf.Config) oconf.Config {
+		cfg.DialOptions = []grpc.DialOption{grpc.WithBlock()}
+		return cfg
+	}))
+	// User-agent, the explicit option, and default transport credentials.
+	assert.Len(t, cfg.DialOptions, 3)
+}
+
+// blockingClient is a Client whose uploads block until unblock is closed.
+type blockingClient struct {
+	ominternal.Client
+
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (c *blockingClient) UploadMetrics(context.Context, *mpb.ResourceMetrics) error {
+	c.started <- struct{}{}
+	<-c.unblock
+	return nil
+}
+
+// forceFlushErr returns the error of a force flush of an exporter built with
+// cfg while an export is in progress.
+func forceFlushErr(t *testing.T, cfg oconf.Config) error {
+	t.Helper()
+
+	c := &blockingClient{
+		Client:  ominternal.NewNoopClient(cfg.Metrics.TemporalitySelector, cfg.Metrics.AggregationSelector),
+		started: make(chan struct{}, 1),
+		unblock: make(chan struct{}),
+	}
+	exp := ominternal.New(c, cfg.Metrics.ExporterOptions()...)
+	ctx := context.Background()
+
+	done := make(chan error, 1)
+	go func() { done <- exp.Export(ctx, &metricdata.ResourceMetrics{}) }()
+	<-c.started
+	t.Cleanup(func() {
+		close(c.unblock)
+		require.NoError(t, <-done)
+	})
+	return exp.ForceFlush(ctx)
+}
+
+func TestWithForceFlushTimeout(t *testing.T) {
+	assert.Equal(t, time.Duration(0), oconf.NewHTTPConfig().Metrics.ForceFlushTimeout)
+	cfg := oconf.NewGRPCConfig(oconf.WithTimeout(time.Hour), oconf.WithForceFlushTimeout(time.Millisecond))
+	assert.Equal(t, time.Millisecond, cfg.Metrics.ForceFlushTimeout)
+	assert.ErrorIs(t, forceFlushErr(t, cfg), context.DeadlineExceeded)
+
+	// The default is the export timeout.
+	cfg = oconf.NewHTTPConfig(oconf.WithTimeout(time.Millisecond))
+	assert.ErrorIs(t, forceFlushErr(t, cfg), context.DeadlineExceeded)
+}
+
+func TestWithMaxInFlightBytes(t *testing.T) {
+	cfg := oconf.NewHTTPConfig()
+	assert.Equal(t, int64(0), cfg.Metrics.MaxInFlightBytes)
+	assert.Equal(t, oconf.BlockBackpressure, cfg.Metrics.InFlightBackpressure)
+
+	cfg = oconf.NewGRPCConfig(oconf.WithMaxInFlightBytes(1024, oconf.DropBackpressure))
+	assert.Equal(t, int64(1024), cfg.Metrics.MaxInFlightBytes)
+	assert.Equal(t, oconf.DropBackpressure, cfg.Metrics.InFlightBackpressure)
+}
+
+func TestWithMaxPayloadBytes(t *testing.T) {
+	cfg := oconf.NewHTTPConfig()
+	assert.Equal(t, 0, cfg.Metrics.MaxPayloadBytes)
+
+	cfg = oconf.NewGRPCConfig(oconf.WithMaxPayloadBytes(4096))
+	assert.Equal(t, 4096, cfg.Metrics.MaxPayloadBytes)
+}
+
+func TestWithMaxConcurrentExports(t *testing.T) {
+	cfg := oconf.NewHTTPConfig()
+	assert.Equal(t, 0, cfg.Metrics.MaxConcurrentExports)
+
+	cfg = oconf.NewGRPCConfig(oconf.WithMaxConcurrentExports(2))
+	assert.Equal(t, 2, cfg.Metrics.MaxConcurrentExports)
+}
+
+func TestWithGzipLevel(t *testing.T) {
+	cfg := oconf.NewHTTPConfig()
+	assert.Equal(t, 0, cfg.Metrics.GzipLevel)
+
+	cfg = oconf.NewHTTPConfig(oconf.WithGzipLevel(gzip.BestSpeed))
+	assert.NoError(t, cfg.Err())
+	assert.Equal(t, gzip.BestSpeed, cfg.Metrics.GzipLevel)
+
+	cfg = oconf.NewGRPCConfig(oconf.WithGzipLevel(gzip.BestCompression))
+	assert.NoError(t, cfg.Err())
+	assert.Equal(t, gzip.BestCompression, cfg.Metrics.GzipLevel)
+
+	for _, level := range []int{gzip.HuffmanOnly, gzip.DefaultCompression, gzip.NoCompression, gzip.BestCompression + 1} {
+		cfg = oconf.NewHTTPConfig(oconf.WithGzipLevel(level))
+		assert.Error(t, cfg.Err(), "level %d", level)
+		assert.Equal(t, 0, cfg.Metrics.GzipLevel, "level %d", level)
+	}
+}
+
+func TestWithTLSMinVersionAndCipherSuites(t *testing.T) {
+	suites := []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}
+	opts := []oconf.GenericOption{
+		oconf.WithTLSClientConfig(&tls.Config{ServerName: "collector"}),
+		oconf.WithTLSMinVersion(tls.VersionTLS13),
+		oconf.WithTLSCipherSuites(suites),
+	}
+
+	cfg := oconf.NewHTTPConfig(asHTTPOptions(opts)...)
+	require.NotNil(t, cfg.Metrics.TLSCfg)
+	assert.Equal(t, "collector", cfg.Metrics.TLSCfg.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS13), cfg.Metrics.TLSCfg.MinVersion)
+	assert.Equal(t, suites, cfg.Metrics.TLSCfg.CipherSuites)
+
+	cfg = oconf.NewGRPCConfig(asGRPCOptions(opts)...)
+	require.NotNil(t, cfg.Metrics.TLSCfg)
+	assert.Equal(t, "collector", cfg.Metrics.TLSCfg.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS13), cfg.Metrics.TLSCfg.MinVersion)
+	assert.Equal(t, suites, cfg.Metrics.TLSCfg.CipherSuites)
+	require.NotNil(t, cfg.Metrics.GRPCCredentials)
+	assert.Equal(t, "tls", cfg.Metrics.GRPCCredentials.Info().SecurityProtocol)
+
+	// Without a TLS configuration, one is created.
+	cfg = oconf.NewHTTPConfig(oconf.WithTLSMinVersion(tls.VersionTLS12))
+	require.NotNil(t, cfg.Metrics.TLSCfg)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.Metrics.TLSCfg.MinVersion)
+	cfg = oconf.NewGRPCConfig(oconf.WithTLSMinVersion(tls.VersionTLS12))
+	require.NotNil(t, cfg.Metrics.TLSCfg)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.Metrics.TLSCfg.MinVersion)
+}
+
+func TestWithTLSMinVersionInvalid(t *testing.T) {
+	cfg := oconf.NewHTTPConfig(oconf.WithTLSMinVersion(tls.VersionSSL30)) // nolint:staticcheck // Testing a rejected version.
+	assert.Equal(t, uint16(0), cfg.Metrics.TLSMinVersion)
+	assert.Nil(t, cfg.Metrics.TLSCfg)
+
+	cfg = oconf.NewHTTPConfig(oconf.WithTLSMinVersion(0x0999))
+	assert.Equal(t, uint16(0), cfg.Metrics.TLSMinVersion)
+
+	cfg = oconf.NewHTTPConfig(oconf.WithTLSCipherSuites([]uint16{0xffff, tls.TLS_AES_128_GCM_SHA256}))
+	assert.Equal(t, []uint16{tls.TLS_AES_128_GCM_SHA256}, cfg.Metrics.TLSCipherSuites)
+}
+
+func TestWithTLSMinVersionGRPCCredentials(t *testing.T) {
+	creds := insecure.NewCredentials()
+	cfg := oconf.NewGRPCConfig(
+		oconf.WithGRPCCredentials(creds),
+		oconf.WithTLSMinVersion(tls.VersionTLS13),
+	)
+	// Credentials not created from a TLS configuration are used as is.
+	assert.Equal(t, creds, cfg.Metrics.GRPCCredentials)
+	assert.Nil(t, cfg.Metrics.TLSCfg)
+}
+
+func TestWithTLSServerName(t *testing.T) {
+	opts := []oconf.GenericOption{
+		oconf.WithTLSClientConfig(&tls.Config{ServerName: "collector", MinVersion: tls.VersionTLS12}),
+		oconf.WithTLSServerName("collector.example.com"),
+	}
+
+	cfg := oconf.NewHTTPConfig(asHTTPOptions(opts)...)
+	require.NotNil(t, cfg.Metrics.TLSCfg)
+	assert.Equal(t, "collector.example.com", cfg.Metrics.TLSCfg.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.Metrics.TLSCfg.MinVersion)
+
+	cfg = oconf.NewGRPCConfig(asGRPCOptions(opts)...)
+	require.NotNil(t, cfg.Metrics.TLSCfg)
+	assert.Equal(t, "collector.example.com", cfg.Metrics.TLSCfg.ServerName)
+	require.NotNil(t, cfg.Metrics.GRPCCredentials)
+	assert.Equal(t, "collector.example.com", cfg.Metrics.GRPCCredentials.Info().ServerName)
+
+	// Without a TLS configuration, one is created.
+	cfg = oconf.NewHTTPConfig(oconf.WithTLSServerName("collector.example.com"))
+	require.NotNil(t, cfg.Metrics.TLSCfg)
+	assert.Equal(t, "collector.example.com", cfg.Metrics.TLSCfg.ServerName)
+	cfg = oconf.NewGRPCConfig(oconf.WithTLSServerName("collector.example.com"))
+	require.NotNil(t, cfg.Metrics.GRPCCredentials)
+	assert.Equal(t, "collector.example.com", cfg.Metrics.GRPCCredentials.Info().ServerName)
+
+	// Credentials not created from a TLS configuration are overridden
+	// without being modified.
+	creds := credentials.NewTLS(&tls.Config{ServerName: "collector"})
+	cfg = oconf.NewGRPCConfig(
+		oconf.WithGRPCCredentials(creds),
+		oconf.WithTLSServerName("collector.example.com"),
+	)
+	require.NotNil(t, cfg.Metrics.GRPCCredentials)
+	assert.Equal(t, "collector.example.com", cfg.Metrics.GRPCCredentials.Info().ServerName)
+	assert.Equal(t, "collector", creds.Info().ServerName)
+}
+
+func TestDefaults(t *testing.T) {
+	// Function values cannot be compared, check them separately.
+	withoutSelectors := func(cfg oconf.Config) oconf.Config {
+		assert.NotNil(t, cfg.Metrics.TemporalitySelector)
+		assert.NotNil(t, cfg.Metrics.AggregationSelector)
+		cfg.Metrics.TemporalitySelector = nil
+		cfg.Metrics.AggregationSelector = nil
+		return cfg
+	}
+
+	want := oconf.Defaults()
+	want.Metrics.Endpoint = "localhost:4318"
+	assert.Equal(t, withoutSelectors(want), withoutSelectors(oconf.NewHTTPConfig()))
+
+	want = oconf.Defaults()
+	want.Metrics.Endpoint = "localhost:4317"
+	got := oconf.NewGRPCConfig()
+	// The gRPC config adds the dial options and credentials derived from the
+	// defaults.
+	assert.NotEmpty(t, got.DialOptions)
+	got.DialOptions = nil
+	got.Metrics.GRPCCredentials = nil
+	assert.Equal(t, withoutSelectors(want), withoutSelectors(got))
+
+	// Modifying the returned Config does not change the defaults.
+	d := oconf.Defaults()
+	d.RetryConfig.InitialInterval = time.Hour
+	assert.Equal(t, retry.DefaultConfig, oconf.Defaults().RetryConfig)
+}
+
+func TestConfigClone(t *testing.T) {
+	orig := oconf.NewGRPCConfig(
+		oconf.WithHeaders(map[string]string{"a": "1"}),
+		oconf.WithEndpoints("collector-1", "collector-2"),
+		oconf.WithTLSClientConfig(&tls.Config{ServerName: "collector"}),
+		oconf.WithTLSCipherSuites([]uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}),
+		oconf.WithGRPCKeepalive(keepalive.ClientParameters{Time: time.Minute}),
+	)
+	clone := orig.Clone()
+	assert.Equal(t, orig.Metrics.Headers, clone.Metrics.Headers)
+	assert.Equal(t, orig.Metrics.TLSCfg.ServerName, clone.Metrics.TLSCfg.ServerName)
+
+	clone.Metrics.Headers["a"] = "2"
+	clone.Metrics.Headers["b"] = "3"
+	clone.Metrics.Endpoints[0] = "other"
+	clone.Metrics.TLSCfg.ServerName = "other"
+	clone.Metrics.TLSCipherSuites[0] = tls.TLS_AES_128_GCM_SHA256
+	clone.KeepaliveParams.Time = time.Second
+
+	assert.Equal(t, map[string]string{"a": "1"}, orig.Metrics.Headers)
+	assert.Equal(t, "collector-1:4317", orig.Metrics.Endpoints[0])
+	assert.Equal(t, "collector", orig.Metrics.TLSCfg.ServerName)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, orig.Metrics.TLSCipherSuites)
+	assert.Equal(t, time.Minute, orig.KeepaliveParams.Time)
+
+	// Nil values stay nil.
+	empty := oconf.Config{}.Clone()
+	assert.Nil(t, empty.Metrics.Headers)
+	assert.Nil(t, empty.DialOptions)
+	assert.Nil(t, empty.Metrics.TLSCfg)
+}
+
+func TestWithProtoMarshalOptions(t *testing.T) {
+	cfg := oconf.NewHTTPConfig()
+	assert.False(t, cfg.Metrics.ProtoMarshalOptions.Deterministic)
+
+	cfg = oconf.NewHTTPConfig(oconf.WithProtoMarshalOptions(proto.MarshalOptions{Deterministic: true}))
+	assert.True(t, cfg.Metrics.ProtoMarshalOptions.Deterministic)
+}
+
+func TestWithHeadersFromFile(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "headers")
+	require.NoError(t, os.WriteFile(valid, []byte("# From a secret.\napi-key: secret\nx-tenant=a\n"), 0o600))
+	malformed := filepath.Join(dir, "malformed")
+	require.NoError(t, os.WriteFile(malformed, []byte("api-key: secret\nmalformed\n"), 0o600))
+
+	existing := oconf.WithHeaders(map[string]string{"x-tenant": "default", "x-other": "1"})
+
+	cfg := oconf.NewHTTPConfig(existing, oconf.WithHeadersFromFile(valid))
+	assert.Equal(t, map[string]string{"api-key": "secret", "x-tenant": "a", "x-other": "1"}, cfg.Metrics.Headers)
+	cfg = oconf.NewGRPCConfig(existing, oconf.WithHeadersFromFile(valid))
+	assert.Equal(t, map[string]string{"api-key": "secret", "x-tenant": "a", "x-other": "1"}, cfg.Metrics.Headers)
+
+	want := map[string]string{"x-tenant": "default", "x-other": "1"}
+	cfg = oconf.NewHTTPConfig(existing, oconf.WithHeadersFromFile(filepath.Join(dir, "missing")))
+	assert.Equal(t, want, cfg.Metrics.Headers, "missing file changed headers")
+	cfg = oconf.NewHTTPConfig(existing, oconf.WithHeadersFromFile(malformed))
+	assert.Equal(t, want, cfg.Metrics.Headers, "malformed file changed headers")
+}
+
+func TestWithServiceConfigFromFile(t *testing.T) {
+	dir := t.TempDir()
+	const sc = `{"loadBalancingConfig":[{"round_robin":{}}]}`
+	valid := filepath.Join(dir, "valid.json")
+	require.NoError(t, os.WriteFile(valid, []byte(sc), 0o600))
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"loadBalancingConfig":`), 0o600))
+
+	cfg := oconf.NewGRPCConfig(oconf.WithServiceConfigFromFile(valid))
+	assert.Equal(t, sc, cfg.ServiceConfig)
+
+	keep := oconf.NewGRPCOption(func(cfg oconf.Config) oconf.Config {
+		cfg.ServiceConfig = "{}"
+		return cfg
+	})
+	cfg = oconf.NewGRPCConfig(keep, oconf.WithServiceConfigFromFile(filepath.Join(dir, "missing.json")))
+	assert.Equal(t, "{}", cfg.ServiceConfig, "missing file changed config")
+
+	cfg = oconf.NewGRPCConfig(keep, oconf.WithServiceConfigFromFile(invalid))
+	assert.Equal(t, "{}", cfg.ServiceConfig, "invalid JSON changed config")
+}
+
+func TestWithMonotonicViolationPolicy(t *testing.T) {
+	cfg := oconf.NewHTTPConfig()
+	assert.Equal(t, ominternal.PassThroughMonotonicViolation, cfg.Metrics.MonotonicViolationPolicy)
+	assert.Len(t, cfg.Metrics.Transforms(), 0)
+
+	opt := oconf.WithMonotonicViolationPolicy(ominternal.ClampMonotonicViolation)
+	cfg = oconf.NewGRPCConfig(opt)
+	assert.Equal(t, ominternal.ClampMonotonicViolation, cfg.Metrics.MonotonicViolationPolicy)
+	assert.Len(t, cfg.Metrics.Transforms(), 1)
+}
+
+func generateCA(t *testing.T, name string) []byte {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestWithTLSCertPool(t *testing.T) {
+	dir := t.TempDir()
+	ca1, ca2 := generateCA(t, "ca1"), generateCA(t, "ca2")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca1.pem"), ca1, 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "ca2.crt"), ca2, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("not a cert"), 0o600))
+
+	want := x509.NewCertPool()
+	require.True(t, want.AppendCertsFromPEM(ca1))
+	require.True(t, want.AppendCertsFromPEM(ca2))
+
+	clientCert, err := tls.X509KeyPair([]byte(WeakCertificate), []byte(WeakPrivateKey))
+	require.NoError(t, err)
+	tlsCfg := &tls.Config{Certificates: []tls.Certificate{clientCert}}
+
+	cfg := oconf.NewHTTPConfig(oconf.WithTLSClientConfig(tlsCfg), oconf.WithTLSCertPool(dir))
+	require.NotNil(t, cfg.Metrics.TLSCfg)
+	assert.True(t, want.Equal(cfg.Metrics.TLSCfg.RootCAs), "not all certificates loaded")
+	assert.Len(t, cfg.Metrics.TLSCfg.Certificates, 1, "client certificate not preserved")
+	assert.Nil(t, tlsCfg.RootCAs, "passed TLS config modified")
+
+	cfg = oconf.NewGRPCConfig(oconf.WithTLSCertPool(dir))
+	require.NotNil(t, cfg.Metrics.GRPCCredentials)
+	assert.Equal(t, "tls", cfg.Metrics.GRPCCredentials.Info().SecurityProtocol)
+
+	// Client certificates are kept for gRPC as well.
+	cfg = oconf.NewGRPCConfig(oconf.WithTLSClientConfig(tlsCfg), oconf.WithTLSCertPool(dir))
+	require.NotNil(t, cfg.Metrics.TLSCfg)
+	assert.True(t, want.Equal(cfg.Metrics.TLSCfg.RootCAs), "not all certificates loaded")
+	assert.Len(t, cfg.Metrics.TLSCfg.Certificates, 1, "client certificate not preserved")
+	assert.Nil(t, tlsCfg.RootCAs, "passed TLS config modified")
+	require.NotNil(t, cfg.Metrics.GRPCCredentials)
+	assert.Equal(t, "tls", cfg.Metrics.GRPCCredentials.Info().SecurityProtocol)
+
+	// A directory without any certificate leaves the config unchanged.
+	empty := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(empty, "README"), []byte("not a cert"), 0o600))
+	cfg = oconf.NewHTTPConfig(oconf.WithTLSClientConfig(tlsCfg), oconf.WithTLSCertPool(empty))
+	assert.Nil(t, cfg.Metrics.TLSCfg.RootCAs)
+	cfg = oconf.NewGRPCConfig(oconf.WithInsecure(), oconf.WithTLSCertPool(empty))
+	assert.Nil(t, cfg.Metrics.GRPCCredentials)
+}
+
+func TestEnvCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca1, ca2 := generateCA(t, "ca1"), generateCA(t, "ca2")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca1.pem"), ca1, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca2.pem"), ca2, 0o600))
+	empty := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(empty, "README"), []byte("not a cert"), 0o600))
+
+	single := x509.NewCertPool()
+	require.True(t, single.AppendCertsFromPEM(ca1))
+	both := x509.NewCertPool()
+	require.True(t, both.AppendCertsFromPEM(ca1))
+	require.True(t, both.AppendCertsFromPEM(ca2))
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  *x509.CertPool
+	}{
+		{"File", "OTEL_EXPORTER_OTLP_METRICS_CERTIFICATE", filepath.Join(dir, "ca1.pem"), single},
+		{"Directory", "OTEL_EXPORTER_OTLP_METRICS_CERTIFICATE", dir, both},
+		{"GenericDirectory", "OTEL_EXPORTER_OTLP_CERTIFICATE", dir, both},
+		{"EmptyDirectory", "OTEL_EXPORTER_OTLP_METRICS_CERTIFICATE", empty, nil},
+		{"Missing", "OTEL_EXPORTER_OTLP_METRICS_CERTIFICATE", filepath.Join(dir, "missing.pem"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origEOR := oconf.DefaultEnvOptionsReader
+			oconf.DefaultEnvOptionsReader.GetEnv = func(key string) string {
+				if key == tt.key {
+					return tt.value
+				}
+				return ""
+			}
+			t.Cleanup(func() { oconf.DefaultEnvOptionsReader = origEOR })
+
+			cfg := oconf.NewHTTPConfig()
+			if tt.want == nil {
+				assert.Nil(t, cfg.Metrics.TLSCfg)
+				return
+			}
+			require.NotNil(t, cfg.Metrics.TLSCfg)
+			assert.True(t, tt.want.Equal(cfg.Metrics.TLSCfg.RootCAs), "unexpected certificates loaded")
+
+			cfg = oconf.NewGRPCConfig()
+			require.NotNil(t, cfg.Metrics.TLSCfg)
+			assert.True(t, tt.want.Equal(cfg.Metrics.TLSCfg.RootCAs), "unexpected certificates loaded")
+		})
+	}
+}
+
+func TestDefaultHistogramAggregationEnv(t *testing.T) {
+	var logged []string
+	otel.SetLogger(funcr.New(func(prefix, args string) {
+		logged = append(logged, args)
+	}, funcr.Options{}))
+	t.Cleanup(func() { otel.SetLogger(stdr.New(log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile))) })
+
+	isDefault := func(sel metric.AggregationSelector) bool {
+		return reflect.ValueOf(sel).Pointer() == reflect.ValueOf(metric.AggregationSelector(metric.DefaultAggregationSelector)).Pointer()
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		applied bool
+		err     bool
+	}{
+		{name: "explicit", value: "explicit_bucket_histogram", applied: true},
+		{name: "explicit mixed case", value: "Explicit_Bucket_Histogram", applied: true},
+		// Not supported by the SDK, the default is kept.
+		{name: "base2 exponential", value: "base2_exponential_bucket_histogram", err: true},
+		{name: "invalid", value: "invalid", err: true},
+		{name: "empty", value: " ", err: true},
+		{name: "unset", value: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			origEOR := oconf.DefaultEnvOptionsReader
+			oconf.DefaultEnvOptionsReader.GetEnv = func(key string) string {
+				if key == "OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION" {
+					return test.value
+				}
+				return ""
+			}
+			t.Cleanup(func() { oconf.DefaultEnvOptionsReader = origEOR })
+
+			newConfigs := []func() oconf.Config{
+				func() oconf.Config { return oconf.NewHTTPConfig() },
+				func() oconf.Config { return oconf.NewGRPCConfig() },
+			}
+			for _, newConfig := range newConfigs {
+				logged = logged[:0]
+				cfg := newConfig()
+
+				var errLogged bool
+				for _, l := range logged {
+					if strings.Contains(l, "OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION ignored") {
+						errLogged = true
+					}
+				}
+				assert.Equal(t, test.err, errLogged, "error logged")
+
+				sel := cfg.Metrics.AggregationSelector
+				assert.Equal(t, !test.applied, isDefault(sel), "selector applied")
+				assert.IsType(t, aggregation.ExplicitBucketHistogram{}, sel(metric.InstrumentKindHistogram))
+				assert.Equal(t, aggregation.Sum{}, sel(metric.InstrumentKindCounter))
+				assert.Equal(t, aggregation.LastValue{}, sel(metric.InstrumentKindObservableGauge))
+			}
+		})
+	}
+}
+
+func TestWithEndpointIPv6(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		wantHTTP string
+		wantGRPC string
+	}{
+		{"[::1]", "[::1]:4318", "[::1]:4317"},
+		{"[::1]:1234", "[::1]:1234", "[::1]:1234"},
+		{"[2001:db8::1]", "[2001:db8::1]:4318", "[2001:db8::1]:4317"},
+		{"[2001:db8::1]:4317", "[2001:db8::1]:4317", "[2001:db8::1]:4317"},
+		{"[fe80::1%eth0]", "[fe80::1%eth0]:4318", "[fe80::1%eth0]:4317"},
+		{"[fe80::1%eth0]:1234", "[fe80::1%eth0]:1234", "[fe80::1%eth0]:1234"},
+		{"[::ffff:192.0.2.1]:1234", "[::ffff:192.0.2.1]:1234", "[::ffff:192.0.2.1]:1234"},
+		// Ambiguous unbracketed addresses are ignored.
+		{"::1", "localhost:4318", "localhost:4317"},
+		{"::1:4317", "localhost:4318", "localhost:4317"},
+		{"2001:db8::1:4317", "localhost:4318", "localhost:4317"},
+		{"fe80::1%eth0", "localhost:4318", "localhost:4317"},
+	}
+	for _, test := range tests {
+		t.Run(test.endpoint, func(t *testing.T) {
+			opt := oconf.WithEndpoint(test.endpoint)
+			assert.Equal(t, test.wantHTTP, oconf.NewHTTPConfig(opt).Metrics.Endpoint, "HTTP")
+			assert.Equal(t, test.wantGRPC, oconf.NewGRPCConfig(opt).Metrics.Endpoint, "gRPC")
+		})
+	}
+}
+
+func TestWithEndpointEmptyHost(t *testing.T) {
+	for _, endpoint := range []string{"", " ", ":4318", "http://", "https://", "https://:4318"} {
+		t.Run(endpoint, func(t *testing.T) {
+			opt := oconf.WithEndpoint(endpoint)
+			assert.Equal(t, "localhost:4318", oconf.NewHTTPConfig(opt).Metrics.Endpoint, "HTTP")
+			assert.Equal(t, "localhost:4317", oconf.NewGRPCConfig(opt).Metrics.Endpoint, "gRPC")
+
+			// A previously set endpoint is preserved.
+			prev := oconf.WithEndpoint("collector:1234")
+			assert.Equal(t, "collector:1234", oconf.NewHTTPConfig(prev, opt).Metrics.Endpoint, "HTTP")
+			assert.Equal(t, "collector:1234", oconf.NewGRPCConfig(prev, opt).Metrics.Endpoint, "gRPC")
+		})
+	}
+}
+
+func TestWithEndpointBasePath(t *testing.T) {
+	for _, endpoint := range []string{"https://collector/base/", "https://collector/base", "collector/base/", "collector/base"} {
+		t.Run(endpoint, func(t *testing.T) {
+			cfg := oconf.NewHTTPConfig(oconf.WithEndpoint(endpoint))
+			assert.Equal(t, "collector:4318", cfg.Metrics.Endpoint)
+			assert.Equal(t, "/base/v1/metrics", cfg.Metrics.URLPath)
+		})
+	}
+
+	// Endpoints without a path keep the URL path.
+	cfg := oconf.NewHTTPConfig(oconf.WithURLPath("/custom"), oconf.WithEndpoint("collector"))
+	assert.Equal(t, "/custom", cfg.Metrics.URLPath)
+	cfg = oconf.NewHTTPConfig(oconf.WithEndpoint("collector/"))
+	assert.Equal(t, "/v1/metrics", cfg.Metrics.URLPath)
+
+	// The option can be applied to multiple configurations.
+	opt := oconf.WithEndpoint("collector/base/")
+	assert.Equal(t, "/base/v1/metrics", oconf.NewHTTPConfig(opt).Metrics.URLPath)
+	assert.Equal(t, "/base/v1/metrics", oconf.NewHTTPConfig(opt).Metrics.URLPath)
+}
+
+func TestEnvEndpointBasePath(t *testing.T) {
+	for _, endpoint := range []string{"https://collector/base/", "https://collector/base"} {
+		t.Run(endpoint, func(t *testing.T) {
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", endpoint)
+			assert.Equal(t, "/base/v1/metrics", oconf.NewHTTPConfig().Metrics.URLPath)
+		})
+	}
+}
+
+func TestWithStartTimeout(t *testing.T) {
+	assert.Equal(t, time.Duration(0), oconf.NewGRPCConfig().StartTimeout)
+	cfg := oconf.NewGRPCConfig(oconf.WithStartTimeout(time.Second))
+	assert.Equal(t, time.Second, cfg.StartTimeout)
+}
+
+func TestWithEndpointsHTTP(t *testing.T) {
+	cfg := oconf.NewHTTPConfig(oconf.WithEndpoints("collector-a", "", "collector-b:1234"))
+	assert.Equal(t, []string{"collector-a:4318", "collector-b:1234"}, cfg.Metrics.Endpoints)
+	assert.Equal(t, "collector-a:4318", cfg.Metrics.Endpoint)
+
+	// Endpoints with a scheme or path are ignored.
+	cfg = oconf.NewHTTPConfig(oconf.WithEndpoints(
+		"https://collector-a",
+		"collector-b/v1/metrics",
+		"[::1]",
+	))
+	assert.Equal(t, []string{"[::1]:4318"}, cfg.Metrics.Endpoints)
+	assert.Equal(t, "/v1/metrics", cfg.Metrics.URLPath)
+
+	// Without any valid endpoint, the default is kept.
+	cfg = oconf.NewHTTPConfig(oconf.WithEndpoints("", "http://collector-a:4318"))
+	assert.Empty(t, cfg.Metrics.Endpoints)
+	assert.Equal(t, "localhost:4318", cfg.Metrics.Endpoint)
+}
+
+func TestWithEndpointsGRPC(t *testing.T) {
+	cfg := oconf.NewGRPCConfig(oconf.WithEndpoints("collector-a", "", "collector-b:1234"))
+	assert.Equal(t, []string{"collector-a:4317", "collector-b:1234"}, cfg.Metrics.Endpoints)
+	assert.Equal(t, "collector-a:4317", cfg.Metrics.Endpoint)
+	assert.Equal(t, "otlp-failover:///collector-a:4317", cfg.GRPCTarget())
+
+	// A single endpoint is dialed directly.
+	cfg = oconf.NewGRPCConfig(oconf.WithEndpoints("collector-a"))
+	assert.Equal(t, "collector-a:4317", cfg.GRPCTarget())
+
+	// The last endpoint option passed is used.
+	cfg = oconf.NewGRPCConfig(oconf.WithEndpoints("collector-a", "collector-b"), oconf.WithEndpoint("collector-c"))
+	assert.Empty(t, cfg.Metrics.Endpoints)
+	assert.Equal(t, "collector-c:4317", cfg.GRPCTarget())
+
+	// Without any valid endpoint, the default is kept.
+	cfg = oconf.NewGRPCConfig(oconf.WithEndpoints("", ":4317"))
+	assert.Empty(t, cfg.Metrics.Endpoints)
+	assert.Equal(t, "localhost:4317", cfg.GRPCTarget())
+}
+
+func TestWithReconnectionPeriod(t *testing.T) {
+	base := oconf.NewGRPCConfig()
+	tests := []struct {
+		name string
+		rp   time.Duration
+		want time.Duration
+	}{
+		{"Zero", 0, 0},
+		{"Negative", -time.Second, oconf.MinReconnectionPeriod},
+		{"TooSmall", time.Millisecond, oconf.MinReconnectionPeriod},
+		{"Minimum", oconf.MinReconnectionPeriod, oconf.MinReconnectionPeriod},
+		{"Valid", 5 * time.Second, 5 * time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := oconf.NewGRPCConfig(oconf.WithReconnectionPeriod(test.rp))
+			assert.Equal(t, test.want, cfg.ReconnectionPeriod)
+
+			want := len(base.DialOptions)
+			if test.want != 0 {
+				// Connection parameters are added.
+				want++
+			}
+			assert.Len(t, cfg.DialOptions, want)
+		})
+	}
+}
+
+func TestConfigString(t *testing.T) {
+	tlsCert, err := tls.X509KeyPair([]byte(WeakCertificate), []byte(WeakPrivateKey))
+	require.NoError(t, err)
+
+	cfg := oconf.NewHTTPConfig(
+		oconf.WithEndpoint("collector:4318"),
+		oconf.WithInsecure(),
+		oconf.WithHeaders(map[string]string{"Authorization": "Bearer secret-token", "b": "secret-value"}),
+		oconf.WithCompression(oconf.GzipCompression),
+		oconf.WithTimeout(5*time.Second),
+		oconf.WithTLSClientConfig(&tls.Config{Certificates: []tls.Certificate{tlsCert}}),
+	)
+	got := cfg.String()
+
+	for _, want := range []string{
+		`endpoint="collector:4318"`,
+		`url_path="/v1/metrics"`,
+		"insecure=true",
+		"compression=gzip",
+		"timeout=5s",
+		"headers={Authorization=****, b=****}",
+		"tls_config_set=true",
+		"tls_client_certificates=1",
+		"retry={enabled=true initial_interval=5s max_interval=30s max_elapsed_time=1m0s multiplier=0 randomization_factor=0}",
+	} {
+		assert.Contains(t, got, want)
+	}
+	for _, secret := range []string{"secret-token", "secret-value", "CERTIFICATE", "PRIVATE KEY"} {
+		assert.NotContains(t, got, secret)
+	}
+}
+
+func TestCompressionText(t *testing.T) {
+	for _, c := range []oconf.Compression{oconf.NoCompression, oconf.GzipCompression} {
+		text, err := c.MarshalText()
+		require.NoError(t, err)
+		assert.Equal(t, c.String(), string(text))
+
+		var got oconf.Compression
+		require.NoError(t, got.UnmarshalText(text))
+		assert.Equal(t, c, got)
+	}
+
+	var c oconf.Compression
+	require.NoError(t, c.UnmarshalText([]byte("GZIP")))
+	assert.Equal(t, oconf.GzipCompression, c)
+
+	for _, text := range []string{"", "zstd", "gzip9", "garbage"} {
+		c := oconf.GzipCompression
+		assert.Error(t, c.UnmarshalText([]byte(text)), text)
+		assert.Equal(t, oconf.GzipCompression, c, "changed on error")
+	}
+
+	_, err := oconf.Compression(-1).MarshalText()
+	assert.Error(t, err)
+	assert.Equal(t, "Compression(-1)", oconf.Compression(-1).String())
+}
+
+func TestWithTLSClientConfigNil(t *testing.T) {
+	tlsCfg := &tls.Config{ServerName: "unchanged"}
+
+	var cfg oconf.Config
+	assert.NotPanics(t, func() {
+		cfg = oconf.NewHTTPConfig(oconf.WithTLSClientConfig(tlsCfg), oconf.WithTLSClientConfig(nil))
+	})
+	require.NotNil(t, cfg.Metrics.TLSCfg)
+	assert.Equal(t, "unchanged", cfg.Metrics.TLSCfg.ServerName)
+
+	assert.NotPanics(t, func() {
+		cfg = oconf.NewGRPCConfig(oconf.WithInsecure(), oconf.WithTLSClientConfig(nil))
+	})
+	assert.Nil(t, cfg.Metrics.GRPCCredentials, "credentials set from nil TLS config")
+	assert.True(t, cfg.Metrics.Insecure)
+}
+
+func TestWithGRPCDialOption(t *testing.T) {
+	base := oconf.NewGRPCConfig()
+	block, auth := grpc.WithBlock(), grpc.WithAuthority("collector")
+
+	cfg := oconf.NewGRPCConfig(
+		oconf.WithGRPCDialOption(block),
+		oconf.WithGRPCDialOption(auth),
+	)
+	assert.Equal(t, []grpc.DialOption{block, auth}, cfg.GRPCDialOptions)
+	// Appended after the default options.
+	require.Len(t, cfg.DialOptions, len(base.DialOptions)+2)
+	n := len(cfg.DialOptions)
+	assert.Equal(t, block, cfg.DialOptions[n-2])
+	assert.Equal(t, auth, cfg.DialOptions[n-1])
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	c := &http.Client{}
+	cfg := oconf.NewHTTPConfig(oconf.WithHTTPClient(c))
+	assert.Same(t, c, cfg.Metrics.HTTPClient)
+
+	assert.Nil(t, oconf.NewHTTPConfig().Metrics.HTTPClient)
+}
+
+func TestWithRetryFields(t *testing.T) {
+	cfg := oconf.NewHTTPConfig(oconf.WithRetryInitialInterval(time.Second))
+	want := retry.DefaultConfig
+	want.InitialInterval = time.Second
+	assert.Equal(t, want, cfg.RetryConfig)
+
+	cfg = oconf.NewGRPCConfig(oconf.WithRetryMaxInterval(time.Minute))
+	want = retry.DefaultConfig
+	want.MaxInterval = time.Minute
+	assert.Equal(t, want, cfg.RetryConfig)
+
+	cfg = oconf.NewHTTPConfig(oconf.WithRetryMultiplier(2))
+	assert.Equal(t, retry.DefaultConfig, cfg.RetryConfig)
+	assert.Equal(t, retry.Backoff{Multiplier: 2}, cfg.RetryBackoff)
+
+	// Invalid multipliers are ignored.
+	cfg = oconf.NewHTTPConfig(oconf.WithRetryMultiplier(0.5))
+	assert.Equal(t, retry.Backoff{}, cfg.RetryBackoff)
+
+	cfg = oconf.NewGRPCConfig(oconf.WithRetryRandomizationFactor(-1))
+	assert.Equal(t, retry.DefaultConfig, cfg.RetryConfig)
+	assert.Equal(t, retry.Backoff{RandomizationFactor: -1}, cfg.RetryBackoff)
+}
+
+func TestWithRetryFieldsCompose(t *testing.T) {
+	rc := retry.Config{
+		Enabled:         true,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Second,
+		MaxElapsedTime:  time.Minute,
+	}
+	cfg := oconf.NewHTTPConfig(
+		oconf.WithRetry(rc),
+		oconf.WithRetryMaxInterval(10*time.Second),
+		oconf.WithRetryMultiplier(3),
+	)
+	want := rc
+	want.MaxInterval = 10 * time.Second
+	assert.Equal(t, want, cfg.RetryConfig)
+	assert.Equal(t, retry.Backoff{Multiplier: 3}, cfg.RetryBackoff)
+
+	// Later options win.
+	cfg = oconf.NewHTTPConfig(
+		oconf.WithRetryInitialInterval(time.Hour),
+		oconf.WithRetryMultiplier(3),
+		oconf.WithRetry(rc),
+	)
+	assert.Equal(t, rc, cfg.RetryConfig)
+	// The multiplier is not part of the RetryConfig and is kept.
+	assert.Equal(t, retry.Backoff{Multiplier: 3}, cfg.RetryBackoff)
+}
+
+func TestWithEndpointScheme(t *testing.T) {
+	testCases := []struct {
+		name         string
+		opts         []oconf.GenericOption
+		wantInsecure bool
+	}{
+		{
+			name:         "https",
+			opts:         []oconf.GenericOption{oconf.WithEndpoint("https://collector:4318")},
+			wantInsecure: false,
+		},
+		{
+			name:         "http",
+			opts:         []oconf.GenericOption{oconf.WithEndpoint("HTTP://collector:4318")},
+			wantInsecure: true,
+		},
+		{
+			name: "https then insecure",
+			opts: []oconf.GenericOption{
+				oconf.WithEndpoint("https://collector:4318"),
+				oconf.WithInsecure(),
+			},
+			wantInsecure: false,
+		},
+		{
+			name: "insecure then https",
+			opts: []oconf.GenericOption{
+				oconf.WithInsecure(),
+				oconf.WithEndpoint("https://collector:4318"),
+			},
+			wantInsecure: false,
+		},
+		{
+			name: "http then secure",
+			opts: []oconf.GenericOption{
+				oconf.WithEndpoint("http://collector:4318"),
+				oconf.WithSecure(),
+			},
+			wantInsecure: true,
+		},
+		{
+			name: "scheme replaced",
+			opts: []oconf.GenericOption{
+				oconf.WithEndpoint("https://collector:4318"),
+				oconf.WithEndpoint("collector:4318"),
+				oconf.WithInsecure(),
+			},
+			wantInsecure: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			httpOpts := make([]oconf.HTTPOption, len(tc.opts))
+			grpcOpts := make([]oconf.GRPCOption, len(tc.opts))
+			for i, o := range tc.opts {
+				httpOpts[i], grpcOpts[i] = o, o
+			}
+
+			cfg := oconf.NewHTTPConfig(httpOpts...)
+			assert.Equal(t, "collector:4318", cfg.Metrics.Endpoint)
+			assert.Equal(t, tc.wantInsecure, cfg.Metrics.Insecure)
+
+			cfg = oconf.NewGRPCConfig(grpcOpts...)
+			assert.Equal(t, "collector:4318", cfg.Metrics.Endpoint)
+			assert.Equal(t, tc.wantInsecure, cfg.Metrics.Insecure)
+			assert.Equal(t, tc.wantInsecure, cfg.Metrics.GRPCCredentials == nil)
+		})
+	}
+}
+
+func TestWithGRPCCredentials(t *testing.T) {
+	creds := credentials.NewTLS(&tls.Config{ServerName: "collector"})
+	cfg := oconf.NewGRPCConfig(oconf.WithGRPCCredentials(creds))
+	assert.Same(t, creds, cfg.Metrics.GRPCCredentials, "credentials overridden by the default")
+
+	// Explicit credentials take precedence over insecure.
+	cfg = oconf.NewGRPCConfig(oconf.WithInsecure(), oconf.WithGRPCCredentials(creds))
+	assert.Same(t, creds, cfg.Metrics.GRPCCredentials)
+
+	cfg = oconf.NewGRPCConfig()
+	require.NotNil(t, cfg.Metrics.GRPCCredentials)
+	assert.NotSame(t, creds, cfg.Metrics.GRPCCredentials)
+	assert.Equal(t, "tls", cfg.Metrics.GRPCCredentials.Info().SecurityProtocol)
+}
+
+func TestWithUserAgent(t *testing.T) {
+	base := ominternal.GetUserAgentHeader()
+	assert.Equal(t, base, oconf.NewHTTPConfig().Metrics.UserAgent())
+
+	cfg := oconf.NewHTTPConfig(oconf.WithUserAgent(" myapp/1.2.3 "))
+	assert.Equal(t, base+" myapp/1.2.3", cfg.Metrics.UserAgent())
+
+	// The last suffix is used.
+	cfg = oconf.NewGRPCConfig(oconf.WithUserAgent("a/1"), oconf.WithUserAgent("b/2"))
+	assert.Equal(t, base+" b/2", cfg.Metrics.UserAgent())
+	assert.Len(t, cfg.DialOptions, len(oconf.NewGRPCConfig().DialOptions))
+
+	// Invalid suffixes are ignored.
+	cfg = oconf.NewHTTPConfig(oconf.WithUserAgent("a/1"), oconf.WithUserAgent("b/2\r\nX-Injected: 1"))
+	assert.Equal(t, base+" a/1", cfg.Metrics.UserAgent())
+
+	// The same option can be applied concurrently.
+	opt := oconf.WithUserAgent(" myapp/1.2.3 ")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, base+" myapp/1.2.3", oconf.NewGRPCConfig(opt).Metrics.UserAgent())
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWithStrictURLPath(t *testing.T) {
+	cfg := oconf.NewHTTPConfig(oconf.WithURLPath("prefix/../v2/metrics"))
+	assert.Equal(t, "/v2/metrics", cfg.Metrics.URLPath)
+
+	cfg = oconf.NewHTTPConfig(oconf.WithURLPath("prefix/../v2/metrics"), oconf.WithStrictURLPath())
+	assert.Equal(t, oconf.DefaultMetricsPath, cfg.Metrics.URLPath)
+
+	cfg = oconf.NewHTTPConfig(oconf.WithURLPath("/prefix/v2/metrics"), oconf.WithStrictURLPath())
+	assert.Equal(t, "/prefix/v2/metrics", cfg.Metrics.URLPath)
+}
+
+func TestWithMetricsURLPath(t *testing.T) {
+	opt := oconf.WithMetricsURLPath(" prefix//./v1/metrics/?tenant=a ")
+	// Cleaned when applied.
+	var cfg oconf.Config
+	cfg = opt.ApplyHTTPOption(cfg)
+	assert.Equal(t, "/prefix/v1/metrics?tenant=a", cfg.Metrics.URLPath)
+
+	assert.Equal(t, "/prefix/v1/metrics?tenant=a", oconf.NewHTTPConfig(opt).Metrics.URLPath)
+	assert.Equal(t, oconf.DefaultMetricsPath, oconf.NewHTTPConfig(oconf.WithMetricsURLPath("dir/..")).Metrics.URLPath)
+
+	cfg = oconf.NewHTTPConfig(oconf.WithStrictURLPath(), oconf.WithMetricsURLPath("dir/../other"))
+	assert.Equal(t, oconf.DefaultMetricsPath, cfg.Metrics.URLPath)
+}
+
+func TestGRPCTarget(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []oconf.GRPCOption
+		want string
+	}{
+		{name: "default", want: "localhost:4317"},
+		{name: "host", opts: []oconf.GRPCOption{oconf.WithEndpoint("collector")}, want: "collector:4317"},
+		{name: "host:port", opts: []oconf.GRPCOption{oconf.WithEndpoint("collector:1234")}, want: "collector:1234"},
+		{name: "IPv6", opts: []oconf.GRPCOption{oconf.WithEndpoint("[::1]")}, want: "[::1]:4317"},
+		{name: "https", opts: []oconf.GRPCOption{oconf.WithEndpoint("https://collector")}, want: "collector:4317"},
+		{
+			name: "http insecure",
+			opts: []oconf.GRPCOption{oconf.WithEndpoint("http://collector:1234"), oconf.WithInsecure()},
+			want: "collector:1234",
+		},
+		{
+			name: "insecure",
+			opts: []oconf.GRPCOption{oconf.WithInsecure(), oconf.WithEndpoint("collector")},
+			want: "collector:4317",
+		},
+		{name: "unix", opts: []oconf.GRPCOption{oconf.WithEndpoint("unix:///var/run/otel.sock")}, want: "unix:///var/run/otel.sock"},
+		{name: "dns", opts: []oconf.GRPCOption{oconf.WithEndpoint("dns:///collector:4317")}, want: "dns:///collector:4317"},
+		{name: "custom scheme", opts: []oconf.GRPCOption{oconf.WithEndpoint("xds:///collector:4317")}, want: "xds:///collector:4317"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, oconf.NewGRPCConfig(tt.opts...).GRPCTarget())
+		})
+	}
+
+	conn, err := grpc.Dial("passthrough:///collector:4317", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	cfg := oconf.NewGRPCConfig(oconf.WithEndpoint("ignored"), oconf.WithGRPCConn(conn))
+	assert.Equal(t, "passthrough:///collector:4317", cfg.GRPCTarget())
+}
+
+func TestWithGRPCResolvers(t *testing.T) {
+	cfg := oconf.NewGRPCConfig()
+	n := len(cfg.DialOptions)
+
+	r := manual.NewBuilderWithScheme("fake")
+	cfg = oconf.NewGRPCConfig(oconf.WithEndpoint("fake:///collector"), oconf.WithGRPCResolvers(r))
+	assert.Equal(t, []resolver.Builder{r}, cfg.GRPCResolvers)
+	assert.Equal(t, "fake:///collector", cfg.GRPCTarget())
+	assert.Len(t, cfg.DialOptions, n+1)
+}
+
+func TestRetryElapsedTimeWarning(t *testing.T) {
+	var logged []string
+	otel.SetLogger(funcr.New(func(prefix, args string) {
+		logged = append(logged, args)
+	}, funcr.Options{Verbosity: 1}))
+	t.Cleanup(func() { otel.SetLogger(stdr.New(log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile))) })
+
+	const msg = "failed exports will not be retried"
+	tests := []struct {
+		name string
+		opts []oconf.GenericOption
+		warn bool
+	}{
+		{name: "Default"},
+		{
+			name: "MaxElapsedTimeShorter",
+			opts: []oconf.GenericOption{oconf.WithRetry(retry.Config{
+				Enabled:         true,
+				InitialInterval: 5 * time.Second,
+				MaxElapsedTime:  time.Second,
+			})},
+			warn: true,
+		},
+		{
+			name: "RetryDisabled",
+			opts: []oconf.GenericOption{oconf.WithRetry(retry.Config{
+				InitialInterval: 5 * time.Second,
+				MaxElapsedTime:  time.Second,
+			})},
+		},
+		{
+			// The timeout only limits each attempt.
+			name: "TimeoutShorter",
+			opts: []oconf.GenericOption{
+				oconf.WithTimeout(time.Second),
+				oconf.WithRetryInitialInterval(5 * time.Second),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged = nil
+			oconf.NewHTTPConfig(asHTTPOptions(tt.opts)...)
+			oconf.NewGRPCConfig(asGRPCOptions(tt.opts)...)
+
+			var warned int
+			for _, l := range logged {
+				if strings.Contains(l, msg) {
+					warned++
+				}
+			}
+			if tt.warn {
+				assert.Equal(t, 2, warned, "HTTP and gRPC configs did not warn")
+			} else {
+				assert.Equal(t, 0, warned)
+			}
+		})
+	}
+}
+
+func TestGRPCConnConflict(t *testing.T) {
+	var logged []string
+	otel.SetLogger(funcr.New(func(prefix, args string) {
+		logged = append(logged, args)
+	}, funcr.Options{}))
+	t.Cleanup(func() { otel.SetLogger(stdr.New(log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile))) })
+
+	conn, err := grpc.Dial("passthrough:///collector:4317", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	tests := []struct {
+		name string
+		opts []oconf.GRPCOption
+		want string
+	}{
+		{name: "ConnOnly", opts: []oconf.GRPCOption{oconf.WithGRPCConn(conn)}},
+		{
+			name: "Endpoint",
+			opts: []oconf.GRPCOption{oconf.WithEndpoint("other:4317"), oconf.WithGRPCConn(conn)},
+			want: `"ignored"=["endpoint"]`,
+		},
+		{
+			name: "TLSClientConfig",
+			opts: []oconf.GRPCOption{oconf.WithGRPCConn(conn), oconf.WithTLSClientConfig(&tls.Config{})},
+			want: `"ignored"=["TLS"]`,
+		},
+		{
+			name: "EndpointAndTLS",
+			opts: []oconf.GRPCOption{
+				oconf.WithEndpoint("other:4317"),
+				oconf.WithTLSClientConfig(&tls.Config{}),
+				oconf.WithGRPCConn(conn),
+			},
+			want: `"ignored"=["endpoint","TLS"]`,
+		},
+		// Without a connection the options are used.
+		{name: "NoConn", opts: []oconf.GRPCOption{oconf.WithEndpoint("other:4317")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged = nil
+			cfg := oconf.NewGRPCConfig(tt.opts...)
+			assert.NoError(t, cfg.Err())
+			if tt.want == "" {
+				assert.Empty(t, logged)
+				return
+			}
+			require.Len(t, logged, 1)
+			assert.Contains(t, logged[0], "conflicting options")
+			assert.Contains(t, logged[0], tt.want)
+		})
+	}
+}
@@ -14,7 +14,11 @@
 
 package oconf // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 const (
 	// DefaultCollectorGRPCPort is the default gRPC port of the collector.
@@ -39,6 +43,84 @@ const (
 	GzipCompression
 )
 
+// String returns the name of c.
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case GzipCompression:
+		return "gzip"
+	default:
+		return fmt.Sprintf("Compression(%d)", int(c))
+	}
+}
+
+// MarshalText returns the name of c. An error is returned if c is not a known
+// Compression.
+func (c Compression) MarshalText() ([]byte, error) {
+	switch c {
+	case NoCompression, GzipCompression:
+		return []byte(c.String()), nil
+	default:
+		return nil, fmt.Errorf("unknown compression: %d", int(c))
+	}
+}
+
+// UnmarshalText sets c to the Compression named by text ("none" or "gzip",
+// case-insensitive). An error is returned and c is left unchanged if text
+// does not name a known Compression.
+func (c *Compression) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "none":
+		*c = NoCompression
+	case "gzip":
+		*c = GzipCompression
+	default:
+		return fmt.Errorf("unknown compression: %q", text)
+	}
+	return nil
+}
+
+// Encoding describes the encoding of payloads sent to the collector using
+// HTTP.
+type Encoding int
+
+const (
+	// ProtobufEncoding tells the driver to send payloads encoded as binary
+	// protobuf messages.
+	ProtobufEncoding Encoding = iota
+	// JSONEncoding tells the driver to send payloads encoded as OTLP/JSON.
+	JSONEncoding
+)
+
+// RotationPolicy describes how successive exports are distributed across
+// multiple configured endpoints.
+type RotationPolicy int
+
+const (
+	// NoRotation tells the driver to always start an export with the first
+	// endpoint, only using the others if it cannot be reached.
+	NoRotation RotationPolicy = iota
+	// RoundRobinRotation tells the driver to start each successive export
+	// with the next endpoint in order.
+	RoundRobinRotation
+	// RandomRotation tells the driver to start each export with a randomly
+	// chosen endpoint.
+	RandomRotation
+)
+
+// BackpressurePolicy describes what is done with an export when the limit of
+// in-flight export bytes has been reached.
+type BackpressurePolicy int
+
+const (
+	// BlockBackpressure tells the driver to wait for in-flight exports to
+	// complete.
+	BlockBackpressure BackpressurePolicy = iota
+	// DropBackpressure tells the driver to drop the export.
+	DropBackpressure
+)
+
 // RetrySettings defines configuration for retrying batches in case of export failure
 // using an exponential backoff.
 type RetrySettings struct {
@@ -0,0 +1,88 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oconf // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/internal/envconfig"
+	"go.opentelemetry.io/otel/internal/global"
+)
+
+// The OTLP transport protocols that can be selected with the
+// OTEL_EXPORTER_OTLP_PROTOCOL and OTEL_EXPORTER_OTLP_METRICS_PROTOCOL
+// environment variables.
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+	ProtocolHTTPJSON     = "http/json"
+
+	// DefaultProtocol is the protocol used if none is selected.
+	DefaultProtocol = ProtocolHTTPProtobuf
+)
+
+// ProtocolFromEnv returns the OTLP transport protocol selected with the
+// OTEL_EXPORTER_OTLP_METRICS_PROTOCOL or OTEL_EXPORTER_OTLP_PROTOCOL
+// environment variable, and true. If both are set,
+// OTEL_EXPORTER_OTLP_METRICS_PROTOCOL takes precedence. Invalid values are
+// logged and ignored. If no protocol is selected, DefaultProtocol and false
+// are returned.
+func ProtocolFromEnv() (string, bool) {
+	protocol, ok := DefaultProtocol, false
+	DefaultEnvOptionsReader.Apply(
+		withEnvProtocol("PROTOCOL", func(p string) { protocol, ok = p, true }),
+		withEnvProtocol("METRICS_PROTOCOL", func(p string) { protocol, ok = p, true }),
+	)
+	return protocol, ok
+}
+
+// NewConfigFromEnv returns a new Config for the OTLP transport protocol
+// returned from ProtocolFromEnv with all settings applied from opts. The
+// protocol is returned along with the Config so the matching client can be
+// created. A Config for the gRPC protocol is created with NewGRPCConfig, and
+// one for the HTTP protocols with NewHTTPConfig.
+func NewConfigFromEnv(opts ...GenericOption) (string, Config) {
+	protocol, _ := ProtocolFromEnv()
+	if protocol == ProtocolGRPC {
+		grpcOpts := make([]GRPCOption, len(opts))
+		for i, o := range opts {
+			grpcOpts[i] = o
+		}
+		return protocol, NewGRPCConfig(grpcOpts...)
+	}
+
+	httpOpts := make([]HTTPOption, len(opts))
+	for i, o := range opts {
+		httpOpts[i] = o
+	}
+	return protocol, NewHTTPConfig(httpOpts...)
+}
+
+// withEnvProtocol retrieves the specified config and passes it to fn if it
+// is a valid OTLP transport protocol. Invalid values are logged and ignored.
+func withEnvProtocol(n string, fn func(string)) func(e *envconfig.EnvOptionsReader) {
+	return func(e *envconfig.EnvOptionsReader) {
+		if v, ok := e.GetEnvValue(n); ok {
+			switch p := strings.ToLower(v); p {
+			case ProtocolGRPC, ProtocolHTTPProtobuf, ProtocolHTTPJSON:
+				fn(p)
+			default:
+				err := fmt.Errorf("invalid OTLP protocol: %q", v)
+				global.Error(err, "ignoring protocol", "variable", e.Namespace+"_"+n)
+			}
+		}
+	}
+}
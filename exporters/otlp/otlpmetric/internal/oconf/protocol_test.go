@@ -0,0 +1,98 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setProtocolEnv sets the generic and signal-specific protocol environment
+// variables read by DefaultEnvOptionsReader for the duration of the test.
+func setProtocolEnv(t *testing.T, generic, signal string) {
+	t.Helper()
+	origEOR := DefaultEnvOptionsReader
+	DefaultEnvOptionsReader.GetEnv = func(key string) string {
+		switch key {
+		case "OTEL_EXPORTER_OTLP_PROTOCOL":
+			return generic
+		case "OTEL_EXPORTER_OTLP_METRICS_PROTOCOL":
+			return signal
+		}
+		return ""
+	}
+	t.Cleanup(func() { DefaultEnvOptionsReader = origEOR })
+}
+
+func TestProtocolFromEnv(t *testing.T) {
+	tests := []struct {
+		name            string
+		generic, signal string
+		want            string
+		wantOK          bool
+	}{
+		{name: "unset", want: ProtocolHTTPProtobuf},
+		{name: "grpc", generic: "grpc", want: ProtocolGRPC, wantOK: true},
+		{name: "http/protobuf", generic: "http/protobuf", want: ProtocolHTTPProtobuf, wantOK: true},
+		{name: "http/json", generic: "http/json", want: ProtocolHTTPJSON, wantOK: true},
+		{name: "case-insensitive", generic: "GRPC", want: ProtocolGRPC, wantOK: true},
+		{name: "signal-specific", signal: "grpc", want: ProtocolGRPC, wantOK: true},
+		{name: "signal-specific precedence", generic: "grpc", signal: "http/json", want: ProtocolHTTPJSON, wantOK: true},
+		{name: "invalid", generic: "http", want: ProtocolHTTPProtobuf},
+		{name: "invalid signal-specific", generic: "grpc", signal: "udp", want: ProtocolGRPC, wantOK: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setProtocolEnv(t, test.generic, test.signal)
+			got, ok := ProtocolFromEnv()
+			assert.Equal(t, test.want, got)
+			assert.Equal(t, test.wantOK, ok)
+		})
+	}
+}
+
+func TestNewConfigFromEnv(t *testing.T) {
+	setProtocolEnv(t, "", "")
+	protocol, cfg := NewConfigFromEnv(WithEndpoint("collector"))
+	assert.Equal(t, ProtocolHTTPProtobuf, protocol)
+	assert.Equal(t, "collector:4318", cfg.Metrics.Endpoint)
+	assert.Nil(t, cfg.DialOptions)
+
+	setProtocolEnv(t, "grpc", "")
+	protocol, cfg = NewConfigFromEnv(WithEndpoint("collector"))
+	assert.Equal(t, ProtocolGRPC, protocol)
+	assert.Equal(t, "collector:4317", cfg.Metrics.Endpoint)
+	assert.NotEmpty(t, cfg.DialOptions)
+
+	setProtocolEnv(t, "", "http/json")
+	protocol, cfg = NewConfigFromEnv()
+	assert.Equal(t, ProtocolHTTPJSON, protocol)
+	assert.Equal(t, "localhost:4318", cfg.Metrics.Endpoint)
+}
+
+func TestProtocolEncodingEnv(t *testing.T) {
+	setProtocolEnv(t, "http/json", "")
+	assert.Equal(t, JSONEncoding, NewHTTPConfig().Metrics.Encoding)
+	// Options take precedence over the environment.
+	assert.Equal(t, ProtobufEncoding, NewHTTPConfig(WithHTTPEncoding(ProtobufEncoding)).Metrics.Encoding)
+
+	setProtocolEnv(t, "http/json", "http/protobuf")
+	assert.Equal(t, ProtobufEncoding, NewHTTPConfig().Metrics.Encoding)
+
+	setProtocolEnv(t, "", "")
+	assert.Equal(t, ProtobufEncoding, NewHTTPConfig().Metrics.Encoding)
+	assert.Equal(t, JSONEncoding, NewHTTPConfig(WithHTTPEncoding(JSONEncoding)).Metrics.Encoding)
+}
@@ -0,0 +1,139 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oconf // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"google.golang.org/grpc"
+)
+
+// proxyFunc returns the URL of the proxy to use for a request to reqURL. If
+// the request is not proxied, a nil URL is returned.
+type proxyFunc func(reqURL *url.URL) (*url.URL, error)
+
+// grpcProxyDialOption returns a DialOption that dials gRPC connections to
+// target according to the proxy environment variables (HTTPS_PROXY and
+// NO_PROXY). If no HTTPS proxy is configured, or target is a Unix domain
+// socket, false is returned and gRPC is left to dial the connections.
+func grpcProxyDialOption(target string) (grpc.DialOption, bool) {
+	env := httpproxy.FromEnvironment()
+	if env.HTTPSProxy == "" || strings.HasPrefix(target, "unix:") {
+		return nil, false
+	}
+	return grpc.WithContextDialer(grpcProxyDialer(env.ProxyFunc())), true
+}
+
+// grpcProxyDialer returns a dialer that connects to addr directly if proxy
+// does not return a proxy for it. Otherwise, the connection is tunneled
+// through the returned proxy with an HTTP CONNECT request.
+func grpcProxyDialer(proxy proxyFunc) func(context.Context, string) (net.Conn, error) {
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		proxyURL, err := proxy(&url.URL{Scheme: "https", Host: addr})
+		if err != nil {
+			return nil, err
+		}
+
+		var d net.Dialer
+		if proxyURL == nil {
+			return d.DialContext(ctx, "tcp", addr)
+		}
+
+		conn, err := d.DialContext(ctx, "tcp", proxyHostPort(proxyURL))
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial proxy %s: %w", proxyURL.Host, err)
+		}
+		tunnel, err := proxyConnect(ctx, conn, proxyURL, addr)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return tunnel, nil
+	}
+}
+
+// proxyHostPort returns the host and port of proxyURL. If proxyURL does not
+// include a port, the default port of its scheme is used.
+func proxyHostPort(proxyURL *url.URL) string {
+	if proxyURL.Port() != "" {
+		return proxyURL.Host
+	}
+	port := "80"
+	if proxyURL.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+// proxyConnect sends an HTTP CONNECT request for addr to the proxy conn is
+// connected to. The returned connection is tunneled to addr.
+func proxyConnect(ctx context.Context, conn net.Conn, proxyURL *url.URL, addr string) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+		defer func() { _ = conn.SetDeadline(time.Time{}) }()
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Host: addr},
+		Header: make(http.Header),
+	}
+	if u := proxyURL.User; u != nil {
+		pass, _ := u.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.Username() + ":" + pass))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("failed to write proxy CONNECT request: %w", err)
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy CONNECT response: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proxy CONNECT to %s failed: %s", addr, resp.Status)
+	}
+
+	if r.Buffered() > 0 {
+		// The proxy sent data from addr along with its response.
+		return &bufferedConn{Conn: conn, r: r}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a net.Conn that reads data already buffered by r before
+// reading from the connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
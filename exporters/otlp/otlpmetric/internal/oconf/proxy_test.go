@@ -0,0 +1,118 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oconf
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// setProxyEnv sets the proxy environment variables for the duration of the
+// test.
+func setProxyEnv(t *testing.T, httpsProxy, noProxy string) {
+	t.Helper()
+	for _, k := range []string{"HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"} {
+		t.Setenv(k, "")
+	}
+	t.Setenv("HTTPS_PROXY", httpsProxy)
+	t.Setenv("NO_PROXY", noProxy)
+}
+
+// fakeProxy accepts a single connection, answers its CONNECT request, and
+// sends the greeting through the established tunnel. The host requested is
+// sent on the returned channel.
+func fakeProxy(t *testing.T, greeting string) (net.Listener, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	hosts := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil || req.Method != http.MethodConnect {
+			return
+		}
+		hosts <- req.Host
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n"+greeting)
+	}()
+	return ln, hosts
+}
+
+func TestGRPCProxyDialOption(t *testing.T) {
+	setProxyEnv(t, "", "")
+	_, ok := grpcProxyDialOption("collector:4317")
+	assert.False(t, ok, "no proxy configured")
+
+	setProxyEnv(t, "http://proxy:3128", "")
+	_, ok = grpcProxyDialOption("collector:4317")
+	assert.True(t, ok, "proxy configured")
+	_, ok = grpcProxyDialOption("unix:///tmp/otlp.sock")
+	assert.False(t, ok, "unix socket target")
+}
+
+func TestGRPCProxyDialerNoProxy(t *testing.T) {
+	proxy, hosts := fakeProxy(t, "")
+	setProxyEnv(t, "http://"+proxy.Addr().String(), "localhost")
+
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = target.Close() })
+	accepted := make(chan struct{})
+	go func() {
+		if conn, err := target.Accept(); err == nil {
+			_ = conn.Close()
+			close(accepted)
+		}
+	}()
+
+	_, port, err := net.SplitHostPort(target.Addr().String())
+	require.NoError(t, err)
+	dial := grpcProxyDialer(httpproxy.FromEnvironment().ProxyFunc())
+	conn, err := dial(context.Background(), net.JoinHostPort("localhost", port))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	<-accepted
+	assert.Len(t, hosts, 0, "localhost target dialed through proxy")
+}
+
+func TestGRPCProxyDialerProxy(t *testing.T) {
+	proxy, hosts := fakeProxy(t, "hello")
+	setProxyEnv(t, "http://user:pass@"+proxy.Addr().String(), "localhost")
+
+	dial := grpcProxyDialer(httpproxy.FromEnvironment().ProxyFunc())
+	conn, err := dial(context.Background(), "collector.example.com:4317")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	assert.Equal(t, "collector.example.com:4317", <-hosts)
+	got, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(got))
+}
@@ -0,0 +1,85 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestTemporalityPresets(t *testing.T) {
+	const (
+		c = metricdata.CumulativeTemporality
+		d = metricdata.DeltaTemporality
+	)
+	kinds := []metric.InstrumentKind{
+		metric.InstrumentKindCounter,
+		metric.InstrumentKindObservableCounter,
+		metric.InstrumentKindHistogram,
+		metric.InstrumentKindUpDownCounter,
+		metric.InstrumentKindObservableUpDownCounter,
+		metric.InstrumentKindObservableGauge,
+	}
+	tests := []struct {
+		name     string
+		selector metric.TemporalitySelector
+		// want is the temporality selected for each of kinds.
+		want []metricdata.Temporality
+	}{
+		{"cumulative", CumulativeTemporality, []metricdata.Temporality{c, c, c, c, c, c}},
+		{"delta", DeltaTemporality, []metricdata.Temporality{d, d, d, c, c, c}},
+		{"lowmemory", LowMemoryTemporality, []metricdata.Temporality{d, c, d, c, c, c}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, k := range kinds {
+				assert.Equal(t, test.want[i], test.selector(k), "instrument kind %d", k)
+			}
+
+			// The preset is selected by the environment variable.
+			origEOR := DefaultEnvOptionsReader
+			DefaultEnvOptionsReader.GetEnv = func(key string) string {
+				if key == "OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE" {
+					return test.name
+				}
+				return ""
+			}
+			t.Cleanup(func() { DefaultEnvOptionsReader = origEOR })
+			for _, cfg := range []Config{NewHTTPConfig(), NewGRPCConfig()} {
+				for i, k := range kinds {
+					assert.Equal(t, test.want[i], cfg.Metrics.TemporalitySelector(k), "instrument kind %d", k)
+				}
+			}
+		})
+	}
+}
+
+func TestTemporalityPreferenceInvalid(t *testing.T) {
+	origEOR := DefaultEnvOptionsReader
+	DefaultEnvOptionsReader.GetEnv = func(key string) string {
+		if key == "OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE" {
+			return "sometimes"
+		}
+		return ""
+	}
+	t.Cleanup(func() { DefaultEnvOptionsReader = origEOR })
+
+	cfg := NewHTTPConfig()
+	assert.Equal(t, metricdata.CumulativeTemporality, cfg.Metrics.TemporalitySelector(metric.InstrumentKindCounter))
+}
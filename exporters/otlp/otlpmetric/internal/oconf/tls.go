@@ -18,7 +18,13 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"sync"
+
+	"go.opentelemetry.io/otel/internal/global"
 )
 
 // ReadTLSConfigFromFile reads a PEM certificate file and creates
@@ -32,6 +38,30 @@ func ReadTLSConfigFromFile(path string) (*tls.Config, error) {
 	return CreateTLSConfig(b)
 }
 
+// ReadTLSClientConfigFromFiles reads the PEM encoded client certificate and
+// key at certPath and keyPath, and the PEM encoded CA certificate at caPath,
+// and creates a tls.Config that will use them. The client certificate is not
+// loaded if both certPath and keyPath are empty, and the CA certificate is
+// not loaded if caPath is empty.
+func ReadTLSClientConfigFromFiles(certPath, keyPath, caPath string) (*tls.Config, error) {
+	tlsCfg := &tls.Config{}
+	if caPath != "" {
+		cfg, err := ReadTLSConfigFromFile(caPath)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.RootCAs = cfg.RootCAs
+	}
+	if certPath != "" || keyPath != "" {
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
 // CreateTLSConfig creates a tls.Config from a raw certificate bytes
 // to verify a server certificate.
 func CreateTLSConfig(certBytes []byte) (*tls.Config, error) {
@@ -44,3 +74,51 @@ func CreateTLSConfig(certBytes []byte) (*tls.Config, error) {
 		RootCAs: cp,
 	}, nil
 }
+
+// ReadCertPoolFromDir walks dir and returns a x509.CertPool containing all the
+// PEM encoded certificates found in its files. Files that do not contain a
+// PEM encoded certificate are skipped. An error is returned if no certificate
+// is found.
+func ReadCertPoolFromDir(dir string) (*x509.CertPool, error) {
+	cp := x509.NewCertPool()
+	var n int
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			global.Debug("skipping unreadable file in certificate directory", "path", path, "error", err.Error())
+			return nil
+		}
+		if !cp.AppendCertsFromPEM(b) {
+			global.Debug("skipping non-PEM file in certificate directory", "path", path)
+			return nil
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificates found in %s", dir)
+	}
+	return cp, nil
+}
+
+// rootCAsInfoOnce ensures the use of the host's root CAs by default is logged
+// at most once per process.
+var rootCAsInfoOnce sync.Once
+
+// infoRootCAsDefault logs that the gRPC connection uses TLS with the host's
+// root CAs because no transport credentials are set. It only logs the first
+// time it is called.
+func infoRootCAsDefault() {
+	rootCAsInfoOnce.Do(func() {
+		global.Info("no gRPC transport credentials set, using TLS with the host's root CAs")
+	})
+}
@@ -0,0 +1,56 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oconf
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/go-logr/stdr"
+	"github.com/stretchr/testify/assert"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestRootCAsInfoOnce(t *testing.T) {
+	var logged []string
+	otel.SetLogger(funcr.New(func(prefix, args string) {
+		logged = append(logged, args)
+	}, funcr.Options{Verbosity: 4}))
+	t.Cleanup(func() { otel.SetLogger(stdr.New(log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile))) })
+
+	count := func() int {
+		var n int
+		for _, l := range logged {
+			if strings.Contains(l, "using TLS with the host's root CAs") {
+				n++
+			}
+		}
+		return n
+	}
+
+	rootCAsInfoOnce = sync.Once{}
+	NewGRPCConfig(WithQuietDefaults())
+	assert.Equal(t, 0, count(), "logged with quiet defaults")
+
+	for i := 0; i < 3; i++ {
+		NewGRPCConfig()
+	}
+	assert.Equal(t, 1, count())
+}
@@ -0,0 +1,36 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/internal"
+	"go.opentelemetry.io/otel/internal/global"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+)
+
+// HandlePartialSuccess reports the partial success ps returned by an OTLP
+// receiver for an export. Rejected data points are reported as an error to
+// the global ErrorHandler. A message without any rejected data points is a
+// warning and is logged. Nothing is reported if ps is nil or empty.
+func HandlePartialSuccess(ps *colmetricpb.ExportMetricsPartialSuccess) {
+	n, msg := ps.GetRejectedDataPoints(), ps.GetErrorMessage()
+	switch {
+	case n != 0:
+		otel.Handle(internal.MetricPartialSuccessError(n, msg))
+	case msg != "":
+		global.Warn("OTLP partial success", "message", msg)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/internal"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+)
+
+func TestHandlePartialSuccess(t *testing.T) {
+	defer func(orig otel.ErrorHandler) {
+		otel.SetErrorHandler(orig)
+	}(otel.GetErrorHandler())
+
+	var errs []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(e error) { errs = append(errs, e) }))
+
+	HandlePartialSuccess(nil)
+	HandlePartialSuccess(&colmetricpb.ExportMetricsPartialSuccess{})
+	// Warnings are logged, not handled as errors.
+	HandlePartialSuccess(&colmetricpb.ExportMetricsPartialSuccess{ErrorMessage: "deprecated field"})
+	assert.Empty(t, errs)
+
+	HandlePartialSuccess(&colmetricpb.ExportMetricsPartialSuccess{
+		RejectedDataPoints: 2,
+		ErrorMessage:       "bad data",
+	})
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], internal.PartialSuccess{})
+	assert.Contains(t, errs[0].Error(), "2 metric data points rejected")
+	assert.Contains(t, errs[0].Error(), "bad data")
+}
@@ -0,0 +1,138 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// DefaultSelfMetricsPrefix is the default prefix of the names of the metrics
+// an exporter records about itself.
+const DefaultSelfMetricsPrefix = "otel.exporter."
+
+// SelfMetricsScopeName is the instrumentation scope name of the metrics an
+// exporter records about itself.
+const SelfMetricsScopeName = "go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
+
+// Reasons an export failed, recorded as the reasonKey attribute of the failed
+// exports self-metric.
+const (
+	reasonInFlightLimit = "in_flight_limit"
+	reasonTimeout       = "timeout"
+	reasonCanceled      = "canceled"
+	reasonUpload        = "upload"
+	reasonTransform     = "transform"
+)
+
+var reasonKey = attribute.Key("reason")
+
+// selfMetricsPrefixRe matches a prefix that, when followed by a valid
+// instrument name segment, forms a valid instrument name.
+var selfMetricsPrefixRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.\-]*$`)
+
+// ValidateSelfMetricsPrefix returns an error if prefix cannot be used as the
+// prefix of instrument names.
+func ValidateSelfMetricsPrefix(prefix string) error {
+	if !selfMetricsPrefixRe.MatchString(prefix) {
+		return fmt.Errorf("invalid self-metrics prefix %q: must start with a letter and only contain letters, digits, '_', '.', or '-'", prefix)
+	}
+	return nil
+}
+
+// selfMetrics are the instruments an exporter uses to record metrics about
+// itself.
+type selfMetrics struct {
+	attempts  metric.Int64Counter
+	successes metric.Int64Counter
+	failures  metric.Int64Counter
+	duration  metric.Float64Histogram
+}
+
+// newSelfMetrics returns selfMetrics with all instruments created by meter
+// and named with prefix. If prefix is empty DefaultSelfMetricsPrefix is used.
+func newSelfMetrics(meter metric.Meter, prefix string) (*selfMetrics, error) {
+	if prefix == "" {
+		prefix = DefaultSelfMetricsPrefix
+	}
+	if err := ValidateSelfMetricsPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	var (
+		m   selfMetrics
+		err error
+	)
+	m.attempts, err = meter.Int64Counter(
+		prefix+"export.attempts",
+		metric.WithDescription("Number of export attempts made by the exporter"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.successes, err = meter.Int64Counter(
+		prefix+"export.successes",
+		metric.WithDescription("Number of exports that succeeded"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.failures, err = meter.Int64Counter(
+		prefix+"export.failures",
+		metric.WithDescription("Number of exports that failed"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	m.duration, err = meter.Float64Histogram(
+		prefix+"export.duration",
+		metric.WithDescription("Duration of exports"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// recordExport records an export that started at start. If the export
+// failed, reason is the reason it failed. Otherwise, reason is empty.
+func (m *selfMetrics) recordExport(start time.Time, reason string) {
+	ctx := context.Background()
+	m.duration.Record(ctx, time.Since(start).Seconds())
+	if reason == "" {
+		m.successes.Add(ctx, 1)
+		return
+	}
+	m.failures.Add(ctx, 1, metric.WithAttributes(reasonKey.String(reason)))
+}
+
+// uploadFailureReason returns the reason an upload failed with err.
+func uploadFailureReason(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return reasonTimeout
+	case errors.Is(err, context.Canceled):
+		return reasonCanceled
+	default:
+		return reasonUpload
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	mpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+func TestValidateSelfMetricsPrefix(t *testing.T) {
+	for _, p := range []string{DefaultSelfMetricsPrefix, "app", "my_app.otlp-", "a1."} {
+		assert.NoError(t, ValidateSelfMetricsPrefix(p), p)
+	}
+	for _, p := range []string{"", ".otel", "1otel.", "otel exporter.", "otel/exporter."} {
+		assert.Error(t, ValidateSelfMetricsPrefix(p), p)
+	}
+}
+
+func selfMetricNames(t *testing.T, prefix string) []string {
+	t.Helper()
+
+	ctx := context.Background()
+	r := metric.NewManualReader()
+	mp := metric.NewMeterProvider(metric.WithReader(r))
+	m, err := newSelfMetrics(mp.Meter("test"), prefix)
+	require.NoError(t, err)
+
+	m.attempts.Add(ctx, 1)
+	m.successes.Add(ctx, 1)
+	m.failures.Add(ctx, 1)
+	m.duration.Record(ctx, 1)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, r.Collect(ctx, &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	var names []string
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		names = append(names, m.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSelfMetricsPrefix(t *testing.T) {
+	assert.Equal(t, []string{
+		"otel.exporter.export.attempts",
+		"otel.exporter.export.duration",
+		"otel.exporter.export.failures",
+		"otel.exporter.export.successes",
+	}, selfMetricNames(t, ""))
+
+	assert.Equal(t, []string{
+		"myapp.otlp.export.attempts",
+		"myapp.otlp.export.duration",
+		"myapp.otlp.export.failures",
+		"myapp.otlp.export.successes",
+	}, selfMetricNames(t, "myapp.otlp."))
+
+	_, err := newSelfMetrics(metric.NewMeterProvider().Meter("test"), "1nvalid")
+	assert.Error(t, err)
+}
+
+// errClient is a Client whose uploads fail with err.
+type errClient struct {
+	client
+
+	err error
+}
+
+func (c *errClient) UploadMetrics(context.Context, *mpb.ResourceMetrics) error {
+	return c.err
+}
+
+func TestWithSelfMetrics(t *testing.T) {
+	ctx := context.Background()
+	r := metric.NewManualReader()
+	mp := metric.NewMeterProvider(metric.WithReader(r))
+
+	c := &errClient{}
+	exp := New(c, WithSelfMetrics(mp, ""))
+	rm := &metricdata.ResourceMetrics{}
+	assert.NoError(t, exp.Export(ctx, rm))
+	c.err = assert.AnError
+	assert.Error(t, exp.Export(ctx, rm))
+	c.err = context.DeadlineExceeded
+	assert.Error(t, exp.Export(ctx, rm))
+
+	var got metricdata.ResourceMetrics
+	require.NoError(t, r.Collect(ctx, &got))
+	require.Len(t, got.ScopeMetrics, 1)
+	assert.Equal(t, SelfMetricsScopeName, got.ScopeMetrics[0].Scope.Name)
+
+	sums := make(map[string]metricdata.Sum[int64])
+	for _, m := range got.ScopeMetrics[0].Metrics {
+		switch data := m.Data.(type) {
+		case metricdata.Sum[int64]:
+			sums[m.Name] = data
+		case metricdata.Histogram[float64]:
+			require.Len(t, data.DataPoints, 1)
+			assert.Equal(t, uint64(3), data.DataPoints[0].Count)
+		}
+	}
+
+	require.Len(t, sums["otel.exporter.export.attempts"].DataPoints, 1)
+	assert.Equal(t, int64(3), sums["otel.exporter.export.attempts"].DataPoints[0].Value)
+	require.Len(t, sums["otel.exporter.export.successes"].DataPoints, 1)
+	assert.Equal(t, int64(1), sums["otel.exporter.export.successes"].DataPoints[0].Value)
+
+	reasons := make(map[string]int64)
+	for _, dp := range sums["otel.exporter.export.failures"].DataPoints {
+		v, _ := dp.Attributes.Value(reasonKey)
+		reasons[v.AsString()] = dp.Value
+	}
+	assert.Equal(t, map[string]int64{reasonUpload: 1, reasonTimeout: 1}, reasons)
+}
+
+func TestWithSelfMetricsNil(t *testing.T) {
+	exp := New(&client{}, WithSelfMetrics(nil, ""))
+	assert.Nil(t, exp.(*exporter).selfMetrics)
+	assert.NoError(t, exp.Export(context.Background(), &metricdata.ResourceMetrics{}))
+}
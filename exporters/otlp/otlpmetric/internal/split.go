@@ -0,0 +1,162 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"go.opentelemetry.io/otel/internal/global"
+	mpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// pointRef references a data point of a metric in a ResourceMetrics. A point
+// of -1 references a metric without data points.
+type pointRef struct {
+	scope, metric, point int
+}
+
+// splitResourceMetrics splits rm into ResourceMetrics that are each at most
+// limit bytes when serialized. The resource, and the grouping of metrics by
+// scope, of rm is kept in each. A data point that exceeds limit on its own is
+// dropped and an error is logged.
+func splitResourceMetrics(rm *mpb.ResourceMetrics, limit int) []*mpb.ResourceMetrics {
+	if proto.Size(rm) <= limit {
+		return []*mpb.ResourceMetrics{rm}
+	}
+
+	var refs []pointRef
+	for i, sm := range rm.ScopeMetrics {
+		for j, m := range sm.Metrics {
+			n := numPoints(m)
+			if n == 0 {
+				refs = append(refs, pointRef{scope: i, metric: j, point: -1})
+			}
+			for k := 0; k < n; k++ {
+				refs = append(refs, pointRef{scope: i, metric: j, point: k})
+			}
+		}
+	}
+	return splitRefs(rm, refs, limit, nil)
+}
+
+// splitRefs appends to dst the ResourceMetrics containing the data points of
+// rm referenced by refs, halving refs until each is at most limit bytes.
+func splitRefs(rm *mpb.ResourceMetrics, refs []pointRef, limit int, dst []*mpb.ResourceMetrics) []*mpb.ResourceMetrics {
+	if len(refs) == 0 {
+		return dst
+	}
+	sub := subsetResourceMetrics(rm, refs)
+	if size := proto.Size(sub); size > limit {
+		if len(refs) == 1 {
+			r := refs[0]
+			name := rm.ScopeMetrics[r.scope].Metrics[r.metric].GetName()
+			err := fmt.Errorf("data point of %d bytes exceeds the payload limit of %d bytes", size, limit)
+			global.Error(err, "dropping data point", "metric", name)
+			return dst
+		}
+		half := len(refs) / 2
+		dst = splitRefs(rm, refs[:half], limit, dst)
+		return splitRefs(rm, refs[half:], limit, dst)
+	}
+	return append(dst, sub)
+}
+
+// subsetResourceMetrics returns a ResourceMetrics with the resource of rm and
+// only the data points of rm referenced by refs. The refs must be ordered by
+// scope, metric, and point.
+func subsetResourceMetrics(rm *mpb.ResourceMetrics, refs []pointRef) *mpb.ResourceMetrics {
+	out := &mpb.ResourceMetrics{Resource: rm.Resource, SchemaUrl: rm.SchemaUrl}
+	for start := 0; start < len(refs); {
+		scope := refs[start].scope
+		sm := rm.ScopeMetrics[scope]
+		outSM := &mpb.ScopeMetrics{Scope: sm.Scope, SchemaUrl: sm.SchemaUrl}
+		for start < len(refs) && refs[start].scope == scope {
+			metric := refs[start].metric
+			var points []int
+			for ; start < len(refs) && refs[start].scope == scope && refs[start].metric == metric; start++ {
+				if p := refs[start].point; p >= 0 {
+					points = append(points, p)
+				}
+			}
+			outSM.Metrics = append(outSM.Metrics, metricWithPoints(sm.Metrics[metric], points))
+		}
+		out.ScopeMetrics = append(out.ScopeMetrics, outSM)
+	}
+	return out
+}
+
+// numPoints returns the number of data points of m.
+func numPoints(m *mpb.Metric) int {
+	switch d := m.Data.(type) {
+	case *mpb.Metric_Gauge:
+		return len(d.Gauge.GetDataPoints())
+	case *mpb.Metric_Sum:
+		return len(d.Sum.GetDataPoints())
+	case *mpb.Metric_Histogram:
+		return len(d.Histogram.GetDataPoints())
+	case *mpb.Metric_ExponentialHistogram:
+		return len(d.ExponentialHistogram.GetDataPoints())
+	case *mpb.Metric_Summary:
+		return len(d.Summary.GetDataPoints())
+	default:
+		return 0
+	}
+}
+
+// metricWithPoints returns a copy of m only containing the data points of m
+// at the indexes in points. The data points themselves are not copied.
+func metricWithPoints(m *mpb.Metric, points []int) *mpb.Metric {
+	out := &mpb.Metric{Name: m.Name, Description: m.Description, Unit: m.Unit}
+	switch d := m.Data.(type) {
+	case *mpb.Metric_Gauge:
+		out.Data = &mpb.Metric_Gauge{Gauge: &mpb.Gauge{
+			DataPoints: selectPoints(d.Gauge.DataPoints, points),
+		}}
+	case *mpb.Metric_Sum:
+		out.Data = &mpb.Metric_Sum{Sum: &mpb.Sum{
+			AggregationTemporality: d.Sum.AggregationTemporality,
+			IsMonotonic:            d.Sum.IsMonotonic,
+			DataPoints:             selectPoints(d.Sum.DataPoints, points),
+		}}
+	case *mpb.Metric_Histogram:
+		out.Data = &mpb.Metric_Histogram{Histogram: &mpb.Histogram{
+			AggregationTemporality: d.Histogram.AggregationTemporality,
+			DataPoints:             selectPoints(d.Histogram.DataPoints, points),
+		}}
+	case *mpb.Metric_ExponentialHistogram:
+		out.Data = &mpb.Metric_ExponentialHistogram{ExponentialHistogram: &mpb.ExponentialHistogram{
+			AggregationTemporality: d.ExponentialHistogram.AggregationTemporality,
+			DataPoints:             selectPoints(d.ExponentialHistogram.DataPoints, points),
+		}}
+	case *mpb.Metric_Summary:
+		out.Data = &mpb.Metric_Summary{Summary: &mpb.Summary{
+			DataPoints: selectPoints(d.Summary.DataPoints, points),
+		}}
+	default:
+		out.Data = m.Data
+	}
+	return out
+}
+
+// selectPoints returns the elements of dp at the indexes in points.
+func selectPoints[T any](dp []T, points []int) []T {
+	out := make([]T, len(points))
+	for i, p := range points {
+		out[i] = dp[p]
+	}
+	return out
+}
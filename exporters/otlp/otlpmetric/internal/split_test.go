@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/transform"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	mpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+func metricNames(rm *mpb.ResourceMetrics) []string {
+	var names []string
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			names = append(names, m.Name)
+		}
+	}
+	return names
+}
+
+func TestExporterMaxPayloadBytes(t *testing.T) {
+	in := testResourceMetrics(oldAttrs)
+	otlpRm, err := transform.ResourceMetrics(in)
+	require.NoError(t, err)
+	size := proto.Size(otlpRm)
+
+	c := &recordingClient{}
+	exp := New(c, WithMaxPayloadBytes(size-1))
+	require.NoError(t, exp.Export(context.Background(), in))
+
+	require.Len(t, c.uploaded, 2)
+	assert.Equal(t, []string{"gauge"}, metricNames(c.uploaded[0]))
+	assert.Equal(t, []string{"sum", "histogram"}, metricNames(c.uploaded[1]))
+	for _, got := range c.uploaded {
+		assert.LessOrEqual(t, proto.Size(got), size-1)
+		assert.True(t, proto.Equal(otlpRm.Resource, got.Resource), "resource not preserved")
+		require.Len(t, got.ScopeMetrics, 1)
+		assert.True(t, proto.Equal(otlpRm.ScopeMetrics[0].Scope, got.ScopeMetrics[0].Scope), "scope not preserved")
+	}
+	sum := c.uploaded[1].ScopeMetrics[0].Metrics[0].GetSum()
+	assert.True(t, sum.IsMonotonic)
+	assert.Equal(t, mpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE, sum.AggregationTemporality)
+}
+
+func TestExporterMaxPayloadBytesNotExceeded(t *testing.T) {
+	c := &recordingClient{}
+	exp := New(c, WithMaxPayloadBytes(1<<20))
+	require.NoError(t, exp.Export(context.Background(), testResourceMetrics(oldAttrs)))
+
+	require.Len(t, c.uploaded, 1)
+	assert.Equal(t, []string{"gauge", "sum", "histogram"}, metricNames(c.uploaded[0]))
+}
+
+func TestSplitResourceMetricsDropsOversizedPoint(t *testing.T) {
+	in := testResourceMetrics(oldAttrs)
+	gauge := in.ScopeMetrics[0].Metrics[0].Data.(metricdata.Gauge[int64])
+	big := attribute.NewSet(attribute.String("big", strings.Repeat("x", 1024)))
+	gauge.DataPoints = append(gauge.DataPoints, metricdata.DataPoint[int64]{Attributes: big, Value: 2})
+	in.ScopeMetrics[0].Metrics[0].Data = gauge
+
+	otlpRm, err := transform.ResourceMetrics(in)
+	require.NoError(t, err)
+
+	got := splitResourceMetrics(otlpRm, 512)
+	var points int
+	for _, rm := range got {
+		assert.LessOrEqual(t, proto.Size(rm), 512)
+		for _, m := range rm.ScopeMetrics[0].Metrics {
+			for _, dp := range m.GetGauge().GetDataPoints() {
+				assert.NotEqual(t, int64(2), dp.GetAsInt(), "oversized data point exported")
+			}
+			points += numPoints(m)
+		}
+	}
+	assert.Equal(t, 3, points)
+}
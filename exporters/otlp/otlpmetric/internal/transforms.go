@@ -0,0 +1,158 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// Transform returns a modified version of the metric data in rm that is
+// exported instead of rm.
+//
+// Implementations must not modify rm or any data it references, the data is
+// owned by the SDK. Instead, a copy needs to be returned.
+type Transform func(rm *metricdata.ResourceMetrics) *metricdata.ResourceMetrics
+
+// AttributeTransform returns a Transform that applies fn to all resource and
+// data point attributes. The attribute fn returns is used in place of the
+// passed one, unless fn returns false in which case the attribute is dropped.
+func AttributeTransform(fn func(attribute.KeyValue) (attribute.KeyValue, bool)) Transform {
+	return func(rm *metricdata.ResourceMetrics) *metricdata.ResourceMetrics {
+		out := &metricdata.ResourceMetrics{
+			Resource: resource.NewWithAttributes(
+				rm.Resource.SchemaURL(),
+				transformAttrs(rm.Resource.Attributes(), fn)...,
+			),
+			ScopeMetrics: make([]metricdata.ScopeMetrics, len(rm.ScopeMetrics)),
+		}
+		for i, sm := range rm.ScopeMetrics {
+			metrics := make([]metricdata.Metrics, len(sm.Metrics))
+			for j, m := range sm.Metrics {
+				m.Data = transformAggregation(m.Data, fn)
+				metrics[j] = m
+			}
+			out.ScopeMetrics[i] = metricdata.ScopeMetrics{
+				Scope:   sm.Scope,
+				Metrics: metrics,
+			}
+		}
+		return out
+	}
+}
+
+// MetricNameTransform returns a Transform that drops all metrics keep returns
+// false for the name of. Scopes without any metric left are dropped as well.
+func MetricNameTransform(keep func(name string) bool) Transform {
+	return func(rm *metricdata.ResourceMetrics) *metricdata.ResourceMetrics {
+		out := &metricdata.ResourceMetrics{
+			Resource:     rm.Resource,
+			ScopeMetrics: make([]metricdata.ScopeMetrics, 0, len(rm.ScopeMetrics)),
+		}
+		for _, sm := range rm.ScopeMetrics {
+			metrics := make([]metricdata.Metrics, 0, len(sm.Metrics))
+			for _, m := range sm.Metrics {
+				if keep(m.Name) {
+					metrics = append(metrics, m)
+				}
+			}
+			if len(metrics) == 0 {
+				continue
+			}
+			out.ScopeMetrics = append(out.ScopeMetrics, metricdata.ScopeMetrics{
+				Scope:   sm.Scope,
+				Metrics: metrics,
+			})
+		}
+		return out
+	}
+}
+
+// ResourceAttributeTransform returns a Transform that drops all resource
+// attributes keep returns false for. Data point attributes are not changed.
+func ResourceAttributeTransform(keep func(attribute.KeyValue) bool) Transform {
+	return func(rm *metricdata.ResourceMetrics) *metricdata.ResourceMetrics {
+		attrs := rm.Resource.Attributes()
+		kept := make([]attribute.KeyValue, 0, len(attrs))
+		for _, attr := range attrs {
+			if keep(attr) {
+				kept = append(kept, attr)
+			}
+		}
+		return &metricdata.ResourceMetrics{
+			Resource:     resource.NewWithAttributes(rm.Resource.SchemaURL(), kept...),
+			ScopeMetrics: rm.ScopeMetrics,
+		}
+	}
+}
+
+// transformAggregation returns a copy of a with fn applied to the attributes
+// of all its data points. Unknown aggregations are returned as is.
+func transformAggregation(a metricdata.Aggregation, fn func(attribute.KeyValue) (attribute.KeyValue, bool)) metricdata.Aggregation {
+	switch a := a.(type) {
+	case metricdata.Gauge[int64]:
+		a.DataPoints = transformDataPoints(a.DataPoints, fn)
+		return a
+	case metricdata.Gauge[float64]:
+		a.DataPoints = transformDataPoints(a.DataPoints, fn)
+		return a
+	case metricdata.Sum[int64]:
+		a.DataPoints = transformDataPoints(a.DataPoints, fn)
+		return a
+	case metricdata.Sum[float64]:
+		a.DataPoints = transformDataPoints(a.DataPoints, fn)
+		return a
+	case metricdata.Histogram[int64]:
+		a.DataPoints = transformHistogramDataPoints(a.DataPoints, fn)
+		return a
+	case metricdata.Histogram[float64]:
+		a.DataPoints = transformHistogramDataPoints(a.DataPoints, fn)
+		return a
+	}
+	return a
+}
+
+func transformDataPoints[N int64 | float64](dPts []metricdata.DataPoint[N], fn func(attribute.KeyValue) (attribute.KeyValue, bool)) []metricdata.DataPoint[N] {
+	out := make([]metricdata.DataPoint[N], len(dPts))
+	for i, dPt := range dPts {
+		dPt.Attributes = transformSet(dPt.Attributes, fn)
+		out[i] = dPt
+	}
+	return out
+}
+
+func transformHistogramDataPoints[N int64 | float64](dPts []metricdata.HistogramDataPoint[N], fn func(attribute.KeyValue) (attribute.KeyValue, bool)) []metricdata.HistogramDataPoint[N] {
+	out := make([]metricdata.HistogramDataPoint[N], len(dPts))
+	for i, dPt := range dPts {
+		dPt.Attributes = transformSet(dPt.Attributes, fn)
+		out[i] = dPt
+	}
+	return out
+}
+
+func transformSet(set attribute.Set, fn func(attribute.KeyValue) (attribute.KeyValue, bool)) attribute.Set {
+	return attribute.NewSet(transformAttrs(set.ToSlice(), fn)...)
+}
+
+func transformAttrs(attrs []attribute.KeyValue, fn func(attribute.KeyValue) (attribute.KeyValue, bool)) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		if kv, ok := fn(attr); ok {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	mpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+var (
+	httpMethod     = attribute.String("http.method", "GET")
+	httpReqMethod  = attribute.String("http.request.method", "GET")
+	deprecated     = attribute.Bool("deprecated", true)
+	serviceName    = attribute.String("service.name", "test")
+	oldAttrs       = attribute.NewSet(httpMethod, deprecated)
+	newAttrs       = attribute.NewSet(httpReqMethod)
+	testResource   = resource.NewWithAttributes("https://schema", serviceName, deprecated)
+	testScope      = instrumentation.Scope{Name: "test", Version: "v1"}
+	schemaUpgrader = func(kv attribute.KeyValue) (attribute.KeyValue, bool) {
+		switch kv.Key {
+		case httpMethod.Key:
+			return attribute.KeyValue{Key: httpReqMethod.Key, Value: kv.Value}, true
+		case deprecated.Key:
+			return kv, false
+		}
+		return kv, true
+	}
+)
+
+func testResourceMetrics(set attribute.Set) *metricdata.ResourceMetrics {
+	return &metricdata.ResourceMetrics{
+		Resource: testResource,
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Scope: testScope,
+			Metrics: []metricdata.Metrics{
+				{
+					Name: "gauge",
+					Data: metricdata.Gauge[int64]{
+						DataPoints: []metricdata.DataPoint[int64]{{Attributes: set, Value: 1}},
+					},
+				},
+				{
+					Name: "sum",
+					Data: metricdata.Sum[float64]{
+						DataPoints:  []metricdata.DataPoint[float64]{{Attributes: set, Value: 1}},
+						Temporality: metricdata.CumulativeTemporality,
+						IsMonotonic: true,
+					},
+				},
+				{
+					Name: "histogram",
+					Data: metricdata.Histogram[int64]{
+						DataPoints: []metricdata.HistogramDataPoint[int64]{{
+							Attributes:   set,
+							Count:        1,
+							Bounds:       []float64{1},
+							BucketCounts: []uint64{1, 0},
+							Sum:          1,
+						}},
+						Temporality: metricdata.DeltaTemporality,
+					},
+				},
+			},
+		}},
+	}
+}
+
+func TestAttributeTransform(t *testing.T) {
+	in := testResourceMetrics(oldAttrs)
+	got := AttributeTransform(schemaUpgrader)(in)
+
+	want := testResourceMetrics(newAttrs)
+	want.Resource = resource.NewWithAttributes("https://schema", serviceName)
+	assert.Equal(t, want, got)
+
+	assert.Equal(t, testResourceMetrics(oldAttrs), in, "input modified")
+}
+
+func TestAttributeTransformEmpty(t *testing.T) {
+	got := AttributeTransform(schemaUpgrader)(&metricdata.ResourceMetrics{})
+	assert.Equal(t, 0, got.Resource.Len())
+	assert.Len(t, got.ScopeMetrics, 0)
+}
+
+func TestResourceAttributeTransform(t *testing.T) {
+	in := testResourceMetrics(oldAttrs)
+	keep := func(kv attribute.KeyValue) bool { return kv.Key != deprecated.Key }
+	got := ResourceAttributeTransform(keep)(in)
+
+	want := testResourceMetrics(oldAttrs)
+	want.Resource = resource.NewWithAttributes("https://schema", serviceName)
+	assert.Equal(t, want, got)
+
+	assert.Equal(t, testResourceMetrics(oldAttrs), in, "input modified")
+}
+
+func TestMetricNameTransform(t *testing.T) {
+	in := testResourceMetrics(oldAttrs)
+	in.ScopeMetrics = append(in.ScopeMetrics, metricdata.ScopeMetrics{
+		Scope:   instrumentation.Scope{Name: "noisy"},
+		Metrics: []metricdata.Metrics{{Name: "noisy.gauge", Data: metricdata.Gauge[int64]{}}},
+	})
+	keep := func(name string) bool { return name != "sum" && !strings.HasPrefix(name, "noisy.") }
+	got := MetricNameTransform(keep)(in)
+
+	want := testResourceMetrics(oldAttrs)
+	want.ScopeMetrics[0].Metrics = []metricdata.Metrics{
+		want.ScopeMetrics[0].Metrics[0],
+		want.ScopeMetrics[0].Metrics[2],
+	}
+	assert.Equal(t, want, got)
+
+	require.Len(t, in.ScopeMetrics, 2, "input modified")
+	assert.Len(t, in.ScopeMetrics[0].Metrics, 3, "input modified")
+}
+
+type recordingClient struct {
+	client
+
+	uploaded []*mpb.ResourceMetrics
+}
+
+func (c *recordingClient) UploadMetrics(_ context.Context, rm *mpb.ResourceMetrics) error {
+	c.uploaded = append(c.uploaded, rm)
+	return nil
+}
+
+func TestExporterTransforms(t *testing.T) {
+	c := &recordingClient{}
+	exp := New(c, WithTransforms(AttributeTransform(schemaUpgrader)))
+	require.NoError(t, exp.Export(context.Background(), testResourceMetrics(oldAttrs)))
+
+	require.Len(t, c.uploaded, 1)
+	got := c.uploaded[0]
+	require.Len(t, got.Resource.Attributes, 1)
+	assert.Equal(t, string(serviceName.Key), got.Resource.Attributes[0].Key)
+
+	dPtAttrs := got.ScopeMetrics[0].Metrics[0].GetGauge().DataPoints[0].Attributes
+	require.Len(t, dPtAttrs, 1)
+	assert.Equal(t, string(httpReqMethod.Key), dPtAttrs[0].Key)
+}
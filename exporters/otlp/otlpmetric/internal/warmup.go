@@ -0,0 +1,77 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+const (
+	// WarmupScopeName is the instrumentation scope name of warmup metrics.
+	WarmupScopeName = "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/warmup"
+	// WarmupMetricName is the name of the warmup metric.
+	WarmupMetricName = "otel.exporter.otlp.warmup"
+)
+
+// WarmupResourceMetrics returns synthetic metric data that can be exported to
+// validate an export pipeline end-to-end. The data contains a single gauge
+// with a name clearly identifying it as a warmup metric so it is not mistaken
+// for real telemetry.
+func WarmupResourceMetrics() *metricdata.ResourceMetrics {
+	now := time.Now()
+	return &metricdata.ResourceMetrics{
+		Resource: resource.Empty(),
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Scope: instrumentation.Scope{
+				Name:    WarmupScopeName,
+				Version: otlpmetric.Version(),
+			},
+			Metrics: []metricdata.Metrics{{
+				Name:        WarmupMetricName,
+				Description: "Synthetic metric used to validate the export pipeline",
+				Unit:        "1",
+				Data: metricdata.Gauge[int64]{
+					DataPoints: []metricdata.DataPoint[int64]{{
+						StartTime: now,
+						Time:      now,
+						Value:     1,
+					}},
+				},
+			}},
+		}},
+	}
+}
+
+// Ping makes an empty export with exp to check its endpoint can be reached.
+// If timeout is positive, the export is abandoned after that time.
+func Ping(ctx context.Context, exp metric.Exporter, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := exp.Export(ctx, &metricdata.ResourceMetrics{}); err != nil {
+		return fmt.Errorf("startup ping failed: %w", err)
+	}
+	return nil
+}
@@ -16,19 +16,22 @@ package otlpmetricgrpc // import "go.opentelemetry.io/otel/exporters/otlp/otlpme
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/internal"
 	"go.opentelemetry.io/otel/exporters/otlp/internal/retry"
 	ominternal "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
+	"go.opentelemetry.io/otel/internal/global"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/aggregation"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
@@ -45,17 +48,65 @@ import (
 // on options. If a connection cannot be establishes in the lifetime of ctx,
 // an error will be returned.
 func New(ctx context.Context, options ...Option) (metric.Exporter, error) {
-	c, err := newClient(ctx, options...)
+	cfg := oconf.NewGRPCConfig(asGRPCOptions(options)...)
+	if err := cfg.Err(); err != nil {
+		return nil, err
+	}
+	c, err := newClient(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
-	return ominternal.New(c), nil
+	exp := ominternal.New(c, cfg.Metrics.ExporterOptions()...)
+	if cfg.StartupPing {
+		if err := ominternal.Ping(ctx, exp, cfg.StartTimeout); err != nil {
+			_ = exp.Shutdown(ctx)
+			return nil, err
+		}
+	}
+	return exp, nil
+}
+
+// NewNoop returns an OpenTelemetry metric Exporter that discards all metric
+// data. It does not connect to any endpoint.
+//
+// The options are validated the same way as by New, and the Exporter uses
+// the same temporality and aggregation selectors and transforms the metric
+// data the same way as one returned by New would. This allows testing a
+// metric pipeline without an OTLP receiving endpoint.
+func NewNoop(opts ...Option) (metric.Exporter, error) {
+	cfg := oconf.NewGRPCConfig(asGRPCOptions(opts)...)
+	if err := cfg.Err(); err != nil {
+		return nil, err
+	}
+	c := ominternal.NewNoopClient(cfg.Metrics.TemporalitySelector, cfg.Metrics.AggregationSelector)
+	return ominternal.New(c, cfg.Metrics.ExporterOptions()...), nil
+}
+
+// WarmupExport exports a single synthetic metric with exp to validate the
+// export pipeline end-to-end (serialization, compression, transport, and
+// retry) before real metric data is exported. Any error encountered during
+// the export is returned.
+//
+// The synthetic metric is a gauge named "otel.exporter.otlp.warmup" in the
+// "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/warmup"
+// instrumentation scope. It is not part of any real metric stream, but
+// receiving backends will store it unless configured to drop it.
+func WarmupExport(ctx context.Context, exp metric.Exporter) error {
+	return exp.Export(ctx, ominternal.WarmupResourceMetrics())
 }
 
 type client struct {
-	metadata      metadata.MD
-	exportTimeout time.Duration
-	requestFunc   retry.RequestFunc
+	metadata         metadata.MD
+	headersFunc      func() map[string]string
+	attemptTimeout   time.Duration
+	minAttemptWindow time.Duration
+	requestFunc      retry.RequestFunc
+	// evaluate reports if the error of a failed export is retry-able.
+	evaluate retry.EvaluateFunc
+
+	// contextHeadersFunc, if set, returns headers for the context of an
+	// export that override the other headers.
+	contextHeadersFunc func(context.Context) map[string]string
 
 	temporalitySelector metric.TemporalitySelector
 	aggregationSelector metric.AggregationSelector
@@ -67,29 +118,54 @@ type client struct {
 	ourConn bool
 	conn    *grpc.ClientConn
 	msc     colmetricpb.MetricsServiceClient
+
+	// jsonFallback is true if an export rejected as unimplemented by the
+	// server is retried using the JSON encoding.
+	jsonFallback bool
+
+	// maxSendMsgSize, if positive, is the maximum size of a request sent
+	// with the conn created by the client.
+	maxSendMsgSize int
 }
 
-// newClient creates a new gRPC metric client.
-func newClient(ctx context.Context, options ...Option) (ominternal.Client, error) {
-	cfg := oconf.NewGRPCConfig(asGRPCOptions(options)...)
+// checkConn returns an error if conn, a ClientConn provided by the user, can
+// no longer be used.
+func checkConn(conn *grpc.ClientConn) error {
+	if conn != nil && conn.GetState() == connectivity.Shutdown {
+		return errors.New("provided gRPC connection is closed")
+	}
+	return nil
+}
 
+// newClient creates a new gRPC metric client.
+func newClient(ctx context.Context, cfg oconf.Config) (ominternal.Client, error) {
+	evaluate := evaluateFunc(cfg.RetryableGRPCCodeFunc)
 	c := &client{
-		exportTimeout: cfg.Metrics.Timeout,
-		requestFunc:   cfg.RetryConfig.RequestFunc(retryable),
-		conn:          cfg.GRPCConn,
+		attemptTimeout:   cfg.Metrics.Timeout,
+		minAttemptWindow: cfg.Metrics.MinAttemptWindow,
+		requestFunc:      cfg.RetryConfig.RequestFuncWithBackoff(cfg.RetryBackoff, evaluate),
+		evaluate:         evaluate,
+		conn:             cfg.GRPCConn,
+		jsonFallback:     cfg.GRPCJSONFallback,
+		headersFunc:      cfg.Metrics.HeadersFunc,
+
+		contextHeadersFunc: cfg.Metrics.ContextHeadersFunc,
 
 		temporalitySelector: cfg.Metrics.TemporalitySelector,
 		aggregationSelector: cfg.Metrics.AggregationSelector,
 	}
 
 	if len(cfg.Metrics.Headers) > 0 {
-		c.metadata = metadata.New(cfg.Metrics.Headers)
+		c.metadata = newMetadata(cfg.Metrics.Headers)
 	}
 
+	if err := checkConn(c.conn); err != nil {
+		global.Error(err, "exports will fail")
+	}
 	if c.conn == nil {
 		// If the caller did not provide a ClientConn when the client was
 		// created, create one using the configuration they did provide.
-		conn, err := grpc.DialContext(ctx, cfg.Metrics.Endpoint, cfg.DialOptions...)
+		conn, err := dial(ctx, cfg)
 		if err != nil {
 			return nil, err
 		}
@@ -97,6 +173,7 @@ func newClient(ctx context.Context, options ...Option) (ominternal.Client, error
 		// it on Shutdown.
 		c.ourConn = true
 		c.conn = conn
+		c.maxSendMsgSize = cfg.MaxSendMsgSize
 	}
 
 	c.msc = colmetricpb.NewMetricsServiceClient(c.conn)
@@ -104,6 +181,24 @@ func newClient(ctx context.Context, options ...Option) (ominternal.Client, error
 	return c, nil
 }
 
+// dial creates a ClientConn to the target of cfg. If cfg has a start timeout,
+// it waits for at most that long for the connection to be ready.
+func dial(ctx context.Context, cfg oconf.Config) (*grpc.ClientConn, error) {
+	target := cfg.GRPCTarget()
+	if cfg.StartTimeout <= 0 {
+		return grpc.DialContext(ctx, target, cfg.DialOptions...)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.StartTimeout)
+	defer cancel()
+	opts := append(cfg.DialOptions[:len(cfg.DialOptions):len(cfg.DialOptions)], grpc.WithBlock(), grpc.WithReturnConnectionError())
+	conn, err := grpc.DialContext(ctx, target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s within %s: %w", target, cfg.StartTimeout, err)
+	}
+	return conn, nil
+}
+
 // Temporality returns the Temporality to use for an instrument kind.
 func (c *client) Temporality(k metric.InstrumentKind) metricdata.Temporality {
 	return c.temporalitySelector(k)
@@ -129,6 +224,8 @@ func (c *client) Shutdown(ctx context.Context) error {
 	// here is to release any computational resources the client holds.
 
 	c.metadata = nil
+	c.headersFunc = nil
+	c.contextHeadersFunc = nil
 	c.requestFunc = nil
 	c.msc = nil
 
@@ -147,8 +244,22 @@ func (c *client) Shutdown(ctx context.Context) error {
 // UploadMetrics sends protoMetrics to connected endpoint.
 //
 // Retryable errors from the server will be handled according to any
-// RetryConfig the client was created with.
+// RetryConfig the client was created with. If the upload fails, the returned
+// error is an *ExportError.
 func (c *client) UploadMetrics(ctx context.Context, protoMetrics *metricpb.ResourceMetrics) error {
+	err := c.uploadMetrics(ctx, protoMetrics)
+	if err == nil {
+		return nil
+	}
+	exportErr := &ExportError{Signal: "metrics", Err: err}
+	if s, ok := status.FromError(err); ok {
+		exportErr.StatusCode = int(s.Code())
+		exportErr.Retryable, _ = c.evaluate(err)
+	}
+	return exportErr
+}
+
+func (c *client) uploadMetrics(ctx context.Context, protoMetrics *metricpb.ResourceMetrics) error {
 	// The otlpmetric.Exporter synchronizes access to client methods, and
 	// ensures this is not called after the Exporter is shutdown. Only thing
 	// to do here is send data.
@@ -160,55 +271,186 @@ func (c *client) UploadMetrics(ctx context.Context, protoMetrics *metricpb.Resou
 	default:
 	}
 
+	if err := c.checkAttemptWindow(ctx); err != nil {
+		return err
+	}
+
 	ctx, cancel := c.exportContext(ctx)
 	defer cancel()
 
+	req := &colmetricpb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricpb.ResourceMetrics{protoMetrics},
+	}
+	// marshalChecked is true once the request is known to marshal.
+	var marshalChecked bool
 	return c.requestFunc(ctx, func(iCtx context.Context) error {
-		resp, err := c.msc.Export(iCtx, &colmetricpb.ExportMetricsServiceRequest{
-			ResourceMetrics: []*metricpb.ResourceMetrics{protoMetrics},
-		})
-		if resp != nil && resp.PartialSuccess != nil {
-			msg := resp.PartialSuccess.GetErrorMessage()
-			n := resp.PartialSuccess.GetRejectedDataPoints()
-			if n != 0 || msg != "" {
-				err := internal.MetricPartialSuccessError(n, msg)
-				otel.Handle(err)
-			}
+		aCtx, cancel := c.attemptContext(iCtx)
+		defer cancel()
+
+		resp, err := c.msc.Export(aCtx, req)
+		if c.jsonFallback && status.Code(err) == codes.Unimplemented {
+			// The server may not support the protobuf encoding, try once
+			// more using JSON.
+			resp, err = c.msc.Export(aCtx, req, grpc.ForceCodec(jsonCodec{}))
 		}
+		ominternal.HandlePartialSuccess(resp.GetPartialSuccess())
 		// nil is converted to OK.
 		if status.Code(err) == codes.OK {
 			// Success.
 			return nil
 		}
+		if status.Code(err) == codes.Internal && !marshalChecked {
+			// The request may have failed to marshal. That fails every
+			// attempt, do not retry. Only check if the error would otherwise
+			// be retried, and only once.
+			if ok, _ := c.evaluate(err); ok {
+				if _, mErr := proto.Marshal(req); mErr != nil {
+					return marshalError{err: mErr}
+				}
+				marshalChecked = true
+			}
+		}
+		if status.Code(err) == codes.ResourceExhausted && c.maxSendMsgSize > 0 && proto.Size(req) > c.maxSendMsgSize {
+			// The request is larger than the client is allowed to send.
+			// That fails every attempt, do not retry.
+			return sendSizeError{err: err}
+		}
+		if ctxErr := iCtx.Err(); ctxErr != nil {
+			// The caller is done with the export, do not retry.
+			return fmt.Errorf("%w: %s", ctxErr, err)
+		}
+		if errors.Is(aCtx.Err(), context.DeadlineExceeded) {
+			// Only this attempt timed out, try again.
+			return attemptTimeoutError{err: err}
+		}
 		return err
 	})
 }
 
-// exportContext returns a copy of parent with an appropriate deadline and
-// cancellation function based on the clients configured export timeout.
+// ExportError is the error returned when the Exporter fails to upload
+// metric data to the endpoint. Use errors.As to retrieve it from the error
+// returned by Export.
+//
+// Whether the export failed because the endpoint could not be reached, the
+// endpoint rejected the data, or the context of the export was canceled can
+// be determined from its fields and, for the latter, with errors.Is.
+type ExportError struct {
+	// Signal is the telemetry signal of the failed export, "metrics".
+	Signal string
+	// StatusCode is the gRPC status code the export failed with. It is zero,
+	// the OK code, if the export failed without a status, like when its
+	// context is done.
+	StatusCode int
+	// Retryable is true if the export failed with a status that is retried
+	// according to the RetryConfig of the Exporter, or with an attempt
+	// timeout. If retries are enabled the export was retried until the
+	// retries were exhausted.
+	Retryable bool
+	// Err is the cause of the failure.
+	Err error
+}
+
+// Error returns the message of the cause of e.
+func (e *ExportError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the cause of e.
+func (e *ExportError) Unwrap() error { return e.Err }
+
+// marshalError is returned when the export request cannot be marshaled. The
+// export is never retried.
+type marshalError struct {
+	err error
+}
+
+func (e marshalError) Error() string { return "failed to marshal metrics: " + e.err.Error() }
+
+func (e marshalError) Unwrap() error { return e.err }
+
+// sendSizeError is returned when the export request is larger than the
+// maximum size the client sends. The export is never retried.
+type sendSizeError struct {
+	err error
+}
+
+func (e sendSizeError) Error() string { return e.err.Error() }
+
+func (e sendSizeError) Unwrap() error { return e.err }
+
+// attemptTimeoutError is returned when an export attempt timed out while the
+// export itself has not. The export is retried regardless of the status code
+// of err.
+type attemptTimeoutError struct {
+	err error
+}
+
+func (e attemptTimeoutError) Error() string { return e.err.Error() }
+
+func (e attemptTimeoutError) Unwrap() error { return e.err }
+
+// errInsufficientTime is returned when an export is not attempted because
+// not enough time remains before the deadline of the export context.
+var errInsufficientTime = errors.New("insufficient time remaining to attempt export")
+
+// checkAttemptWindow returns an error if less than the configured minimum
+// attempt window remains before the deadline of ctx.
+func (c *client) checkAttemptWindow(ctx context.Context) error {
+	if c.minAttemptWindow <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < c.minAttemptWindow {
+			return fmt.Errorf("%w: %s remaining, %s required", errInsufficientTime, remaining, c.minAttemptWindow)
+		}
+	}
+	return nil
+}
+
+// exportContext returns a copy of parent with the headers of the client
+// added as outgoing metadata and a cancellation function.
 //
 // It is the callers responsibility to cancel the returned context once its
 // use is complete, via the parent or directly with the returned CancelFunc, to
 // ensure all resources are correctly released.
 func (c *client) exportContext(parent context.Context) (context.Context, context.CancelFunc) {
-	var (
-		ctx    context.Context
-		cancel context.CancelFunc
-	)
+	ctx, cancel := context.WithCancel(parent)
 
-	if c.exportTimeout > 0 {
-		ctx, cancel = context.WithTimeout(parent, c.exportTimeout)
-	} else {
-		ctx, cancel = context.WithCancel(parent)
+	md := c.metadata
+	if c.headersFunc != nil {
+		md = newMetadata(c.headersFunc())
 	}
-
-	if c.metadata.Len() > 0 {
-		ctx = metadata.NewOutgoingContext(ctx, c.metadata)
+	if c.contextHeadersFunc != nil {
+		// Copy to not modify the metadata shared by all exports.
+		md = md.Copy()
+		for k, v := range c.contextHeadersFunc(parent) {
+			md.Set(k, v)
+		}
+	}
+	if md.Len() > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, md)
 	}
 
 	return ctx, cancel
 }
 
+// newMetadata returns metadata containing headers. The keys are lowercased,
+// so keys only differing in case are sent once.
+func newMetadata(headers map[string]string) metadata.MD {
+	md := make(metadata.MD, len(headers))
+	for k, v := range headers {
+		md.Set(k, v)
+	}
+	return md
+}
+
+// attemptContext returns a copy of parent with a deadline based on the
+// clients configured timeout for each export attempt.
+func (c *client) attemptContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if c.attemptTimeout > 0 {
+		return context.WithTimeout(parent, c.attemptTimeout)
+	}
+	return context.WithCancel(parent)
+}
+
 // retryable returns if err identifies a request that can be retried and a
 // duration to wait for if an explicit throttle time is included in err.
 func retryable(err error) (bool, time.Duration) {
@@ -228,6 +470,32 @@ func retryable(err error) (bool, time.Duration) {
 	return false, 0
 }
 
+// evaluateFunc returns the function used to evaluate if a failed request is
+// retried. If fn is not nil, it determines which status codes are retry-able
+// instead of retryable. An attempt that timed out is always retried, and one
+// that failed to marshal is never retried.
+func evaluateFunc(fn func(codes.Code) bool) retry.EvaluateFunc {
+	eval := retryable
+	if fn != nil {
+		eval = func(err error) (bool, time.Duration) {
+			s := status.Convert(err)
+			if !fn(s.Code()) {
+				return false, 0
+			}
+			return true, throttleDelay(s)
+		}
+	}
+	return func(err error) (bool, time.Duration) {
+		if errors.As(err, &marshalError{}) || errors.As(err, &sendSizeError{}) {
+			return false, 0
+		}
+		if errors.As(err, &attemptTimeoutError{}) {
+			return true, 0
+		}
+		return eval(err)
+	}
+}
+
 // throttleDelay returns a duration to wait for if an explicit throttle time
 // is included in the response status.
 func throttleDelay(s *status.Status) time.Duration {
@@ -16,6 +16,16 @@ package otlpmetricgrpc
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -24,13 +34,24 @@ import (
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/durationpb"
 
+	"go.opentelemetry.io/otel/attribute"
 	ominternal "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/otest"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 )
 
 func TestThrottleDuration(t *testing.T) {
@@ -129,6 +150,65 @@ func TestRetryable(t *testing.T) {
 	}
 }
 
+func TestEvaluateFunc(t *testing.T) {
+	assert.NotNil(t, evaluateFunc(nil))
+
+	// Make a normally terminal code retry-able, and vice versa.
+	eval := evaluateFunc(func(code codes.Code) bool {
+		return code == codes.Internal
+	})
+	got, _ := eval(status.Error(codes.Internal, ""))
+	assert.True(t, got, "custom retry-able code")
+	got, _ = eval(status.Error(codes.Unavailable, ""))
+	assert.False(t, got, "custom terminal code")
+
+	st, err := status.New(codes.Internal, "").WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(15 * time.Millisecond)},
+	)
+	require.NoError(t, err)
+	got, throttle := eval(st.Err())
+	assert.True(t, got)
+	assert.Equal(t, 15*time.Millisecond, throttle)
+}
+
+func TestConfigRetryableGRPCCodeFunc(t *testing.T) {
+	rCh := make(chan otest.ExportResult, 2)
+	rCh <- otest.ExportResult{Err: status.Error(codes.Internal, "internal")}
+	rCh <- otest.ExportResult{}
+	coll, err := otest.NewGRPCCollector("", rCh)
+	require.NoError(t, err)
+	t.Cleanup(coll.Shutdown)
+
+	ctx := context.Background()
+	exp, err := New(
+		ctx,
+		WithEndpoint(coll.Addr().String()),
+		WithInsecure(),
+		WithRetry(RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Nanosecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Minute,
+		}),
+		WithRetryableGRPCCodeFunc(func(code codes.Code) bool {
+			return code == codes.Internal
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	assert.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	assert.Len(t, rCh, 0, "export not retried")
+}
+
+func TestNewMetadata(t *testing.T) {
+	md := newMetadata(map[string]string{"Authorization": "a", "x-tenant": "b"})
+	assert.Equal(t, metadata.MD{"authorization": {"a"}, "x-tenant": {"b"}}, md)
+
+	md = newMetadata(map[string]string{"X-Tenant": "a", "x-tenant": "a"})
+	assert.Equal(t, metadata.MD{"x-tenant": {"a"}}, md, "duplicate keys sent")
+}
+
 func TestClient(t *testing.T) {
 	factory := func(rCh <-chan otest.ExportResult) (ominternal.Client, otest.Collector) {
 		coll, err := otest.NewGRPCCollector("", rCh)
@@ -136,7 +216,10 @@ func TestClient(t *testing.T) {
 
 		ctx := context.Background()
 		addr := coll.Addr().String()
-		client, err := newClient(ctx, WithEndpoint(addr), WithInsecure())
+		client, err := newClient(ctx, oconf.NewGRPCConfig(asGRPCOptions([]Option{
+			WithEndpoint(addr),
+			WithInsecure(),
+		})...))
 		require.NoError(t, err)
 		return client, coll
 	}
@@ -175,6 +258,123 @@ func TestConfig(t *testing.T) {
 		assert.Equal(t, got[key], []string{headers[key]})
 	})
 
+	t.Run("WithMixedCaseHeaders", func(t *testing.T) {
+		exp, coll := factoryFunc(
+			nil,
+			WithHeaders(map[string]string{"x-scope-orgid": "team-a"}),
+			WithHeader("X-Scope-OrgID", "team-b"),
+		)
+		t.Cleanup(coll.Shutdown)
+		ctx := context.Background()
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Headers()
+		assert.Equal(t, []string{"team-b"}, got["x-scope-orgid"])
+	})
+
+	t.Run("WithHeadersFunc", func(t *testing.T) {
+		key := "authorization"
+		var n atomic.Int64
+		fn := func() map[string]string {
+			return map[string]string{key: fmt.Sprintf("token-%d", n.Add(1))}
+		}
+		exp, coll := factoryFunc(nil, WithHeaders(map[string]string{"static": "v"}), WithHeadersFunc(fn))
+		t.Cleanup(coll.Shutdown)
+		ctx := context.Background()
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Headers()
+		assert.Equal(t, []string{"token-1", "token-2"}, got[key])
+		assert.NotContains(t, got, "static", "static headers sent with HeadersFunc")
+	})
+
+	t.Run("WithContextHeaders", func(t *testing.T) {
+		type tenantKey struct{}
+		fn := func(ctx context.Context) map[string]string {
+			return map[string]string{"X-Scope-OrgID": ctx.Value(tenantKey{}).(string)}
+		}
+		exp, coll := factoryFunc(
+			nil,
+			WithHeaders(map[string]string{"x-scope-orgid": "static", "static": "v"}),
+			WithContextHeaders(fn),
+		)
+		t.Cleanup(coll.Shutdown)
+		ctx := context.Background()
+
+		tenants := []string{"team-a", "team-b", "team-c"}
+		var wg sync.WaitGroup
+		for _, tenant := range tenants {
+			wg.Add(1)
+			go func(tenant string) {
+				defer wg.Done()
+				tCtx := context.WithValue(ctx, tenantKey{}, tenant)
+				assert.NoError(t, exp.Export(tCtx, &metricdata.ResourceMetrics{}))
+			}(tenant)
+		}
+		wg.Wait()
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Headers()
+		assert.ElementsMatch(t, tenants, got["x-scope-orgid"])
+		assert.Equal(t, []string{"v", "v", "v"}, got["static"])
+	})
+
+	t.Run("WithMetricNameFilter", func(t *testing.T) {
+		keep := func(name string) bool { return name != "noisy" }
+		exp, coll := factoryFunc(nil, WithMetricNameFilter(keep))
+		ctx := context.Background()
+		t.Cleanup(coll.Shutdown)
+		gauge := func(name string) metricdata.Metrics {
+			return metricdata.Metrics{
+				Name: name,
+				Data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{{Value: 1}}},
+			}
+		}
+		rm := &metricdata.ResourceMetrics{
+			ScopeMetrics: []metricdata.ScopeMetrics{
+				{
+					Scope:   instrumentation.Scope{Name: "app"},
+					Metrics: []metricdata.Metrics{gauge("noisy"), gauge("useful")},
+				},
+				{
+					Scope:   instrumentation.Scope{Name: "library"},
+					Metrics: []metricdata.Metrics{gauge("noisy")},
+				},
+			},
+		}
+		require.NoError(t, exp.Export(ctx, rm))
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Collect().Dump()
+		require.Len(t, got, 1)
+		require.Len(t, got[0].ScopeMetrics, 1, "filtered scope not removed")
+		sm := got[0].ScopeMetrics[0]
+		assert.Equal(t, "app", sm.Scope.Name)
+		require.Len(t, sm.Metrics, 1)
+		assert.Equal(t, "useful", sm.Metrics[0].Name)
+	})
+
+	t.Run("WithResourceAttributeFilter", func(t *testing.T) {
+		podUID := attribute.String("k8s.pod.uid", "1234")
+		service := attribute.String("service.name", "test")
+		res := resource.NewSchemaless(podUID, service)
+		keep := func(kv attribute.KeyValue) bool { return kv.Key != podUID.Key }
+		exp, coll := factoryFunc(nil, WithResourceAttributeFilter(keep))
+		ctx := context.Background()
+		t.Cleanup(coll.Shutdown)
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{Resource: res}))
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Collect().Dump()
+		require.Len(t, got, 1)
+		require.Len(t, got[0].Resource.Attributes, 1)
+		assert.Equal(t, string(service.Key), got[0].Resource.Attributes[0].Key)
+		assert.Equal(t, 2, res.Len(), "SDK resource modified")
+	})
+
 	t.Run("WithTimeout", func(t *testing.T) {
 		// Do not send on rCh so the Collector never responds to the client.
 		rCh := make(chan otest.ExportResult)
@@ -191,6 +391,50 @@ func TestConfig(t *testing.T) {
 		assert.ErrorContains(t, err, context.DeadlineExceeded.Error())
 	})
 
+	t.Run("WithRetryDisabled", func(t *testing.T) {
+		rCh := make(chan otest.ExportResult, 2)
+		rCh <- otest.ExportResult{Err: status.Error(codes.Unavailable, "unavailable")}
+		rCh <- otest.ExportResult{}
+		exp, coll := factoryFunc(rCh, WithRetryDisabled())
+		t.Cleanup(coll.Shutdown)
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		assert.Error(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		assert.Len(t, rCh, 1, "export retried")
+	})
+
+	t.Run("WithMinAttemptWindow", func(t *testing.T) {
+		exp, coll := factoryFunc(nil, WithMinAttemptWindow(time.Minute))
+		t.Cleanup(coll.Shutdown)
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		short, cancel := context.WithTimeout(ctx, time.Second)
+		t.Cleanup(cancel)
+		err := exp.Export(short, &metricdata.ResourceMetrics{})
+		assert.ErrorIs(t, err, errInsufficientTime)
+		assert.Len(t, coll.Collect().Dump(), 0)
+
+		long, cancel := context.WithTimeout(ctx, time.Hour)
+		t.Cleanup(cancel)
+		assert.NoError(t, exp.Export(long, &metricdata.ResourceMetrics{}))
+		assert.Len(t, coll.Collect().Dump(), 1)
+	})
+
+	t.Run("WithUserAgent", func(t *testing.T) {
+		exp, coll := factoryFunc(nil, WithUserAgent("myapp/1.2.3"))
+		t.Cleanup(coll.Shutdown)
+		ctx := context.Background()
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		// Ensure everything is flushed.
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Headers()["user-agent"]
+		require.Len(t, got, 1)
+		// gRPC appends its own User-Agent.
+		assert.Regexp(t, "^OTel OTLP Exporter Go/[01]\\..* myapp/1\\.2\\.3 grpc-go/", got[0])
+	})
+
 	t.Run("WithCustomUserAgent", func(t *testing.T) {
 		key := "user-agent"
 		customerUserAgent := "custom-user-agent"
@@ -205,3 +449,529 @@ func TestConfig(t *testing.T) {
 		assert.Contains(t, got[key][0], customerUserAgent)
 	})
 }
+
+func TestWarmupExport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		coll, err := otest.NewGRPCCollector("", nil)
+		require.NoError(t, err)
+		t.Cleanup(coll.Shutdown)
+		exp, err := New(ctx, WithEndpoint(coll.Addr().String()), WithInsecure())
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		require.NoError(t, WarmupExport(ctx, exp))
+		got := coll.Collect().Dump()
+		require.Len(t, got, 1)
+		require.Len(t, got[0].ScopeMetrics, 1)
+		sm := got[0].ScopeMetrics[0]
+		assert.Equal(t, ominternal.WarmupScopeName, sm.Scope.Name)
+		require.Len(t, sm.Metrics, 1)
+		assert.Equal(t, ominternal.WarmupMetricName, sm.Metrics[0].Name)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		rCh := make(chan otest.ExportResult, 1)
+		rCh <- otest.ExportResult{Err: status.Error(codes.PermissionDenied, "denied")}
+		coll, err := otest.NewGRPCCollector("", rCh)
+		require.NoError(t, err)
+		t.Cleanup(coll.Shutdown)
+		exp, err := New(ctx, WithEndpoint(coll.Addr().String()), WithInsecure())
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		err = WarmupExport(ctx, exp)
+		assert.Equal(t, codes.PermissionDenied, status.Code(errors.Unwrap(err)))
+	})
+}
+
+// slowFirstCollector is a metric service that does not respond to the first
+// export it receives until that export is canceled.
+type slowFirstCollector struct {
+	colmetricpb.UnimplementedMetricsServiceServer
+
+	attempts atomic.Int64
+}
+
+func (c *slowFirstCollector) Export(ctx context.Context, _ *colmetricpb.ExportMetricsServiceRequest) (*colmetricpb.ExportMetricsServiceResponse, error) {
+	if c.attempts.Add(1) == 1 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &colmetricpb.ExportMetricsServiceResponse{}, nil
+}
+
+// serve serves the metric service srv and returns the address it listens at.
+func serve(t *testing.T, srv colmetricpb.MetricsServiceServer) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	colmetricpb.RegisterMetricsServiceServer(s, srv)
+	go func() { _ = s.Serve(ln) }()
+	t.Cleanup(s.Stop)
+	return ln.Addr().String()
+}
+
+func TestTimeoutPerAttempt(t *testing.T) {
+	tests := []struct {
+		name      string
+		retryable func(codes.Code) bool
+	}{
+		{name: "Default"},
+		{
+			// An attempt that timed out is retried even if the status code
+			// is not retry-able.
+			name:      "DeadlineExceededNotRetryable",
+			retryable: func(codes.Code) bool { return false },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coll := &slowFirstCollector{}
+			ctx := context.Background()
+			exp, err := New(
+				ctx,
+				WithEndpoint(serve(t, coll)),
+				WithInsecure(),
+				WithTimeout(100*time.Millisecond),
+				WithRetry(RetryConfig{
+					Enabled:         true,
+					InitialInterval: time.Nanosecond,
+					MaxInterval:     time.Millisecond,
+					MaxElapsedTime:  time.Minute,
+				}),
+				WithRetryableGRPCCodeFunc(tt.retryable),
+			)
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+			assert.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+			assert.Equal(t, int64(2), coll.attempts.Load())
+		})
+	}
+}
+
+func TestCanceledExport(t *testing.T) {
+	coll := &slowFirstCollector{}
+	exp, err := New(
+		context.Background(),
+		WithEndpoint(serve(t, coll)),
+		WithInsecure(),
+		WithTimeout(time.Hour),
+		WithRetry(RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Nanosecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Hour,
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(context.Background())) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for coll.attempts.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	err = exp.Export(ctx, &metricdata.ResourceMetrics{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int64(1), coll.attempts.Load(), "canceled export retried")
+}
+
+// blockingCollector is a metric service that records the deadline of the
+// exports it receives and does not respond until they are canceled.
+type blockingCollector struct {
+	colmetricpb.UnimplementedMetricsServiceServer
+
+	remaining chan time.Duration
+}
+
+func (c *blockingCollector) Export(ctx context.Context, _ *colmetricpb.ExportMetricsServiceRequest) (*colmetricpb.ExportMetricsServiceResponse, error) {
+	var remaining time.Duration
+	if d, ok := ctx.Deadline(); ok {
+		remaining = time.Until(d)
+	}
+	c.remaining <- remaining
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCallerDeadline(t *testing.T) {
+	coll := &blockingCollector{remaining: make(chan time.Duration, 1)}
+	exp, err := New(
+		context.Background(),
+		WithEndpoint(serve(t, coll)),
+		WithInsecure(),
+		WithTimeout(10*time.Second),
+		WithRetry(RetryConfig{Enabled: false}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(context.Background())) })
+
+	const deadline = 100 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+
+	start := time.Now()
+	err = exp.Export(ctx, &metricdata.ResourceMetrics{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second, "caller deadline not honored")
+
+	// The earlier deadline of the caller is propagated to the server.
+	remaining := <-coll.remaining
+	assert.Greater(t, remaining, time.Duration(0), "no deadline propagated")
+	assert.LessOrEqual(t, remaining, deadline)
+}
+
+func TestStartTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Unreachable", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		const timeout = 100 * time.Millisecond
+		start := time.Now()
+		_, err = New(ctx, WithEndpoint(addr), WithInsecure(), WithStartTimeout(timeout))
+		assert.ErrorContains(t, err, "failed to connect")
+		assert.Less(t, time.Since(start), 10*timeout, "start timeout not honored")
+	})
+
+	t.Run("Reachable", func(t *testing.T) {
+		coll, err := otest.NewGRPCCollector("", nil)
+		require.NoError(t, err)
+		t.Cleanup(coll.Shutdown)
+
+		exp, err := New(ctx, WithEndpoint(coll.Addr().String()), WithInsecure(), WithStartTimeout(time.Minute))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		assert.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	})
+}
+
+func TestClosedGRPCConn(t *testing.T) {
+	conn, err := grpc.Dial("passthrough:///localhost:0", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	assert.NoError(t, checkConn(conn))
+	require.NoError(t, conn.Close())
+	assert.Error(t, checkConn(conn))
+	assert.NoError(t, checkConn(nil))
+
+	// A closed connection is reported, but does not prevent the creation of
+	// the Exporter.
+	ctx := context.Background()
+	exp, err := New(ctx, WithGRPCConn(conn), WithRetryDisabled())
+	require.NoError(t, err)
+	assert.Error(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	assert.NoError(t, exp.Shutdown(ctx))
+}
+
+func TestNewNoop(t *testing.T) {
+	ctx := context.Background()
+	exp, err := NewNoop(WithTemporalitySelector(func(metric.InstrumentKind) metricdata.Temporality {
+		return metricdata.DeltaTemporality
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, metricdata.DeltaTemporality, exp.Temporality(metric.InstrumentKindCounter))
+	assert.Equal(t, aggregation.Sum{}, exp.Aggregation(metric.InstrumentKindCounter))
+
+	rm := &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{{
+				Name: "gauge",
+				Data: metricdata.Gauge[int64]{
+					DataPoints: []metricdata.DataPoint[int64]{{Value: 1}},
+				},
+			}},
+		}},
+	}
+	assert.NoError(t, exp.Export(ctx, rm))
+	assert.NoError(t, exp.ForceFlush(ctx))
+	assert.NoError(t, exp.Shutdown(ctx))
+}
+
+func TestEndpointFailover(t *testing.T) {
+	// down returns the address of an endpoint that is not listening.
+	down := func(t *testing.T) string {
+		ln, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+		return addr
+	}
+
+	coll, err := otest.NewGRPCCollector("", nil)
+	require.NoError(t, err)
+	t.Cleanup(coll.Shutdown)
+
+	ctx := context.Background()
+	exp, err := New(ctx,
+		WithEndpoints(down(t), coll.Addr().String()),
+		WithInsecure(),
+		WithRetry(RetryConfig{Enabled: false}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	}
+	assert.Len(t, coll.Collect().Dump(), 4)
+}
+
+func TestTLSServerName(t *testing.T) {
+	// Borrow the certificate of an httptest server. It is valid for
+	// example.com and the loopback addresses.
+	certSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	cert := certSrv.TLS.Certificates[0]
+	pool := x509.NewCertPool()
+	pool.AddCert(certSrv.Certificate())
+	certSrv.Close()
+
+	var (
+		mu  sync.Mutex
+		sni string
+	)
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			mu.Lock()
+			sni = hello.ServerName
+			mu.Unlock()
+			return nil, nil
+		},
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(grpc.Creds(creds))
+	// Do not block the first export.
+	coll := new(slowFirstCollector)
+	coll.attempts.Store(1)
+	colmetricpb.RegisterMetricsServiceServer(s, coll)
+	go func() { _ = s.Serve(ln) }()
+	t.Cleanup(s.Stop)
+
+	// The server is dialed by IP and presents a certificate for example.com.
+	// The server name of the credentials passed is overridden.
+	ctx := context.Background()
+	exp, err := New(ctx,
+		WithEndpoint(ln.Addr().String()),
+		WithTLSCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool, ServerName: "invalid"})),
+		WithTLSServerName("example.com"),
+		WithRetry(RetryConfig{Enabled: false}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	mu.Lock()
+	assert.Equal(t, "example.com", sni)
+	mu.Unlock()
+}
+
+func TestMarshalErrorNotRetried(t *testing.T) {
+	coll, err := otest.NewGRPCCollector("", nil)
+	require.NoError(t, err)
+	t.Cleanup(coll.Shutdown)
+
+	var attempts atomic.Int64
+	count := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		attempts.Add(1)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	ctx := context.Background()
+	exp, err := New(
+		ctx,
+		WithEndpoint(coll.Addr().String()),
+		WithInsecure(),
+		WithDialOption(grpc.WithUnaryInterceptor(count)),
+		WithRetry(RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Nanosecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Minute,
+		}),
+		// Marshal errors have the Internal code, they are not retried even
+		// if the code is retry-able.
+		WithRetryableGRPCCodeFunc(func(code codes.Code) bool {
+			return code == codes.Internal
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	// Strings with invalid UTF-8 cannot be marshaled.
+	rm := &metricdata.ResourceMetrics{
+		Resource: resource.NewSchemaless(attribute.String("service.name", "\xff")),
+	}
+	err = exp.Export(ctx, rm)
+	assert.ErrorContains(t, err, "failed to marshal metrics")
+	assert.Equal(t, int64(1), attempts.Load())
+	assert.Len(t, coll.Collect().Dump(), 0)
+}
+
+func TestGRPCResolvers(t *testing.T) {
+	coll, err := otest.NewGRPCCollector("", nil)
+	require.NoError(t, err)
+	t.Cleanup(coll.Shutdown)
+
+	r := manual.NewBuilderWithScheme("fake")
+	r.InitialState(resolver.State{
+		Addresses: []resolver.Address{{Addr: coll.Addr().String()}},
+	})
+
+	ctx := context.Background()
+	exp, err := New(ctx,
+		WithEndpoint("fake:///collector"),
+		WithInsecure(),
+		WithGRPCResolvers(r),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	assert.Len(t, coll.Collect().Dump(), 1)
+}
+
+func TestStartupPing(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Healthy", func(t *testing.T) {
+		coll, err := otest.NewGRPCCollector("", nil)
+		require.NoError(t, err)
+		t.Cleanup(coll.Shutdown)
+
+		exp, err := New(ctx, WithEndpoint(coll.Addr().String()), WithInsecure(), WithStartupPing(true))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		assert.Len(t, coll.Collect().Dump(), 1, "no ping export received")
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		const timeout = 100 * time.Millisecond
+		start := time.Now()
+		_, err = New(ctx,
+			WithEndpoint(addr),
+			WithInsecure(),
+			WithStartupPing(true),
+			WithStartTimeout(timeout),
+		)
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), 50*timeout, "start timeout not honored")
+
+		// Without a start timeout, the connection is established by the
+		// ping which is bounded by the context passed to New.
+		tCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		_, err = New(tCtx, WithEndpoint(addr), WithInsecure(), WithStartupPing(true))
+		assert.ErrorContains(t, err, "startup ping failed")
+	})
+}
+
+func TestGRPCMaxSendMsgSize(t *testing.T) {
+	coll, err := otest.NewGRPCCollector("", nil)
+	require.NoError(t, err)
+	t.Cleanup(coll.Shutdown)
+
+	var attempts int64
+	countAttempts := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		atomic.AddInt64(&attempts, 1)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	ctx := context.Background()
+	exp, err := New(
+		ctx,
+		WithEndpoint(coll.Addr().String()),
+		WithInsecure(),
+		WithDialOption(grpc.WithUnaryInterceptor(countAttempts)),
+		WithTimeout(time.Hour),
+		// The request never fits, it must not be retried until
+		// MaxElapsedTime.
+		WithRetry(RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Nanosecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Hour,
+		}),
+		WithGRPCMaxSendMsgSize(16),
+		WithGRPCMaxRecvMsgSize(1<<20),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	rm := &metricdata.ResourceMetrics{
+		Resource: resource.NewSchemaless(attribute.String("service.name", strings.Repeat("a", 64))),
+	}
+	err = exp.Export(ctx, rm)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(errors.Unwrap(err)), err)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&attempts))
+	assert.Len(t, coll.Collect().Dump(), 0)
+}
+
+func TestExportError(t *testing.T) {
+	ctx := context.Background()
+	newExporter := func(t *testing.T, results ...otest.ExportResult) metric.Exporter {
+		rCh := make(chan otest.ExportResult, len(results))
+		for _, r := range results {
+			rCh <- r
+		}
+		coll, err := otest.NewGRPCCollector("", rCh)
+		require.NoError(t, err)
+		t.Cleanup(coll.Shutdown)
+		exp, err := New(ctx, WithEndpoint(coll.Addr().String()), WithInsecure(), WithRetry(RetryConfig{Enabled: false}))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		return exp
+	}
+
+	t.Run("Rejected", func(t *testing.T) {
+		exp := newExporter(t, otest.ExportResult{Err: status.Error(codes.InvalidArgument, "invalid")})
+		err := exp.Export(ctx, &metricdata.ResourceMetrics{})
+
+		var exportErr *ExportError
+		require.ErrorAs(t, err, &exportErr)
+		assert.Equal(t, "metrics", exportErr.Signal)
+		assert.Equal(t, int(codes.InvalidArgument), exportErr.StatusCode)
+		assert.False(t, exportErr.Retryable)
+	})
+
+	t.Run("Retryable", func(t *testing.T) {
+		exp := newExporter(t, otest.ExportResult{Err: status.Error(codes.Unavailable, "unavailable")})
+		err := exp.Export(ctx, &metricdata.ResourceMetrics{})
+
+		var exportErr *ExportError
+		require.ErrorAs(t, err, &exportErr)
+		assert.Equal(t, int(codes.Unavailable), exportErr.StatusCode)
+		assert.True(t, exportErr.Retryable)
+		assert.Equal(t, codes.Unavailable, status.Code(exportErr))
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		exp := newExporter(t)
+		cCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		err := exp.Export(cCtx, &metricdata.ResourceMetrics{})
+
+		var exportErr *ExportError
+		require.ErrorAs(t, err, &exportErr)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, exportErr.StatusCode)
+		assert.False(t, exportErr.Retryable)
+	})
+}
@@ -15,16 +15,52 @@
 package otlpmetricgrpc // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/internal/retry"
+	ominternal "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
+	otelmetric "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// BackpressurePolicy describes what is done with an export when the limit set
+// with WithMaxInFlightBytes has been reached.
+type BackpressurePolicy oconf.BackpressurePolicy
+
+const (
+	// Block makes an export wait for in-flight exports to complete.
+	Block = BackpressurePolicy(oconf.BlockBackpressure)
+	// Drop makes an export fail immediately, dropping its metric data.
+	Drop = BackpressurePolicy(oconf.DropBackpressure)
+)
+
+// MonotonicViolationPolicy describes how a cumulative monotonic sum data
+// point with a value less than the previously exported value of its series is
+// handled.
+type MonotonicViolationPolicy ominternal.MonotonicViolationPolicy
+
+const (
+	// PassThrough exports the decreased value.
+	PassThrough = MonotonicViolationPolicy(ominternal.PassThroughMonotonicViolation)
+	// DropViolation does not export the data point with the decreased value.
+	// A metric left without data points, and a scope left without metrics,
+	// are not exported either.
+	DropViolation = MonotonicViolationPolicy(ominternal.DropMonotonicViolation)
+	// ClampToPrevious exports the previously exported value of the series
+	// instead of the decreased value.
+	ClampToPrevious = MonotonicViolationPolicy(ominternal.ClampMonotonicViolation)
 )
 
 // Option applies a configuration option to the Exporter.
@@ -65,6 +101,16 @@ func (w wrappedOption) applyGRPCOption(cfg oconf.Config) oconf.Config {
 // scheme of "http" or "unix" client security will be disabled. If both are
 // set, OTEL_EXPORTER_OTLP_METRICS_ENDPOINT will take precedence.
 //
+// If the OTEL_EXPORTER_OTLP_INSECURE or OTEL_EXPORTER_OTLP_METRICS_INSECURE
+// environment variable is set to "true" or "false" (case-insensitive), and
+// this option is not passed, that variable value will be used to determine
+// client security instead of the endpoint scheme. If both are set,
+// OTEL_EXPORTER_OTLP_METRICS_INSECURE will take precedence.
+//
+// This option has no effect if the endpoint passed to WithEndpoint starts
+// with an "http://" or "https://" scheme. The scheme determines the client
+// security instead.
+//
 // By default, if an environment variable is not set, and this option is not
 // passed, client security will be used.
 //
@@ -73,7 +119,26 @@ func WithInsecure() Option {
 	return wrappedOption{oconf.WithInsecure()}
 }
 
-// WithEndpoint sets the target endpoint the Exporter will connect to.
+// WithEndpoint sets the target endpoint the Exporter will connect to. This
+// endpoint is specified as a host and optional port. If no port is included,
+// the default OTLP/gRPC port 4317 is used. IPv6 addresses need to be enclosed
+// in square brackets (e.g. "[::1]:4317" or "[fe80::1%eth0]"), unbracketed IPv6
+// addresses are ambiguous and ignored with an error logged.
+//
+// The endpoint may start with an "http://" or "https://" scheme. An explicit
+// scheme determines the client security and takes precedence over
+// WithInsecure regardless of the order the options are passed in. If
+// WithInsecure is also passed with an "https://" endpoint, an error is logged
+// and client security is used.
+//
+// The endpoint may be a gRPC target using the scheme of a name resolver built
+// into gRPC (e.g. "unix:///var/run/otel.sock", "dns:///collector:4317", or
+// "passthrough:///localhost:4317"). These targets are used as is, both when
+// passed with this option and when set with an environment variable. Targets
+// with any other scheme than "http" or "https" followed by "://" (e.g.
+// "xds:///collector") are also used as is, no default port is added. The
+// name resolver for their scheme needs to be registered with gRPC or passed
+// with WithGRPCResolvers.
 //
 // If the OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_METRICS_ENDPOINT
 // environment variable is set, and this option is not passed, that variable
@@ -88,15 +153,67 @@ func WithEndpoint(endpoint string) Option {
 	return wrappedOption{oconf.WithEndpoint(endpoint)}
 }
 
+// WithEndpoints sets multiple target endpoints the Exporter will connect to.
+// Each endpoint is specified as a host and optional port, no scheme should
+// be included. If no port is included, the default port 4317 is used.
+//
+// The endpoints are used for failover: the Exporter connects to the first
+// endpoint that can be reached, in the order they are passed. If the
+// connection to that endpoint fails, a connection to the endpoints is
+// established again in the same order. Exports are not distributed across
+// the endpoints.
+//
+// Endpoints without a host are ignored and an error is logged. If a
+// certificate is verified, it is verified against the name of the endpoint
+// connected to.
+//
+// This option and WithEndpoint override each other, the last one passed is
+// used.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithEndpoints(endpoints ...string) Option {
+	return wrappedOption{oconf.WithEndpoints(endpoints...)}
+}
+
 // WithReconnectionPeriod set the minimum amount of time between connection
 // attempts to the target endpoint.
 //
+// Periods less than 100ms, including negative ones, are increased to 100ms.
+// A zero period means the gRPC default is used.
+//
 // This option has no effect if WithGRPCConn is used.
 func WithReconnectionPeriod(rp time.Duration) Option {
-	return wrappedOption{oconf.NewGRPCOption(func(cfg oconf.Config) oconf.Config {
-		cfg.ReconnectionPeriod = rp
-		return cfg
-	})}
+	return wrappedOption{oconf.WithReconnectionPeriod(rp)}
+}
+
+// WithStartupPing sets if New makes an empty export to check the target
+// endpoint can be reached. If the export fails, New returns an error instead
+// of the problem being discovered when the first metric data is exported.
+// The export is bounded by the context passed to New and, if set, by the
+// time set with WithStartTimeout. It is retried like any other export.
+//
+// The collector receives this export like any other. It contains no metric
+// data, but receiving backends may still record it.
+//
+// By default, if this option is not passed, no export is made by New.
+func WithStartupPing(enabled bool) Option {
+	return wrappedOption{oconf.WithStartupPing(enabled)}
+}
+
+// WithStartTimeout sets the maximum amount of time New waits for the
+// connection to the target endpoint to be established. If the connection is
+// not ready within d, New returns an error. This bounds the creation of an
+// Exporter when the target endpoint cannot be resolved or is unreachable.
+//
+// This is separate from the timeout set with WithTimeout, which bounds each
+// export attempt after the Exporter has been created.
+//
+// By default, or if d is not positive, New does not wait for the connection
+// to be established, it is established when the first export is made.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithStartTimeout(d time.Duration) Option {
+	return wrappedOption{oconf.WithStartTimeout(d)}
 }
 
 func compressorToCompression(compressor string) oconf.Compression {
@@ -126,6 +243,11 @@ func compressorToCompression(compressor string) oconf.Compression {
 // By default, if an environment variable is not set, and this option is not
 // passed, no compressor will be used.
 //
+// The level of the gzip compressor is not configurable through this option.
+// The compressor registered by google.golang.org/grpc/encoding/gzip uses the
+// default level unless changed with its SetLevel function, which applies to
+// all gRPC clients of the process.
+//
 // This option has no effect if WithGRPCConn is used.
 func WithCompressor(compressor string) Option {
 	return wrappedOption{oconf.WithCompression(compressorToCompression(compressor))}
@@ -139,6 +261,13 @@ func WithCompressor(compressor string) Option {
 // These pairs are expected to be in the W3C Correlation-Context format
 // without additional semi-colon delimited metadata (i.e. "k1=v1,k2=v2"). If
 // both are set, OTEL_EXPORTER_OTLP_METRICS_HEADERS will take precedence.
+// Header values read from these environment variables may reference other
+// environment variables using the "${VAR}" syntax (use "$$" for a literal
+// "$"). A header referencing an unset variable is not sent.
+//
+// The headers are merged with any headers set by an environment variable or
+// previously passed option, with the values in headers taking precedence. Use
+// WithHeadersReplace to replace these headers instead.
 //
 // By default, if an environment variable is not set, and this option is not
 // passed, no user headers will be set.
@@ -146,23 +275,159 @@ func WithHeaders(headers map[string]string) Option {
 	return wrappedOption{oconf.WithHeaders(headers)}
 }
 
+// WithHeader sends a header with key and value with each gRPC request. It is
+// merged with the headers set by an environment variable or previously passed
+// option, replacing any value previously set for key. For example, a tenant
+// can be set with:
+//
+//	WithHeader("X-Scope-OrgID", "team-a")
+//
+// This is equivalent to WithHeaders(map[string]string{key: value}).
+func WithHeader(key, value string) Option {
+	return wrappedOption{oconf.WithHeader(key, value)}
+}
+
+// WithHeadersFromFile reads headers from the file at path and merges them
+// like WithHeaders. This allows headers to be read from a mounted secret.
+// Each line of the file defines one header as a "key: value" or "key=value"
+// pair, for example:
+//
+//	# Collector credentials.
+//	Authorization: Bearer dG9rZW4=
+//	X-Scope-OrgID=team-a
+//
+// Blank lines and lines starting with "#" are ignored. The file is read once,
+// when the Exporter is created. If it cannot be read or a line is malformed,
+// no header is read from it and an error is logged.
+func WithHeadersFromFile(path string) Option {
+	return wrappedOption{oconf.WithHeadersFromFile(path)}
+}
+
+// WithHeadersReplace is like WithHeaders, but replaces any headers set by an
+// environment variable or previously passed option with headers instead of
+// merging with them.
+func WithHeadersReplace(headers map[string]string) Option {
+	return wrappedOption{oconf.WithHeadersReplace(headers)}
+}
+
+// WithHeadersFunc sets fn to be called for each export to get the headers
+// sent with it. This allows headers, like authentication tokens, to be
+// refreshed without creating a new Exporter. If set, the headers returned
+// from fn are sent instead of any set with WithHeaders or an environment
+// variable.
+//
+// The fn is called synchronously once for every export, so it should be
+// cheap (e.g. return a cached value refreshed elsewhere). It may be called
+// concurrently and needs to be safe to do so. The returned map is not
+// modified.
+func WithHeadersFunc(fn func() map[string]string) Option {
+	return wrappedOption{oconf.WithHeadersFunc(fn)}
+}
+
 // WithTLSCredentials sets the gRPC connection to use creds.
 //
 // If the OTEL_EXPORTER_OTLP_CERTIFICATE or
 // OTEL_EXPORTER_OTLP_METRICS_CERTIFICATE environment variable is set, and
 // this option is not passed, that variable value will be used. The value will
-// be parsed the filepath of the TLS certificate chain to use. If both are
-// set, OTEL_EXPORTER_OTLP_METRICS_CERTIFICATE will take precedence.
+// be parsed the filepath of the TLS certificate chain to use. If the value is
+// the path of a directory, the certificates of all PEM encoded files in the
+// directory are used. If both are set,
+// OTEL_EXPORTER_OTLP_METRICS_CERTIFICATE will take precedence.
 //
-// By default, if an environment variable is not set, and this option is not
-// passed, no TLS credentials will be used.
+// If the OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE and
+// OTEL_EXPORTER_OTLP_CLIENT_KEY, or the
+// OTEL_EXPORTER_OTLP_METRICS_CLIENT_CERTIFICATE and
+// OTEL_EXPORTER_OTLP_METRICS_CLIENT_KEY, environment variables are set, and
+// this option is not passed, those variable values will be parsed as the
+// filepaths of the client certificate and key to use for mTLS. If both pairs
+// are set, the OTEL_EXPORTER_OTLP_METRICS_ pair will take precedence.
+//
+// By default, if an environment variable is not set, and neither this option
+// nor WithInsecure is passed, TLS credentials using the root CAs of the host
+// will be used and an informational message is logged. If the host has no
+// root CAs (e.g. in an air-gapped environment), pass the credentials to use
+// with this option instead.
 //
 // This option has no effect if WithGRPCConn is used.
 func WithTLSCredentials(creds credentials.TransportCredentials) Option {
-	return wrappedOption{oconf.NewGRPCOption(func(cfg oconf.Config) oconf.Config {
-		cfg.Metrics.GRPCCredentials = creds
-		return cfg
-	})}
+	return wrappedOption{oconf.WithGRPCCredentials(creds)}
+}
+
+// WithTLSMinVersion sets the minimum TLS version the Exporter uses to
+// version, one of the version constants of the crypto/tls package (e.g.
+// tls.VersionTLS13). It is applied to the TLS configuration set by an
+// environment variable, or to a new one if none is set, and the transport
+// credentials are created from it. It cannot be applied to credentials passed
+// with WithTLSCredentials, those are used as is and an error is reported to
+// the global error handler. An unknown version is reported to the global
+// error handler and ignored.
+//
+// This option has no effect if WithGRPCConn or WithInsecure is used.
+//
+// By default, if this option is not passed, the minimum version of the TLS
+// configuration is used.
+func WithTLSMinVersion(version uint16) Option {
+	return wrappedOption{oconf.WithTLSMinVersion(version)}
+}
+
+// WithTLSServerName sets the server name the Exporter uses to verify the
+// certificate of the endpoint, and sends for SNI, to name. This allows the
+// endpoint to be an IP address while the certificate presented is for a host
+// name. It is applied to the TLS configuration set by an environment
+// variable, or to a new one if none is set, and the transport credentials are
+// created from it. Credentials passed with WithTLSCredentials are copied and
+// have their server name overridden.
+//
+// This option has no effect if WithGRPCConn or WithInsecure is used.
+//
+// By default, if this option is not passed, the server name of the TLS
+// configuration is used, or the host of the endpoint if it is empty.
+func WithTLSServerName(name string) Option {
+	return wrappedOption{oconf.WithTLSServerName(name)}
+}
+
+// WithTLSCipherSuites sets the cipher suites the Exporter enables to suites,
+// cipher suite IDs defined in the crypto/tls package. It is applied like
+// WithTLSMinVersion. Unknown cipher suites are reported to the global error
+// handler and ignored.
+//
+// The cipher suites of TLS 1.3 are not configurable. Use this option with
+// WithTLSMinVersion(tls.VersionTLS12) or lower to restrict the cipher suites
+// negotiated with older TLS versions.
+//
+// By default, if this option is not passed, the cipher suites of the TLS
+// configuration are used.
+func WithTLSCipherSuites(suites []uint16) Option {
+	return wrappedOption{oconf.WithTLSCipherSuites(suites)}
+}
+
+// WithTLSCertPool sets the certificate authorities used to verify the
+// certificate of the endpoint to all PEM encoded certificates found in the
+// files of dir and its subdirectories. Files that do not contain a PEM encoded
+// certificate are skipped.
+//
+// If no certificate is found, an error is logged and the TLS configuration is
+// left unchanged.
+//
+// This option replaces any transport credentials set with
+// WithTLSCredentials, and has no effect if WithGRPCConn is used.
+func WithTLSCertPool(dir string) Option {
+	return wrappedOption{oconf.WithTLSCertPool(dir)}
+}
+
+// WithTLSClientCertFromFile sets the TLS configuration the Exporter will use
+// for the gRPC connection to one loaded from files. The PEM encoded client certificate
+// and key at certPath and keyPath are presented to the server, and the PEM
+// encoded CA certificate at caPath is used to verify the server certificate.
+// If both certPath and keyPath are empty no client certificate is used, and
+// if caPath is empty the host's root CA set is used.
+//
+// If any of the files cannot be read or the certificate and key do not form a
+// valid pair, an error is logged and the TLS configuration is left unchanged.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithTLSClientCertFromFile(certPath, keyPath, caPath string) Option {
+	return wrappedOption{oconf.WithTLSClientCertFromFile(certPath, keyPath, caPath)}
 }
 
 // WithServiceConfig defines the default gRPC service config used.
@@ -175,39 +440,164 @@ func WithServiceConfig(serviceConfig string) Option {
 	})}
 }
 
+// WithServiceConfigFromFile defines the default gRPC service config used as
+// the JSON contents of the file at path.
+//
+// If the file cannot be read or does not contain valid JSON, an error is
+// logged and the service config is left unchanged.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithServiceConfigFromFile(path string) Option {
+	return wrappedOption{oconf.WithServiceConfigFromFile(path)}
+}
+
+// WithGRPCResolvers adds name resolver builders used to resolve the endpoint
+// of the Exporter. Unlike registering them with gRPC, these builders are only
+// used by the Exporter. Set the endpoint to a target with the scheme of a
+// builder (e.g. "xds:///collector") using WithEndpoint or an environment
+// variable to use it, that target is passed to gRPC as is.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithGRPCResolvers(builders ...resolver.Builder) Option {
+	return wrappedOption{oconf.WithGRPCResolvers(builders...)}
+}
+
 // WithDialOption sets explicit grpc.DialOptions to use when establishing a
-// gRPC connection. The options here are appended to the internal grpc.DialOptions
-// used so they will take precedence over any other internal grpc.DialOptions
-// they might conflict with.
+// gRPC connection. This can be used to set options not otherwise configurable,
+// like interceptors, stats handlers, or resolvers. The options here are
+// appended after all the internal grpc.DialOptions used so they will take
+// precedence over any other internal grpc.DialOptions they might conflict
+// with. Options passed with multiple uses of this option are all used, in
+// the order passed.
+//
+// If the HTTPS_PROXY environment variable is set, connections are tunneled
+// through that proxy unless the endpoint host matches the NO_PROXY
+// environment variable, in which case it is dialed directly. A dialer passed
+// with grpc.WithContextDialer replaces this behavior.
 //
 // This option has no effect if WithGRPCConn is used.
 func WithDialOption(opts ...grpc.DialOption) Option {
-	return wrappedOption{oconf.NewGRPCOption(func(cfg oconf.Config) oconf.Config {
-		cfg.DialOptions = opts
-		return cfg
-	})}
+	return wrappedOption{oconf.WithGRPCDialOption(opts...)}
+}
+
+// WithGRPCWaitForReady sets if exports wait for the gRPC connection to the
+// endpoint to be ready instead of failing fast. The connection is established
+// in the background when the Exporter is created. If waitForReady is true, an
+// export made before the connection is ready, or while it is reconnecting,
+// blocks until it is ready or the export times out (see WithTimeout). This
+// avoids failing the first exports after startup, at the cost of increasing
+// the latency of exports while the endpoint cannot be reached.
+//
+// By default, if this option is not passed, exports fail immediately if the
+// connection is not ready and are retried according to WithRetry.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithGRPCWaitForReady(waitForReady bool) Option {
+	return wrappedOption{oconf.WithGRPCWaitForReady(waitForReady)}
+}
+
+// WithGRPCMaxRecvMsgSize sets the maximum size in bytes of the responses the
+// Exporter receives from the endpoint. Responses larger than n fail the
+// export with a ResourceExhausted error. This can be used to accept large
+// responses, like ones with verbose partial success messages.
+//
+// By default, if this option is not passed, or n is not positive, the gRPC
+// default of 4 MiB is used.
+//
+// This option has no effect if WithGRPCConn is used. The otlpmetrichttp
+// exporter does not limit the size of responses.
+func WithGRPCMaxRecvMsgSize(n int) Option {
+	return wrappedOption{oconf.WithGRPCMaxRecvMsgSize(n)}
+}
+
+// WithGRPCMaxSendMsgSize sets the maximum size in bytes of the requests the
+// Exporter sends to the endpoint. Exports of requests larger than n fail with
+// a ResourceExhausted error without being sent and are not retried. Use
+// WithMaxPayloadBytes to split large exports into smaller requests instead.
+//
+// By default, if this option is not passed, or n is not positive, the size of
+// requests is not limited by gRPC.
+//
+// This option has no effect if WithGRPCConn is used. The otlpmetrichttp
+// exporter does not limit the size of requests with this option.
+func WithGRPCMaxSendMsgSize(n int) Option {
+	return wrappedOption{oconf.WithGRPCMaxSendMsgSize(n)}
+}
+
+// WithGRPCKeepalive sets the keepalive parameters of the gRPC connection to
+// the endpoint. This can be used to keep long-lived connections from being
+// dropped by intermediaries when they are idle.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithGRPCKeepalive(params keepalive.ClientParameters) Option {
+	return wrappedOption{oconf.WithGRPCKeepalive(params)}
+}
+
+// WithQuietDefaults suppresses the informational log message emitted when
+// the Exporter uses TLS with the host's root CAs because no transport
+// credentials are set.
+//
+// By default, if this option is not passed, the message is logged the first
+// time an Exporter is created without transport credentials in the process.
+func WithQuietDefaults() Option {
+	return wrappedOption{oconf.WithQuietDefaults()}
 }
 
 // WithGRPCConn sets conn as the gRPC ClientConn used for all communication.
 //
 // This option takes precedence over any other option that relates to
 // establishing or persisting a gRPC connection to a target endpoint. Any
-// other option of those types passed will be ignored.
+// other option of those types passed will be ignored. An error is logged if
+// WithEndpoint, WithEndpoints, WithTLSClientConfig, WithTLSCertPool, or
+// WithTLSCredentials is also passed.
 //
 // It is the callers responsibility to close the passed conn. The Exporter
-// Shutdown method will not close this connection.
+// Shutdown method will not close this connection. If conn is already closed
+// when the Exporter is created, an error is logged and all exports will fail.
 func WithGRPCConn(conn *grpc.ClientConn) Option {
+	return wrappedOption{oconf.WithGRPCConn(conn)}
+}
+
+// WithGRPCJSONFallback makes the Exporter retry an export once using the
+// OTLP/JSON encoding if the server responds to the protobuf encoded export
+// with an Unimplemented status. This allows exporting to gateways that only
+// accept JSON encoded OTLP over gRPC.
+//
+// By default, if this option is not passed, only the protobuf encoding is
+// used.
+func WithGRPCJSONFallback() Option {
 	return wrappedOption{oconf.NewGRPCOption(func(cfg oconf.Config) oconf.Config {
-		cfg.GRPCConn = conn
+		cfg.GRPCJSONFallback = true
 		return cfg
 	})}
 }
 
-// WithTimeout sets the max amount of time an Exporter will attempt an export.
+// WithContextHeaders sets fn to be called with the context passed to each
+// export to get headers sent with it. This allows values only known when
+// metric data is exported, like a tenant ID stored in the context by
+// middleware, to be forwarded to the receiving endpoint.
 //
-// This takes precedence over any retry settings defined by WithRetry. Once
-// this time limit has been reached the export is abandoned and the metric
-// data is dropped.
+// The headers returned from fn are merged with the headers set by
+// WithHeaders, WithHeadersFunc, or an environment variable. The headers
+// returned from fn take precedence.
+//
+// The fn is called synchronously once for every export. It may be called
+// concurrently with different contexts and needs to be safe to do so. The
+// returned map is not modified.
+func WithContextHeaders(fn func(context.Context) map[string]string) Option {
+	return wrappedOption{oconf.WithContextHeaders(fn)}
+}
+
+// WithTimeout sets the max amount of time each attempt of an export can take.
+//
+// If retries are enabled with WithRetry, an attempt that reaches this time
+// limit is retried. The max amount of time spent on an export, including all
+// of its attempts, is limited by the MaxElapsedTime of the RetryConfig
+// instead. If retries are not enabled, or the time limit of the export is
+// reached, the export is abandoned and the metric data is dropped.
+//
+// If the context passed to Export has an earlier deadline, that deadline is
+// used for the attempt instead.
 //
 // If the OTEL_EXPORTER_OTLP_TIMEOUT or OTEL_EXPORTER_OTLP_METRICS_TIMEOUT
 // environment variable is set, and this option is not passed, that variable
@@ -221,6 +611,42 @@ func WithTimeout(duration time.Duration) Option {
 	return wrappedOption{oconf.WithTimeout(duration)}
 }
 
+// WithForceFlushTimeout sets the max amount of time ForceFlush of the
+// Exporter waits for the export in progress, if any, to complete. If the
+// context passed to ForceFlush has an earlier deadline, that deadline is used
+// instead. When d elapses, ForceFlush returns context.DeadlineExceeded and the
+// export in progress continues.
+//
+// By default, if this option is not passed, or d is not positive, the export
+// timeout set with WithTimeout is used.
+func WithForceFlushTimeout(d time.Duration) Option {
+	return wrappedOption{oconf.WithForceFlushTimeout(d)}
+}
+
+// WithMinAttemptWindow sets the minimum amount of time that needs to remain
+// before the deadline of the context passed to Export for the Exporter to
+// attempt the export. If less time remains, the export is not attempted and
+// an error is returned immediately.
+//
+// By default, if this option is not passed, or d is not positive, an export
+// is attempted regardless of the time remaining.
+func WithMinAttemptWindow(d time.Duration) Option {
+	return wrappedOption{oconf.WithMinAttemptWindow(d)}
+}
+
+// WithUserAgent appends suffix to the User-Agent the Exporter sends with
+// exports. The User-Agent of the Exporter, identifying the OpenTelemetry
+// exporter and its version, is always sent first. The suffix should be one
+// or more product identifiers separated by spaces (e.g. "myapp/1.2.3").
+//
+// If this option is passed multiple times, the last suffix is used. A suffix
+// containing line breaks is ignored and an error is logged.
+//
+// This option has no effect if WithGRPCConn is used.
+func WithUserAgent(suffix string) Option {
+	return wrappedOption{oconf.WithUserAgent(suffix)}
+}
+
 // WithRetry sets the retry policy for transient retryable errors that are
 // returned by the target endpoint.
 //
@@ -234,23 +660,314 @@ func WithTimeout(duration time.Duration) Option {
 // If unset, the default retry policy will be used. It will retry the export
 // 5 seconds after receiving a retryable error and increase exponentially
 // after each error for no more than a total time of 1 minute.
+//
+// If retries are enabled and the MaxElapsedTime of the policy is shorter
+// than its InitialInterval, no retry is ever made. A warning is logged for
+// this combination.
+//
+// Each backoff interval is randomized to avoid many clients retrying at the
+// same time. Use WithRetryRandomizationFactor to change by how much.
 func WithRetry(settings RetryConfig) Option {
 	return wrappedOption{oconf.WithRetry(retry.Config(settings))}
 }
 
+// WithRetryableGRPCCodeFunc sets the function that reports if an export that
+// failed with the gRPC status code is retried. It replaces the default
+// classification of the retry-able codes defined by the OTLP specification.
+// An export attempt that timed out is retried regardless of fn, and one that
+// cannot be marshaled is never retried.
+//
+// Exports are only retried if retries are enabled, see WithRetry.
+//
+// By default, if this option is not passed, or fn is nil, the default
+// classification is used.
+func WithRetryableGRPCCodeFunc(fn func(code codes.Code) bool) Option {
+	return wrappedOption{oconf.WithRetryableGRPCCodeFunc(fn)}
+}
+
+// WithRetryDisabled disables retrying exports that failed with a retryable
+// error. A failed export returns its error immediately after the first
+// attempt and the metric data is dropped. This is useful when dropping
+// metric data is preferred over blocking the export for retries.
+//
+// If WithRetry is passed after this option, it overrides this setting.
+func WithRetryDisabled() Option {
+	return wrappedOption{oconf.WithRetryDisabled()}
+}
+
+// WithRetryInitialInterval sets the time to wait after the first failed
+// attempt of an export before retrying it. Only this setting of the retry
+// policy is changed, the rest are left as set by WithRetry or the defaults.
+//
+// If WithRetry is passed after this option, it overrides this setting.
+func WithRetryInitialInterval(d time.Duration) Option {
+	return wrappedOption{oconf.WithRetryInitialInterval(d)}
+}
+
+// WithRetryMaxInterval sets the upper bound of the time to wait between
+// attempts of an export. Only this setting of the retry policy is changed,
+// the rest are left as set by WithRetry or the defaults.
+//
+// If WithRetry is passed after this option, it overrides this setting.
+func WithRetryMaxInterval(d time.Duration) Option {
+	return wrappedOption{oconf.WithRetryMaxInterval(d)}
+}
+
+// WithRetryMultiplier sets the factor the time to wait between attempts of an
+// export is multiplied by after each failed attempt. The multiplier is not
+// part of the RetryConfig and is not changed by WithRetry.
+//
+// The multiplier must be at least 1. If a smaller value is passed, an error
+// is logged and the multiplier is not changed.
+//
+// By default, if this option is not passed, a multiplier of 1.5 is used.
+func WithRetryMultiplier(m float64) Option {
+	return wrappedOption{oconf.WithRetryMultiplier(m)}
+}
+
+// WithRetryRandomizationFactor sets the factor the time to wait between
+// attempts of an export is randomized by. This spreads out the retries of
+// many clients failing at the same time. The time waited is chosen at random
+// in the range [interval*(1-f), interval*(1+f)]. The factor is not part of
+// the RetryConfig and is not changed by WithRetry.
+//
+// A negative factor means the time waited is not randomized. A factor greater
+// than 1 is reduced to 1.
+//
+// By default, if this option is not passed, or f is zero, a factor of 0.5 is
+// used.
+func WithRetryRandomizationFactor(f float64) Option {
+	return wrappedOption{oconf.WithRetryRandomizationFactor(f)}
+}
+
+// WithSchemaTransform sets a function that is applied to all resource and
+// data point attributes of the metric data the Exporter exports. The
+// attribute returned by fn is exported instead of the passed one, unless fn
+// returns false in which case the attribute is dropped. This can be used to
+// translate attributes between versions of semantic conventions.
+//
+// The metric data held by the SDK is not modified, only the exported data.
+func WithSchemaTransform(fn func(attribute.KeyValue) (attribute.KeyValue, bool)) Option {
+	return wrappedOption{oconf.WithSchemaTransform(fn)}
+}
+
+// WithMetricNameFilter sets a function that reports if a metric with the
+// name is exported. The metrics keep returns false for are dropped from the
+// exported data, and so are the instrumentation scopes left without any
+// metric. This can be used to avoid exporting the metrics of instrumentation
+// that cannot be changed.
+//
+// The filter is applied before any other transformation of the exported
+// data. The metric data held by the SDK is not modified, only the exported
+// data.
+func WithMetricNameFilter(keep func(name string) bool) Option {
+	return wrappedOption{oconf.WithMetricNameFilter(keep)}
+}
+
+// WithResourceAttributeFilter sets a function that reports if a resource
+// attribute is exported. The resource attributes keep returns false for are
+// dropped from the exported data. This can be used to avoid sending internal
+// attributes to a receiving endpoint.
+//
+// The filter is applied after any other transformation of the exported data.
+// The resource held by the SDK is not modified, only the exported data.
+func WithResourceAttributeFilter(keep func(attribute.KeyValue) bool) Option {
+	return wrappedOption{oconf.WithResourceAttributeFilter(keep)}
+}
+
+// WithCardinalityLimit limits the number of distinct attribute sets the
+// Exporter exports for each metric to n. The data points of the first n
+// attribute sets seen for a metric are exported as is. The data points of
+// any other attribute set are aggregated into a single overflow data point
+// with the otel.metric.overflow=true attribute instead of being dropped:
+// gauge values are replaced by the latest value, sum values are added, and
+// histogram buckets are merged.
+//
+// The limit is applied to the metric data being exported, after it has been
+// aggregated by the SDK. It bounds the series sent to the receiving endpoint,
+// not the memory used by the SDK to aggregate measurements.
+//
+// If the OTEL_EXPORTER_OTLP_METRICS_CARDINALITY_LIMIT environment variable is
+// set to a positive integer, and this option is not passed, that value will
+// be used.
+//
+// By default, if the environment variable is not set, and this option is not
+// passed, or n is not positive, the number of data points is not limited.
+func WithCardinalityLimit(n int) Option {
+	return wrappedOption{oconf.WithCardinalityLimit(n)}
+}
+
+// WithMonotonicViolationPolicy sets how the Exporter handles a cumulative
+// monotonic sum data point with a value less than the value it last exported
+// for the same series. Such a decrease is usually caused by a faulty
+// instrument and is rejected by some backends. Regardless of policy, a
+// warning is logged the first time a series decreases, and again only after
+// the series has increased or been reset. Decreases are not reported in any other way.
+//
+// A data point with a different start time than the one last exported for
+// the series is a reset of the series, not a decrease.
+//
+// By default, if this option is not passed, the PassThrough policy is used
+// and the last exported values are not tracked.
+func WithMonotonicViolationPolicy(policy MonotonicViolationPolicy) Option {
+	return wrappedOption{oconf.WithMonotonicViolationPolicy(ominternal.MonotonicViolationPolicy(policy))}
+}
+
+// WithMaxInFlightBytes limits the serialized size of all exports in flight
+// to n bytes. This bounds the memory held by exports waiting on a slow
+// endpoint. When an export would exceed the limit, policy determines whether
+// the export waits for in-flight exports to complete or is dropped with an
+// error. An export larger than n is still attempted if no other export is in
+// flight.
+//
+// By default, if this option is not passed, or n is not positive, the bytes
+// in flight are not limited.
+func WithMaxInFlightBytes(n int64, policy BackpressurePolicy) Option {
+	return wrappedOption{oconf.WithMaxInFlightBytes(n, oconf.BackpressurePolicy(policy))}
+}
+
+// WithMaxPayloadBytes limits the serialized size of a single export request
+// to n bytes. Exports larger than n are split into multiple requests that are
+// sent in order, each containing the resource and the scopes of the exported
+// metric data. A data point that exceeds n bytes on its own is dropped and an
+// error is reported to the global error handler.
+//
+// By default, if this option is not passed, or n is not positive, exports
+// are not split.
+func WithMaxPayloadBytes(n int) Option {
+	return wrappedOption{oconf.WithMaxPayloadBytes(n)}
+}
+
+// WithMaxConcurrentExports allows the Exporter to have up to n exports in
+// flight and to send their requests concurrently. An export that would exceed
+// the limit waits for another export to complete, or for its context to be
+// done in which case the context error is returned.
+//
+// By default, if this option is not passed, or n is not positive, the number
+// of exports in flight is not limited, but their requests are sent one at a
+// time.
+func WithMaxConcurrentExports(n int) Option {
+	return wrappedOption{oconf.WithMaxConcurrentExports(n)}
+}
+
+// WithSelfMetricsPrefix sets the prefix of the names of the metrics the
+// Exporter records about itself. This can be used to avoid these names
+// colliding with application metric names when they are exported through the
+// same pipeline.
+//
+// The prefix must start with a letter and only contain letters, digits, '_',
+// '.', or '-'. If an invalid prefix is passed, an error is logged and the
+// prefix is not changed.
+//
+// The self-metrics are only recorded if they are enabled with
+// WithSelfObservability.
+//
+// If this option is not used, the prefix "otel.exporter." is used.
+func WithSelfMetricsPrefix(prefix string) Option {
+	return wrappedOption{oconf.WithSelfMetricsPrefix(prefix)}
+}
+
+// WithSelfObservability makes the Exporter record metrics about the exports
+// it makes with mp. The Exporter records the number of export attempts, the
+// number of successful exports, the number of failed exports by the reason
+// they failed, and the duration of exports.
+//
+// The mp should not be the MeterProvider whose metric data is exported by the
+// Exporter. Otherwise, every export records measurements that are themselves
+// exported by the next export. Use a separate MeterProvider instead.
+//
+// If this option is not used, or mp is nil, no self-metrics are recorded and
+// exports are not instrumented.
+func WithSelfObservability(mp otelmetric.MeterProvider) Option {
+	return wrappedOption{oconf.WithSelfObservability(mp)}
+}
+
+// WithProducers adds producers of external metrics to the options. This
+// allows a single list of options to configure both the Exporter and the
+// metric.Reader it is paired with.
+//
+// The producers are not used by the Exporter. They only produce metrics if
+// they are registered with the reader the Exporter is paired with. Use
+// Producers to get them from the options and register each of them with the
+// reader using its RegisterProducer method.
+//
+// This option may be used multiple times, the producers are appended.
+func WithProducers(producers ...metric.Producer) Option {
+	return wrappedOption{oconf.WithProducers(producers...)}
+}
+
+// Producers returns the producers added to opts with WithProducers, in the
+// order they were added.
+func Producers(opts ...Option) []metric.Producer {
+	return oconf.NewGRPCConfig(asGRPCOptions(opts)...).Metrics.Producers
+}
+
 // WithTemporalitySelector sets the TemporalitySelector the client will use to
-// determine the Temporality of an instrument based on its kind. If this option
-// is not used, the client will use the DefaultTemporalitySelector from the
+// determine the Temporality of an instrument based on its kind.
+//
+// If the OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE environment
+// variable is set to "cumulative", "delta", or "lowmemory", and this option is
+// not passed, the CumulativeTemporality, DeltaTemporality, or
+// LowMemoryTemporality selector respectively will be used.
+//
+// By default, if the environment variable is not set, and this option is not
+// passed, the client will use the DefaultTemporalitySelector from the
 // go.opentelemetry.io/otel/sdk/metric package.
+//
+// A TemporalitySelector only knows the kind of an instrument, not its name,
+// so the temporality cannot be selected per instrument. To export some
+// instruments with a different temporality than the others, pair a reader
+// with an Exporter for each temporality, and use WithMetricNameFilter so that
+// each Exporter only exports the instruments that use its temporality.
 func WithTemporalitySelector(selector metric.TemporalitySelector) Option {
 	return wrappedOption{oconf.WithTemporalitySelector(selector)}
 }
 
+// CumulativeTemporality is a TemporalitySelector that selects the cumulative
+// temporality for all instrument kinds. It can be passed to
+// WithTemporalitySelector.
+func CumulativeTemporality(ik metric.InstrumentKind) metricdata.Temporality {
+	return oconf.CumulativeTemporality(ik)
+}
+
+// DeltaTemporality is a TemporalitySelector that selects the delta
+// temporality for counter, observable counter, and histogram instruments, and
+// the cumulative temporality for up-down counter, observable up-down counter,
+// and observable gauge instruments. It can be passed to
+// WithTemporalitySelector.
+func DeltaTemporality(ik metric.InstrumentKind) metricdata.Temporality {
+	return oconf.DeltaTemporality(ik)
+}
+
+// LowMemoryTemporality is a TemporalitySelector that selects the delta
+// temporality for counter and histogram instruments, and the cumulative
+// temporality for observable counter, up-down counter, observable up-down
+// counter, and observable gauge instruments. It can be passed to
+// WithTemporalitySelector.
+func LowMemoryTemporality(ik metric.InstrumentKind) metricdata.Temporality {
+	return oconf.LowMemoryTemporality(ik)
+}
+
 // WithAggregationSelector sets the AggregationSelector the client will use to
 // determine the aggregation to use for an instrument based on its kind. If
 // this option is not used, the reader will use the DefaultAggregationSelector
 // from the go.opentelemetry.io/otel/sdk/metric package, or the aggregation
 // explicitly passed for a view matching an instrument.
+//
+// If the OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION environment
+// variable is set to "explicit_bucket_histogram" the explicit bucket histogram
+// aggregation is used for histogram instruments. The
+// "base2_exponential_bucket_histogram" value is not yet supported and is
+// ignored. This option overrides the environment variable.
 func WithAggregationSelector(selector metric.AggregationSelector) Option {
 	return wrappedOption{oconf.WithAggregationSelector(selector)}
 }
+
+// WithAggregationSelectorStrict is like WithAggregationSelector, but
+// validates the aggregation selector returns for every instrument kind when
+// the Exporter is created. If any of them are invalid, New returns an error
+// describing all the invalid aggregations instead of using the default
+// aggregation in their place.
+func WithAggregationSelectorStrict(selector metric.AggregationSelector) Option {
+	return wrappedOption{oconf.WithAggregationSelectorStrict(selector)}
+}
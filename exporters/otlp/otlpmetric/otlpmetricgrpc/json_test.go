@@ -0,0 +1,132 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlpmetricgrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+)
+
+func init() {
+	// Allow the test server to decode JSON encoded requests.
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonOnlyCollector is a metric service that rejects all requests not
+// encoded as JSON.
+type jsonOnlyCollector struct {
+	colmetricpb.UnimplementedMetricsServiceServer
+
+	mu       sync.Mutex
+	rejected int
+	requests []*colmetricpb.ExportMetricsServiceRequest
+}
+
+func (c *jsonOnlyCollector) Export(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) (*colmetricpb.ExportMetricsServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ct := md.Get("content-type"); len(ct) == 0 || ct[0] != "application/grpc+json" {
+		c.rejected++
+		return nil, status.Error(codes.Unimplemented, "only JSON is supported")
+	}
+	c.requests = append(c.requests, req)
+	return &colmetricpb.ExportMetricsServiceResponse{}, nil
+}
+
+func startJSONOnlyCollector(t *testing.T) (*jsonOnlyCollector, string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	coll := &jsonOnlyCollector{}
+	srv := grpc.NewServer()
+	colmetricpb.RegisterMetricsServiceServer(srv, coll)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+	return coll, ln.Addr().String()
+}
+
+func TestGRPCJSONFallback(t *testing.T) {
+	ctx := context.Background()
+	rm := &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{{
+				Name: "requests",
+				Data: metricdata.Gauge[int64]{
+					DataPoints: []metricdata.DataPoint[int64]{{Value: 1}},
+				},
+			}},
+		}},
+	}
+
+	t.Run("Enabled", func(t *testing.T) {
+		coll, addr := startJSONOnlyCollector(t)
+		exp, err := New(
+			ctx,
+			WithEndpoint(addr),
+			WithInsecure(),
+			WithRetry(RetryConfig{Enabled: false}),
+			WithGRPCJSONFallback(),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		require.NoError(t, exp.Export(ctx, rm))
+
+		coll.mu.Lock()
+		defer coll.mu.Unlock()
+		assert.Equal(t, 1, coll.rejected, "protobuf not attempted first")
+		require.Len(t, coll.requests, 1)
+		sm := coll.requests[0].ResourceMetrics[0].ScopeMetrics
+		require.Len(t, sm, 1)
+		assert.Equal(t, "requests", sm[0].Metrics[0].Name)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		coll, addr := startJSONOnlyCollector(t)
+		exp, err := New(
+			ctx,
+			WithEndpoint(addr),
+			WithInsecure(),
+			WithRetry(RetryConfig{Enabled: false}),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		err = exp.Export(ctx, rm)
+		assert.Equal(t, codes.Unimplemented, status.Code(errors.Unwrap(err)))
+
+		coll.mu.Lock()
+		defer coll.mu.Unlock()
+		assert.Equal(t, 1, coll.rejected)
+		assert.Len(t, coll.requests, 0)
+	})
+}
@@ -18,22 +18,25 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net"
 	"net/http"
 	"net/url"
-	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/internal"
 	"go.opentelemetry.io/otel/exporters/otlp/internal/retry"
 	ominternal "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
+	"go.opentelemetry.io/otel/internal/global"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/aggregation"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
@@ -44,20 +47,89 @@ import (
 // New returns an OpenTelemetry metric Exporter. The Exporter can be used with
 // a PeriodicReader to export OpenTelemetry metric data to an OTLP receiving
 // endpoint using protobufs over HTTP.
-func New(_ context.Context, opts ...Option) (metric.Exporter, error) {
-	c, err := newClient(opts...)
+func New(ctx context.Context, opts ...Option) (metric.Exporter, error) {
+	cfg := oconf.NewHTTPConfig(asHTTPOptions(opts)...)
+	if err := cfg.Err(); err != nil {
+		return nil, err
+	}
+	c, err := newClient(cfg)
 	if err != nil {
 		return nil, err
 	}
-	return ominternal.New(c), nil
+	exp := ominternal.New(c, cfg.Metrics.ExporterOptions()...)
+	if cfg.StartupPing {
+		if err := ominternal.Ping(ctx, exp, 0); err != nil {
+			_ = exp.Shutdown(ctx)
+			return nil, err
+		}
+	}
+	return exp, nil
+}
+
+// NewNoop returns an OpenTelemetry metric Exporter that discards all metric
+// data. It does not connect to any endpoint.
+//
+// The options are validated the same way as by New, and the Exporter uses
+// the same temporality and aggregation selectors and transforms the metric
+// data the same way as one returned by New would. This allows testing a
+// metric pipeline without an OTLP receiving endpoint.
+func NewNoop(opts ...Option) (metric.Exporter, error) {
+	cfg := oconf.NewHTTPConfig(asHTTPOptions(opts)...)
+	if err := cfg.Err(); err != nil {
+		return nil, err
+	}
+	c := ominternal.NewNoopClient(cfg.Metrics.TemporalitySelector, cfg.Metrics.AggregationSelector)
+	return ominternal.New(c, cfg.Metrics.ExporterOptions()...), nil
+}
+
+// WarmupExport exports a single synthetic metric with exp to validate the
+// export pipeline end-to-end (serialization, compression, transport, and
+// retry) before real metric data is exported. Any error encountered during
+// the export is returned.
+//
+// The synthetic metric is a gauge named "otel.exporter.otlp.warmup" in the
+// "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/warmup"
+// instrumentation scope. It is not part of any real metric stream, but
+// receiving backends will store it unless configured to drop it.
+func WarmupExport(ctx context.Context, exp metric.Exporter) error {
+	return exp.Export(ctx, ominternal.WarmupResourceMetrics())
 }
 
 type client struct {
 	// req is cloned for every upload the client makes.
 	req         *http.Request
 	compression Compression
+	// gzPool holds the gzip writers used with GzipCompression.
+	gzPool      *sync.Pool
+	encoding    oconf.Encoding
+	marshalOpts proto.MarshalOptions
 	requestFunc retry.RequestFunc
 	httpClient  *http.Client
+	// headersFunc, if set, returns the headers added to each upload.
+	headersFunc func() map[string]string
+	// contextHeadersFunc, if set, returns headers for the context of an
+	// upload that override the other headers.
+	contextHeadersFunc func(context.Context) map[string]string
+	// requestEditor, if set, returns headers for the body of an upload that
+	// override all other headers.
+	requestEditor func(context.Context, map[string]string, []byte) (map[string]string, error)
+	// responseHandler, if set, is called with each response and can fail
+	// the export attempt.
+	responseHandler func(int, []byte) error
+
+	// urls are the endpoint URLs uploads are sent to.
+	urls     []*url.URL
+	rotation oconf.RotationPolicy
+	// next is the index of the URL the next round-robin upload starts with.
+	next uint64
+
+	minAttemptWindow time.Duration
+	// attemptTimeout is the max amount of time an export attempt can take.
+	attemptTimeout time.Duration
+
+	// retryableStatusFunc, if set, overrides which HTTP status codes are
+	// retried.
+	retryableStatusFunc func(int) bool
 
 	temporalitySelector metric.TemporalitySelector
 	aggregationSelector metric.AggregationSelector
@@ -81,47 +153,99 @@ var ourTransport = &http.Transport{
 }
 
 // newClient creates a new HTTP metric client.
-func newClient(opts ...Option) (ominternal.Client, error) {
-	cfg := oconf.NewHTTPConfig(asHTTPOptions(opts)...)
-
-	httpClient := &http.Client{
-		Transport: ourTransport,
-		Timeout:   cfg.Metrics.Timeout,
+func newClient(cfg oconf.Config) (ominternal.Client, error) {
+	if cfg.Metrics.FileStreamPath != "" {
+		return newFileClient(cfg)
 	}
-	if cfg.Metrics.TLSCfg != nil {
+
+	// The timeout is applied to each export attempt by the client instead
+	// of to each request by the http.Client.
+	httpClient := &http.Client{Transport: ourTransport}
+	if cfg.Metrics.HTTPClient != nil {
+		httpClient = cfg.Metrics.HTTPClient
+		if cfg.Metrics.TLSCfg != nil || cfg.Metrics.Proxy != nil {
+			global.Info("TLS and proxy configuration superseded by the HTTP client set with WithHTTPClient")
+		}
+	} else if cfg.Metrics.TLSCfg != nil || cfg.Metrics.Proxy != nil {
 		transport := ourTransport.Clone()
-		transport.TLSClientConfig = cfg.Metrics.TLSCfg
+		if cfg.Metrics.TLSCfg != nil {
+			transport.TLSClientConfig = cfg.Metrics.TLSCfg
+		}
+		if cfg.Metrics.Proxy != nil {
+			transport.Proxy = cfg.Metrics.Proxy
+		}
 		httpClient.Transport = transport
 	}
 
-	u := &url.URL{
-		Scheme: "https",
-		Host:   cfg.Metrics.Endpoint,
-		Path:   cfg.Metrics.URLPath,
+	endpoints := cfg.Metrics.Endpoints
+	if len(endpoints) == 0 {
+		endpoints = []string{cfg.Metrics.Endpoint}
 	}
-	if cfg.Metrics.Insecure {
-		u.Scheme = "http"
+	// The URL path may contain a query string.
+	urlPath, query, _ := strings.Cut(cfg.Metrics.URLPath, "?")
+	urls := make([]*url.URL, len(endpoints))
+	for i, endpoint := range endpoints {
+		u := &url.URL{
+			Scheme:   "https",
+			Host:     endpoint,
+			Path:     urlPath,
+			RawQuery: query,
+		}
+		if cfg.Metrics.Insecure {
+			u.Scheme = "http"
+		}
+		// Validate the URL is usable as a request target.
+		if _, err := http.NewRequest(http.MethodPost, u.String(), http.NoBody); err != nil {
+			return nil, err
+		}
+		urls[i] = u
 	}
 	// Body is set when this is cloned during upload.
-	req, err := http.NewRequest(http.MethodPost, u.String(), http.NoBody)
+	req, err := http.NewRequest(http.MethodPost, urls[0].String(), http.NoBody)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("User-Agent", ominternal.GetUserAgentHeader())
+	req.Header.Set("User-Agent", cfg.Metrics.UserAgent())
+	// Request gzip explicitly so responses are decompressed by
+	// readResponseBody instead of the transport. This allows a response body
+	// incorrectly claiming to be gzip encoded to still be reported.
+	req.Header.Set("Accept-Encoding", "gzip")
 
-	if n := len(cfg.Metrics.Headers); n > 0 {
+	if n := len(cfg.Metrics.Headers); n > 0 && cfg.Metrics.HeadersFunc == nil {
+		// Set canonicalizes the keys, so keys only differing in case are
+		// sent once.
 		for k, v := range cfg.Metrics.Headers {
 			req.Header.Set(k, v)
 		}
 	}
-	req.Header.Set("Content-Type", "application/x-protobuf")
+	contentType := "application/x-protobuf"
+	if cfg.Metrics.Encoding == oconf.JSONEncoding {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
 
 	return &client{
 		compression: Compression(cfg.Metrics.Compression),
+		gzPool:      gzipPool(cfg.Metrics.GzipLevel),
+		encoding:    cfg.Metrics.Encoding,
+		marshalOpts: cfg.Metrics.ProtoMarshalOptions,
 		req:         req,
-		requestFunc: cfg.RetryConfig.RequestFunc(evaluate),
+		requestFunc: cfg.RetryConfig.RequestFuncWithBackoff(cfg.RetryBackoff, evaluate),
 		httpClient:  httpClient,
+		headersFunc: cfg.Metrics.HeadersFunc,
+
+		contextHeadersFunc: cfg.Metrics.ContextHeadersFunc,
+		requestEditor:      cfg.Metrics.RequestEditor,
+		responseHandler:    cfg.Metrics.ResponseHandler,
+
+		urls:     urls,
+		rotation: cfg.Metrics.EndpointRotation,
+
+		minAttemptWindow: cfg.Metrics.MinAttemptWindow,
+		attemptTimeout:   cfg.Metrics.Timeout,
+
+		retryableStatusFunc: cfg.RetryableStatusFunc,
 
 		temporalitySelector: cfg.Metrics.TemporalitySelector,
 		aggregationSelector: cfg.Metrics.AggregationSelector,
@@ -155,24 +279,47 @@ func (c *client) Shutdown(ctx context.Context) error {
 // UploadMetrics sends protoMetrics to the connected endpoint.
 //
 // Retryable errors from the server will be handled according to any
-// RetryConfig the client was created with.
+// RetryConfig the client was created with. If the upload fails, the returned
+// error is an *ExportError.
 func (c *client) UploadMetrics(ctx context.Context, protoMetrics *metricpb.ResourceMetrics) error {
+	var statusCode int
+	err := c.uploadMetrics(ctx, protoMetrics, &statusCode)
+	if err == nil {
+		return nil
+	}
+	return &ExportError{
+		Signal:     "metrics",
+		StatusCode: statusCode,
+		Retryable:  errors.As(err, &retryableError{}),
+		Err:        err,
+	}
+}
+
+// uploadMetrics sends protoMetrics to the connected endpoint. The status code
+// of the last response received, if any, is stored in statusCode.
+func (c *client) uploadMetrics(ctx context.Context, protoMetrics *metricpb.ResourceMetrics, statusCode *int) error {
 	// The otlpmetric.Exporter synchronizes access to client methods, and
 	// ensures this is not called after the Exporter is shutdown. Only thing
 	// to do here is send data.
 
+	if err := c.checkAttemptWindow(ctx); err != nil {
+		return err
+	}
+
 	pbRequest := &colmetricpb.ExportMetricsServiceRequest{
 		ResourceMetrics: []*metricpb.ResourceMetrics{protoMetrics},
 	}
-	body, err := proto.Marshal(pbRequest)
+	body, err := c.marshal(pbRequest)
 	if err != nil {
-		return err
+		// Marshaling fails the same way every time, do not retry.
+		return fmt.Errorf("failed to marshal metrics: %w", err)
 	}
 	request, err := c.newRequest(ctx, body)
 	if err != nil {
 		return err
 	}
 
+	start := c.startIndex()
 	return c.requestFunc(ctx, func(iCtx context.Context) error {
 		select {
 		case <-iCtx.Done():
@@ -180,9 +327,19 @@ func (c *client) UploadMetrics(ctx context.Context, protoMetrics *metricpb.Resou
 		default:
 		}
 
-		request.reset(iCtx)
-		resp, err := c.httpClient.Do(request.Request)
+		aCtx, cancel := c.attemptContext(iCtx)
+		defer cancel()
+
+		resp, err := c.send(aCtx, &request, start)
 		if err != nil {
+			if errors.Is(aCtx.Err(), context.DeadlineExceeded) && iCtx.Err() == nil {
+				// Only this attempt timed out, try again.
+				return retryableError{err: err}
+			}
+			return err
+		}
+		*statusCode = resp.StatusCode
+		if err := c.handleResponse(resp); err != nil {
 			return err
 		}
 
@@ -192,29 +349,34 @@ func (c *client) UploadMetrics(ctx context.Context, protoMetrics *metricpb.Resou
 			// Success, do not retry.
 
 			// Read the partial success message, if any.
-			var respData bytes.Buffer
-			if _, err := io.Copy(&respData, resp.Body); err != nil {
+			respData, err := readResponseBody(resp)
+			if err != nil {
 				return err
 			}
 
-			if respData.Len() != 0 {
+			if len(respData) != 0 {
 				var respProto colmetricpb.ExportMetricsServiceResponse
-				if err := proto.Unmarshal(respData.Bytes(), &respProto); err != nil {
+				if err := c.unmarshal(respData, &respProto); err != nil {
+					// The export succeeded, only the response is unknown.
+					global.Error(err, "failed to parse export response")
+					return nil
+				}
+				ominternal.HandlePartialSuccess(respProto.GetPartialSuccess())
+			}
+			return nil
+		default:
+			if !c.retryableStatus(resp.StatusCode) {
+				rErr = fmt.Errorf("failed to send metrics to %s: %s", request.URL, resp.Status)
+				respData, err := readResponseBody(resp)
+				if err != nil {
+					_ = resp.Body.Close()
 					return err
 				}
-
-				if respProto.PartialSuccess != nil {
-					msg := respProto.PartialSuccess.GetErrorMessage()
-					n := respProto.PartialSuccess.GetRejectedDataPoints()
-					if n != 0 || msg != "" {
-						err := internal.MetricPartialSuccessError(n, msg)
-						otel.Handle(err)
-					}
+				if msg := strings.TrimSpace(string(respData)); msg != "" {
+					rErr = fmt.Errorf("%w: %s", rErr, msg)
 				}
+				break
 			}
-			return nil
-		case http.StatusTooManyRequests,
-			http.StatusServiceUnavailable:
 			// Retry-able failure.
 			rErr = newResponseError(resp.Header)
 
@@ -223,8 +385,6 @@ func (c *client) UploadMetrics(ctx context.Context, protoMetrics *metricpb.Resou
 				_ = resp.Body.Close()
 				return err
 			}
-		default:
-			rErr = fmt.Errorf("failed to send metrics to %s: %s", request.URL, resp.Status)
 		}
 
 		if err := resp.Body.Close(); err != nil {
@@ -234,6 +394,154 @@ func (c *client) UploadMetrics(ctx context.Context, protoMetrics *metricpb.Resou
 	})
 }
 
+// handleResponse calls the response handler of c, if any, with resp. The
+// body of resp is read and replaced with its decompressed copy, so it can
+// still be read. If the handler returns an error, resp is closed and the
+// error is returned.
+func (c *client) handleResponse(resp *http.Response) error {
+	if c.responseHandler == nil {
+		return nil
+	}
+	body, err := readResponseBody(resp)
+	_ = resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.Header.Del("Content-Encoding")
+
+	err = c.responseHandler(resp.StatusCode, body)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrRetryableResponse) {
+		rErr := newResponseError(resp.Header)
+		rErr.err = err
+		return rErr
+	}
+	return err
+}
+
+// readResponseBody returns the body of resp. If the body is gzip encoded, it
+// is decompressed. A body that claims to be gzip encoded but cannot be
+// decompressed is returned as is.
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, resp.Body); err != nil {
+		return nil, err
+	}
+	if buf.Len() == 0 || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return buf.Bytes(), nil
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	if err == nil {
+		var decoded bytes.Buffer
+		if _, err = io.Copy(&decoded, gz); err == nil {
+			return decoded.Bytes(), nil
+		}
+	}
+	global.Error(err, "failed to decompress gzip encoded response body, using raw body")
+	return buf.Bytes(), nil
+}
+
+// retryableStatus returns if a request that failed with the HTTP status code
+// is retried.
+func (c *client) retryableStatus(code int) bool {
+	if c.retryableStatusFunc != nil {
+		return c.retryableStatusFunc(code)
+	}
+	switch code {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	}
+	return false
+}
+
+// marshal encodes m using the encoding of c.
+func (c *client) marshal(m proto.Message) ([]byte, error) {
+	if c.encoding == oconf.JSONEncoding {
+		return protojson.Marshal(m)
+	}
+	return c.marshalOpts.Marshal(m)
+}
+
+// unmarshal decodes b into m using the encoding of c.
+func (c *client) unmarshal(b []byte, m proto.Message) error {
+	if c.encoding == oconf.JSONEncoding {
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, m)
+	}
+	return proto.Unmarshal(b, m)
+}
+
+// attemptContext returns a copy of parent with a deadline based on the
+// clients configured timeout for each export attempt.
+func (c *client) attemptContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if c.attemptTimeout > 0 {
+		return context.WithTimeout(parent, c.attemptTimeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// startIndex returns the index of the URL an upload is first sent to.
+func (c *client) startIndex() int {
+	n := len(c.urls)
+	if n < 2 {
+		return 0
+	}
+	switch c.rotation {
+	case oconf.RoundRobinRotation:
+		return int((atomic.AddUint64(&c.next, 1) - 1) % uint64(n))
+	case oconf.RandomRotation:
+		return rand.Intn(n) // nolint: gosec  // Load distribution only.
+	default:
+		return 0
+	}
+}
+
+// send sends request to the endpoint URL at the start index. If that endpoint
+// cannot be reached, the request is sent to the following endpoints in order
+// until one responds. The error from the last endpoint is returned if none
+// of them respond.
+func (c *client) send(ctx context.Context, request *request, start int) (*http.Response, error) {
+	var err error
+	for i := 0; i < len(c.urls); i++ {
+		u := c.urls[(start+i)%len(c.urls)]
+		request.reset(ctx)
+		request.URL = u
+		request.Host = u.Host
+
+		var resp *http.Response
+		resp, err = c.httpClient.Do(request.Request)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			// Do not try other endpoints if the request was canceled.
+			break
+		}
+	}
+	return nil, err
+}
+
+// errInsufficientTime is returned when an export is not attempted because
+// not enough time remains before the deadline of the export context.
+var errInsufficientTime = errors.New("insufficient time remaining to attempt export")
+
+// checkAttemptWindow returns an error if less than the configured minimum
+// attempt window remains before the deadline of ctx.
+func (c *client) checkAttemptWindow(ctx context.Context) error {
+	if c.minAttemptWindow <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < c.minAttemptWindow {
+			return fmt.Errorf("%w: %s remaining, %s required", errInsufficientTime, remaining, c.minAttemptWindow)
+		}
+	}
+	return nil
+}
+
 var gzPool = sync.Pool{
 	New: func() interface{} {
 		w := gzip.NewWriter(io.Discard)
@@ -241,21 +549,46 @@ var gzPool = sync.Pool{
 	},
 }
 
+// gzipPool returns a pool of gzip writers compressing at level. If level is
+// zero, the shared pool of writers using the default level is returned.
+func gzipPool(level int) *sync.Pool {
+	if level == 0 {
+		return &gzPool
+	}
+	return &sync.Pool{
+		New: func() interface{} {
+			// The level is validated when the option is applied.
+			w, _ := gzip.NewWriterLevel(io.Discard, level)
+			return w
+		},
+	}
+}
+
 func (c *client) newRequest(ctx context.Context, body []byte) (request, error) {
 	r := c.req.Clone(ctx)
 	req := request{Request: r}
 
+	if c.headersFunc != nil {
+		for k, v := range c.headersFunc() {
+			r.Header.Set(k, v)
+		}
+	}
+	if c.contextHeadersFunc != nil {
+		for k, v := range c.contextHeadersFunc(ctx) {
+			r.Header.Set(k, v)
+		}
+	}
+
 	switch c.compression {
 	case NoCompression:
 		r.ContentLength = (int64)(len(body))
-		req.bodyReader = bodyReader(body)
 	case GzipCompression:
 		// Ensure the content length is not used.
 		r.ContentLength = -1
 		r.Header.Set("Content-Encoding", "gzip")
 
-		gz := gzPool.Get().(*gzip.Writer)
-		defer gzPool.Put(gz)
+		gz := c.gzPool.Get().(*gzip.Writer)
+		defer c.gzPool.Put(gz)
 
 		var b bytes.Buffer
 		gz.Reset(&b)
@@ -267,9 +600,23 @@ func (c *client) newRequest(ctx context.Context, body []byte) (request, error) {
 		if err := gz.Close(); err != nil {
 			return req, err
 		}
+		body = b.Bytes()
+	}
 
-		req.bodyReader = bodyReader(b.Bytes())
+	if c.requestEditor != nil {
+		headers := make(map[string]string, len(r.Header))
+		for k := range r.Header {
+			headers[k] = r.Header.Get(k)
+		}
+		edited, err := c.requestEditor(ctx, headers, body)
+		if err != nil {
+			return req, fmt.Errorf("request editor: %w", err)
+		}
+		for k, v := range edited {
+			r.Header.Set(k, v)
+		}
 	}
+	req.bodyReader = bodyReader(body)
 
 	return req, nil
 }
@@ -295,17 +642,47 @@ func (r *request) reset(ctx context.Context) {
 	r.Request = r.Request.WithContext(ctx)
 }
 
+// ExportError is the error returned when the Exporter fails to upload
+// metric data to the endpoint. Use errors.As to retrieve it from the error
+// returned by Export.
+//
+// Whether the export failed because the endpoint could not be reached, the
+// endpoint rejected the data, or the context of the export was canceled can
+// be determined from its fields and, for the latter, with errors.Is.
+type ExportError struct {
+	// Signal is the telemetry signal of the failed export, "metrics".
+	Signal string
+	// StatusCode is the HTTP status code of the last response received from
+	// the endpoint. It is zero if no response was received, like when the
+	// endpoint could not be reached or the context of the export is done.
+	StatusCode int
+	// Retryable is true if the export failed in a way that is retried, like
+	// with a retry-able status code or an attempt timeout. If retries are
+	// enabled the export was retried until the retries were exhausted.
+	Retryable bool
+	// Err is the cause of the failure.
+	Err error
+}
+
+// Error returns the message of the cause of e.
+func (e *ExportError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the cause of e.
+func (e *ExportError) Unwrap() error { return e.Err }
+
 // retryableError represents a request failure that can be retried.
 type retryableError struct {
-	throttle int64
+	throttle time.Duration
+	// err is the cause of the failure, if any.
+	err error
 }
 
 // newResponseError returns a retryableError and will extract any explicit
 // throttle delay contained in headers.
-func newResponseError(header http.Header) error {
+func newResponseError(header http.Header) retryableError {
 	var rErr retryableError
 	if v := header.Get("Retry-After"); v != "" {
-		if t, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if t, ok := retry.ParseRetryAfter(v); ok {
 			rErr.throttle = t
 		}
 	}
@@ -313,9 +690,16 @@ func newResponseError(header http.Header) error {
 }
 
 func (e retryableError) Error() string {
+	if e.err != nil {
+		return "retry-able request failure: " + e.err.Error()
+	}
 	return "retry-able request failure"
 }
 
+func (e retryableError) Unwrap() error {
+	return e.err
+}
+
 // evaluate returns if err is retry-able. If it is and it includes an explicit
 // throttling delay, that delay is also returned.
 func evaluate(err error) (bool, time.Duration) {
@@ -328,5 +712,5 @@ func evaluate(err error) (bool, time.Duration) {
 		return false, 0
 	}
 
-	return true, time.Duration(rErr.throttle)
+	return true, rErr.throttle
 }
@@ -15,22 +15,44 @@
 package otlpmetrichttp
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	ominternal "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/otest"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	mpb "go.opentelemetry.io/proto/otlp/metrics/v1"
 )
 
 func TestClient(t *testing.T) {
@@ -39,7 +61,10 @@ func TestClient(t *testing.T) {
 		require.NoError(t, err)
 
 		addr := coll.Addr().String()
-		client, err := newClient(WithEndpoint(addr), WithInsecure())
+		client, err := newClient(oconf.NewHTTPConfig(asHTTPOptions([]Option{
+			WithEndpoint(addr),
+			WithInsecure(),
+		})...))
 		require.NoError(t, err)
 		return client, coll
 	}
@@ -80,6 +105,126 @@ func TestConfig(t *testing.T) {
 		assert.Equal(t, got[key], []string{headers[key]})
 	})
 
+	t.Run("WithMixedCaseHeaders", func(t *testing.T) {
+		exp, coll := factoryFunc(
+			"",
+			nil,
+			WithHeaders(map[string]string{"x-scope-orgid": "team-a"}),
+			WithHeader("X-Scope-OrgID", "team-b"),
+		)
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Headers()
+		assert.Equal(t, []string{"team-b"}, got["X-Scope-Orgid"])
+	})
+
+	t.Run("WithHeadersFunc", func(t *testing.T) {
+		key := http.CanonicalHeaderKey("authorization")
+		var n atomic.Int64
+		fn := func() map[string]string {
+			return map[string]string{key: fmt.Sprintf("token-%d", n.Add(1))}
+		}
+		static := http.CanonicalHeaderKey("static")
+		exp, coll := factoryFunc("", nil, WithHeaders(map[string]string{static: "v"}), WithHeadersFunc(fn))
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Headers()
+		assert.Equal(t, []string{"token-1", "token-2"}, got[key])
+		assert.NotContains(t, got, static, "static headers sent with HeadersFunc")
+	})
+
+	t.Run("WithContextHeaders", func(t *testing.T) {
+		type tenantKey struct{}
+		fn := func(ctx context.Context) map[string]string {
+			return map[string]string{"X-Scope-OrgID": ctx.Value(tenantKey{}).(string)}
+		}
+		exp, coll := factoryFunc(
+			"",
+			nil,
+			WithHeaders(map[string]string{"X-Scope-OrgID": "static", "Static": "v"}),
+			WithContextHeaders(fn),
+		)
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+
+		tenants := []string{"team-a", "team-b", "team-c"}
+		var wg sync.WaitGroup
+		for _, tenant := range tenants {
+			wg.Add(1)
+			go func(tenant string) {
+				defer wg.Done()
+				tCtx := context.WithValue(ctx, tenantKey{}, tenant)
+				assert.NoError(t, exp.Export(tCtx, &metricdata.ResourceMetrics{}))
+			}(tenant)
+		}
+		wg.Wait()
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Headers()
+		assert.ElementsMatch(t, tenants, got["X-Scope-Orgid"])
+		assert.Equal(t, []string{"v", "v", "v"}, got["Static"])
+	})
+
+	t.Run("WithMetricNameFilter", func(t *testing.T) {
+		keep := func(name string) bool { return name != "noisy" }
+		exp, coll := factoryFunc("", nil, WithMetricNameFilter(keep))
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		gauge := func(name string) metricdata.Metrics {
+			return metricdata.Metrics{
+				Name: name,
+				Data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{{Value: 1}}},
+			}
+		}
+		rm := &metricdata.ResourceMetrics{
+			ScopeMetrics: []metricdata.ScopeMetrics{
+				{
+					Scope:   instrumentation.Scope{Name: "app"},
+					Metrics: []metricdata.Metrics{gauge("noisy"), gauge("useful")},
+				},
+				{
+					Scope:   instrumentation.Scope{Name: "library"},
+					Metrics: []metricdata.Metrics{gauge("noisy")},
+				},
+			},
+		}
+		require.NoError(t, exp.Export(ctx, rm))
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Collect().Dump()
+		require.Len(t, got, 1)
+		require.Len(t, got[0].ScopeMetrics, 1, "filtered scope not removed")
+		sm := got[0].ScopeMetrics[0]
+		assert.Equal(t, "app", sm.Scope.Name)
+		require.Len(t, sm.Metrics, 1)
+		assert.Equal(t, "useful", sm.Metrics[0].Name)
+	})
+
+	t.Run("WithResourceAttributeFilter", func(t *testing.T) {
+		podUID := attribute.String("k8s.pod.uid", "1234")
+		service := attribute.String("service.name", "test")
+		res := resource.NewSchemaless(podUID, service)
+		keep := func(kv attribute.KeyValue) bool { return kv.Key != podUID.Key }
+		exp, coll := factoryFunc("", nil, WithResourceAttributeFilter(keep))
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{Resource: res}))
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Collect().Dump()
+		require.Len(t, got, 1)
+		require.Len(t, got[0].Resource.Attributes, 1)
+		assert.Equal(t, string(service.Key), got[0].Resource.Attributes[0].Key)
+		assert.Equal(t, 2, res.Len(), "SDK resource modified")
+	})
+
 	t.Run("WithTimeout", func(t *testing.T) {
 		// Do not send on rCh so the Collector never responds to the client.
 		rCh := make(chan otest.ExportResult)
@@ -137,6 +282,69 @@ func TestConfig(t *testing.T) {
 		assert.Len(t, rCh, 0, "failed HTTP responses did not occur")
 	})
 
+	t.Run("WithRetryDisabled", func(t *testing.T) {
+		rCh := make(chan otest.ExportResult, 2)
+		rCh <- otest.ExportResult{Err: &otest.HTTPResponseError{
+			Status: http.StatusServiceUnavailable,
+			Err:    errors.New("unavailable"),
+		}}
+		rCh <- otest.ExportResult{}
+		exp, coll := factoryFunc("", rCh, WithRetryDisabled())
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		// Push this after Shutdown so the HTTP server doesn't hang.
+		t.Cleanup(func() { close(rCh) })
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		assert.Error(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		assert.Len(t, rCh, 1, "export retried")
+	})
+
+	t.Run("WithRetryMaxElapsedTime", func(t *testing.T) {
+		const n = 100
+		rCh := make(chan otest.ExportResult, n)
+		for i := 0; i < n; i++ {
+			rCh <- otest.ExportResult{Err: &otest.HTTPResponseError{
+				Status: http.StatusServiceUnavailable,
+				Err:    errors.New("unavailable"),
+			}}
+		}
+		maxElapsed := 50 * time.Millisecond
+		exp, coll := factoryFunc("", rCh, WithRetry(RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     5 * time.Millisecond,
+			MaxElapsedTime:  maxElapsed,
+		}))
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		t.Cleanup(func() { close(rCh) })
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		start := time.Now()
+		err := exp.Export(ctx, &metricdata.ResourceMetrics{})
+		assert.ErrorContains(t, err, "max retry time elapsed")
+		assert.Less(t, time.Since(start), maxElapsed+time.Second, "retries not bounded")
+		assert.Less(t, len(rCh), n-1, "request not retried")
+	})
+
+	t.Run("WithMinAttemptWindow", func(t *testing.T) {
+		exp, coll := factoryFunc("", nil, WithMinAttemptWindow(time.Minute))
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		short, cancel := context.WithTimeout(ctx, time.Second)
+		t.Cleanup(cancel)
+		err := exp.Export(short, &metricdata.ResourceMetrics{})
+		assert.ErrorIs(t, err, errInsufficientTime)
+		assert.Len(t, coll.Collect().Dump(), 0)
+
+		long, cancel := context.WithTimeout(ctx, time.Hour)
+		t.Cleanup(cancel)
+		assert.NoError(t, exp.Export(long, &metricdata.ResourceMetrics{}))
+		assert.Len(t, coll.Collect().Dump(), 1)
+	})
+
 	t.Run("WithURLPath", func(t *testing.T) {
 		path := "/prefix/v2/metrics"
 		ePt := fmt.Sprintf("http://localhost:0%s", path)
@@ -170,6 +378,67 @@ func TestConfig(t *testing.T) {
 		assert.Len(t, coll.Collect().Dump(), 1)
 	})
 
+	t.Run("WithProxy", func(t *testing.T) {
+		var called bool
+		proxy := func(*http.Request) (*url.URL, error) {
+			called = true
+			// Do not proxy the request.
+			return nil, nil
+		}
+		exp, coll := factoryFunc("", nil, WithProxy(proxy))
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		assert.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		assert.Len(t, coll.Collect().Dump(), 1)
+		assert.True(t, called, "proxy function not called")
+	})
+
+	t.Run("WithHTTPClient", func(t *testing.T) {
+		var proxyCalled bool
+		proxy := func(*http.Request) (*url.URL, error) {
+			proxyCalled = true
+			return nil, nil
+		}
+		var n atomic.Int64
+		c := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			n.Add(1)
+			return http.DefaultTransport.RoundTrip(r)
+		})}
+		// The client takes precedence regardless of the option order.
+		exp, coll := factoryFunc("", nil, WithHTTPClient(c), WithProxy(proxy))
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		assert.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		assert.Len(t, coll.Collect().Dump(), 1)
+		assert.Equal(t, int64(1), n.Load(), "HTTP client not used")
+		assert.False(t, proxyCalled, "proxy used with HTTP client")
+	})
+
+	t.Run("WithProxyError", func(t *testing.T) {
+		proxyErr := errors.New("proxy error")
+		proxy := func(*http.Request) (*url.URL, error) { return nil, proxyErr }
+		exp, coll := factoryFunc("", nil, WithProxy(proxy), WithRetry(RetryConfig{Enabled: false}))
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		assert.ErrorIs(t, exp.Export(ctx, &metricdata.ResourceMetrics{}), proxyErr)
+	})
+
+	t.Run("WithUserAgent", func(t *testing.T) {
+		exp, coll := factoryFunc("", nil, WithUserAgent("myapp/1.2.3"))
+		ctx := context.Background()
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		// Ensure everything is flushed.
+		require.NoError(t, exp.Shutdown(ctx))
+
+		got := coll.Headers()[http.CanonicalHeaderKey("user-agent")]
+		require.Len(t, got, 1)
+		assert.Regexp(t, "^OTel OTLP Exporter Go/[01]\\..* myapp/1\\.2\\.3$", got[0])
+	})
+
 	t.Run("WithCustomUserAgent", func(t *testing.T) {
 		key := http.CanonicalHeaderKey("user-agent")
 		headers := map[string]string{key: "custom-user-agent"}
@@ -185,3 +454,974 @@ func TestConfig(t *testing.T) {
 		assert.Equal(t, got[key], []string{headers[key]})
 	})
 }
+
+func TestEndpointRotation(t *testing.T) {
+	newCollector := func(t *testing.T) *otest.HTTPCollector {
+		coll, err := otest.NewHTTPCollector("", nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(context.Background())) })
+		return coll
+	}
+
+	// downEndpoint returns the address of an endpoint that is not listening.
+	downEndpoint := func(t *testing.T) string {
+		l, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+		require.NoError(t, l.Close())
+		return addr
+	}
+
+	export := func(t *testing.T, n int, opts ...Option) {
+		ctx := context.Background()
+		opts = append(opts, WithInsecure(), WithRetry(RetryConfig{Enabled: false}))
+		exp, err := New(ctx, opts...)
+		require.NoError(t, err)
+		for i := 0; i < n; i++ {
+			require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		}
+		require.NoError(t, exp.Shutdown(ctx))
+	}
+
+	t.Run("NoRotation", func(t *testing.T) {
+		c0, c1 := newCollector(t), newCollector(t)
+		export(t, 4, WithEndpoints(c0.Addr().String(), c1.Addr().String()))
+		assert.Len(t, c0.Collect().Dump(), 4)
+		assert.Len(t, c1.Collect().Dump(), 0)
+	})
+
+	t.Run("RoundRobin", func(t *testing.T) {
+		c0, c1 := newCollector(t), newCollector(t)
+		export(t, 4,
+			WithEndpoints(c0.Addr().String(), c1.Addr().String()),
+			WithEndpointRotation(RoundRobin),
+		)
+		assert.Len(t, c0.Collect().Dump(), 2)
+		assert.Len(t, c1.Collect().Dump(), 2)
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		c0, c1 := newCollector(t), newCollector(t)
+		const n = 64
+		export(t, n,
+			WithEndpoints(c0.Addr().String(), c1.Addr().String()),
+			WithEndpointRotation(Random),
+		)
+		n0, n1 := len(c0.Collect().Dump()), len(c1.Collect().Dump())
+		assert.Equal(t, n, n0+n1)
+		assert.NotZero(t, n0, "no exports sent to first endpoint")
+		assert.NotZero(t, n1, "no exports sent to second endpoint")
+	})
+
+	t.Run("Failover", func(t *testing.T) {
+		for _, policy := range []RotationPolicy{NoRotation, RoundRobin, Random} {
+			coll := newCollector(t)
+			export(t, 4,
+				WithEndpoints(downEndpoint(t), coll.Addr().String()),
+				WithEndpointRotation(policy),
+			)
+			assert.Len(t, coll.Collect().Dump(), 4)
+		}
+	})
+
+	t.Run("AllDown", func(t *testing.T) {
+		ctx := context.Background()
+		exp, err := New(ctx,
+			WithEndpoints(downEndpoint(t), downEndpoint(t)),
+			WithInsecure(),
+			WithRetry(RetryConfig{Enabled: false}),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		assert.Error(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	})
+}
+
+func TestWarmupExport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		coll, err := otest.NewHTTPCollector("", nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		exp, err := New(ctx, WithEndpoint(coll.Addr().String()), WithInsecure())
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		require.NoError(t, WarmupExport(ctx, exp))
+		got := coll.Collect().Dump()
+		require.Len(t, got, 1)
+		require.Len(t, got[0].ScopeMetrics, 1)
+		sm := got[0].ScopeMetrics[0]
+		assert.Equal(t, ominternal.WarmupScopeName, sm.Scope.Name)
+		require.Len(t, sm.Metrics, 1)
+		assert.Equal(t, ominternal.WarmupMetricName, sm.Metrics[0].Name)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		rCh := make(chan otest.ExportResult, 1)
+		rCh <- otest.ExportResult{Err: &otest.HTTPResponseError{
+			Status: http.StatusUnauthorized,
+			Err:    errors.New("unauthorized"),
+		}}
+		coll, err := otest.NewHTTPCollector("", rCh)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, coll.Shutdown(ctx)) })
+		exp, err := New(ctx, WithEndpoint(coll.Addr().String()), WithInsecure())
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+		assert.ErrorContains(t, WarmupExport(ctx, exp), http.StatusText(http.StatusUnauthorized))
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "3")
+	retryable, throttle := evaluate(newResponseError(header))
+	assert.True(t, retryable)
+	assert.Equal(t, 3*time.Second, throttle)
+
+	header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	retryable, throttle = evaluate(newResponseError(header))
+	assert.True(t, retryable)
+	assert.InDelta(t, time.Minute, throttle, float64(2*time.Second))
+
+	header.Set("Retry-After", "invalid")
+	retryable, throttle = evaluate(newResponseError(header))
+	assert.True(t, retryable)
+	assert.Equal(t, time.Duration(0), throttle)
+}
+
+func TestURLPathQuery(t *testing.T) {
+	cfg := oconf.NewHTTPConfig(asHTTPOptions([]Option{
+		WithEndpoint("localhost:4318"),
+		WithURLPath("/v1//metrics?tenant=a"),
+	})...)
+	c, err := newClient(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "https://localhost:4318/v1/metrics?tenant=a", c.(*client).urls[0].String())
+}
+
+func TestNewAggregationSelectorStrictError(t *testing.T) {
+	invalid := func(metric.InstrumentKind) aggregation.Aggregation {
+		return aggregation.ExplicitBucketHistogram{Boundaries: []float64{2, 1}}
+	}
+	_, err := New(context.Background(), WithAggregationSelectorStrict(invalid))
+	assert.ErrorContains(t, err, "invalid aggregation selected")
+}
+
+func TestTimeoutPerAttempt(t *testing.T) {
+	var attempts atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if attempts.Add(1) == 1 {
+			// Slow first attempt, respond only after the client gave up.
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	exp, err := New(
+		ctx,
+		WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithInsecure(),
+		WithTimeout(100*time.Millisecond),
+		WithRetry(RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Nanosecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Minute,
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	assert.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	assert.Equal(t, int64(2), attempts.Load())
+}
+
+func TestCanceledExport(t *testing.T) {
+	var attempts atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		attempts.Add(1)
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	exp, err := New(
+		context.Background(),
+		WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithInsecure(),
+		WithTimeout(time.Hour),
+		WithRetry(RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Nanosecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Hour,
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(context.Background())) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for attempts.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	err = exp.Export(ctx, &metricdata.ResourceMetrics{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int64(1), attempts.Load(), "canceled export retried")
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestUnparsableSuccessResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("not a protobuf message"))
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	exp, err := New(ctx, WithEndpoint(strings.TrimPrefix(srv.URL, "http://")), WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	assert.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+}
+
+func TestHTTPEncoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		contentType string
+		unmarshal   func([]byte, proto.Message) error
+	}{
+		{
+			name:        "Default",
+			contentType: "application/x-protobuf",
+			unmarshal:   proto.Unmarshal,
+		},
+		{
+			name:        "Protobuf",
+			opts:        []Option{WithHTTPEncoding(ProtobufEncoding)},
+			contentType: "application/x-protobuf",
+			unmarshal:   proto.Unmarshal,
+		},
+		{
+			name:        "JSON",
+			opts:        []Option{WithHTTPEncoding(JSONEncoding)},
+			contentType: "application/json",
+			unmarshal:   protojson.Unmarshal,
+		},
+	}
+
+	rm := &metricdata.ResourceMetrics{
+		Resource: resource.NewSchemaless(attribute.String("service.name", "test")),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotType string
+				gotReq  colmetricpb.ExportMetricsServiceRequest
+				gotErr  error
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotType = r.Header.Get("Content-Type")
+				b, err := io.ReadAll(r.Body)
+				if err == nil {
+					err = tt.unmarshal(b, &gotReq)
+				}
+				gotErr = err
+				w.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(srv.Close)
+
+			ctx := context.Background()
+			opts := append([]Option{
+				WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+				WithInsecure(),
+			}, tt.opts...)
+			exp, err := New(ctx, opts...)
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+			require.NoError(t, exp.Export(ctx, rm))
+			assert.Equal(t, tt.contentType, gotType)
+			require.NoError(t, gotErr, "body not encoded as %s", tt.contentType)
+			require.Len(t, gotReq.ResourceMetrics, 1)
+			attrs := gotReq.ResourceMetrics[0].GetResource().GetAttributes()
+			require.Len(t, attrs, 1)
+			assert.Equal(t, "service.name", attrs[0].Key)
+		})
+	}
+}
+
+func TestGzipLevel(t *testing.T) {
+	for _, level := range []int{gzip.BestSpeed, gzip.BestCompression} {
+		t.Run(strconv.Itoa(level), func(t *testing.T) {
+			var (
+				gotReq colmetricpb.ExportMetricsServiceRequest
+				gotErr error
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gz, err := gzip.NewReader(r.Body)
+				if err == nil {
+					var b []byte
+					b, err = io.ReadAll(gz)
+					if err == nil {
+						err = proto.Unmarshal(b, &gotReq)
+					}
+				}
+				gotErr = err
+				w.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(srv.Close)
+
+			ctx := context.Background()
+			exp, err := New(
+				ctx,
+				WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+				WithInsecure(),
+				WithCompression(GzipCompression),
+				WithGzipLevel(level),
+			)
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+			rm := &metricdata.ResourceMetrics{
+				Resource: resource.NewSchemaless(attribute.String("service.name", "test")),
+			}
+			require.NoError(t, exp.Export(ctx, rm))
+			require.NoError(t, gotErr)
+			require.Len(t, gotReq.ResourceMetrics, 1)
+			assert.Equal(t, "service.name", gotReq.ResourceMetrics[0].Resource.Attributes[0].Key)
+		})
+	}
+}
+
+func TestGzipLevelInvalid(t *testing.T) {
+	for _, level := range []int{gzip.HuffmanOnly, gzip.NoCompression, gzip.BestCompression + 1} {
+		_, err := New(context.Background(), WithGzipLevel(level))
+		assert.ErrorContains(t, err, "invalid gzip level", "level %d", level)
+	}
+}
+
+func TestRetryableStatusFunc(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		fn       func(int) bool
+		wantErr  bool
+		attempts int64
+	}{
+		{
+			name:     "DefaultTerminal",
+			status:   http.StatusBadGateway,
+			wantErr:  true,
+			attempts: 1,
+		},
+		{
+			name:     "CustomRetryable",
+			status:   http.StatusBadGateway,
+			fn:       func(code int) bool { return code == http.StatusBadGateway },
+			attempts: 2,
+		},
+		{
+			name:     "CustomTerminal",
+			status:   http.StatusServiceUnavailable,
+			fn:       func(int) bool { return false },
+			wantErr:  true,
+			attempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				if attempts.Add(1) == 1 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(srv.Close)
+
+			ctx := context.Background()
+			exp, err := New(
+				ctx,
+				WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+				WithInsecure(),
+				WithRetry(RetryConfig{
+					Enabled:         true,
+					InitialInterval: time.Nanosecond,
+					MaxInterval:     time.Millisecond,
+					MaxElapsedTime:  time.Minute,
+				}),
+				WithRetryableStatusFunc(tt.fn),
+			)
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+			err = exp.Export(ctx, &metricdata.ResourceMetrics{})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.attempts, attempts.Load())
+		})
+	}
+}
+
+func gzipBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestGzipResponse(t *testing.T) {
+	partialSuccess, err := proto.Marshal(&colmetricpb.ExportMetricsServiceResponse{
+		PartialSuccess: &colmetricpb.ExportMetricsPartialSuccess{
+			RejectedDataPoints: 2,
+			ErrorMessage:       "bad data",
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		status  int
+		body    []byte
+		wantErr string
+		handled string
+	}{
+		{
+			name:    "Error",
+			status:  http.StatusBadRequest,
+			body:    gzipBytes(t, []byte("invalid metric data")),
+			wantErr: "invalid metric data",
+		},
+		{
+			name:    "InvalidGzipError",
+			status:  http.StatusBadRequest,
+			body:    []byte("not gzip encoded"),
+			wantErr: "not gzip encoded",
+		},
+		{
+			name:    "PartialSuccess",
+			status:  http.StatusOK,
+			body:    gzipBytes(t, partialSuccess),
+			handled: "bad data",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var handled []error
+			orig := otel.GetErrorHandler()
+			otel.SetErrorHandler(otel.ErrorHandlerFunc(func(e error) { handled = append(handled, e) }))
+			t.Cleanup(func() { otel.SetErrorHandler(orig) })
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				w.Header().Set("Content-Encoding", "gzip")
+				w.WriteHeader(tc.status)
+				_, _ = w.Write(tc.body)
+			}))
+			t.Cleanup(srv.Close)
+
+			ctx := context.Background()
+			exp, err := New(ctx, WithEndpoint(strings.TrimPrefix(srv.URL, "http://")), WithInsecure())
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+			err = exp.Export(ctx, &metricdata.ResourceMetrics{})
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tc.handled != "" {
+				require.Len(t, handled, 1)
+				assert.ErrorContains(t, handled[0], tc.handled)
+			}
+		})
+	}
+}
+
+func TestNewNoop(t *testing.T) {
+	ctx := context.Background()
+	exp, err := NewNoop(WithTemporalitySelector(func(metric.InstrumentKind) metricdata.Temporality {
+		return metricdata.DeltaTemporality
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, metricdata.DeltaTemporality, exp.Temporality(metric.InstrumentKindCounter))
+	assert.Equal(t, aggregation.Sum{}, exp.Aggregation(metric.InstrumentKindCounter))
+
+	rm := &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{{
+				Name: "gauge",
+				Data: metricdata.Gauge[int64]{
+					DataPoints: []metricdata.DataPoint[int64]{{Value: 1}},
+				},
+			}},
+		}},
+	}
+	assert.NoError(t, exp.Export(ctx, rm))
+	assert.NoError(t, exp.ForceFlush(ctx))
+	assert.NoError(t, exp.Shutdown(ctx))
+
+	// Options are validated the same way as by New.
+	_, err = NewNoop(WithGzipLevel(100))
+	assert.Error(t, err)
+}
+
+func TestRequestEditor(t *testing.T) {
+	sign := func(b []byte) string {
+		sum := sha256.Sum256(b)
+		return hex.EncodeToString(sum[:])
+	}
+
+	var (
+		mu       sync.Mutex
+		received int
+		valid    bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received++
+		valid = r.Header.Get("X-Signature") == sign(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	var gotHeaders map[string]string
+	editor := func(_ context.Context, headers map[string]string, body []byte) (map[string]string, error) {
+		gotHeaders = headers
+		return map[string]string{"X-Signature": sign(body)}, nil
+	}
+
+	ctx := context.Background()
+	exp, err := New(ctx,
+		WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithInsecure(),
+		WithCompression(GzipCompression),
+		WithRequestEditor(editor),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	mu.Lock()
+	assert.Equal(t, 1, received)
+	assert.True(t, valid, "signature does not match the body sent")
+	mu.Unlock()
+	assert.Equal(t, "gzip", gotHeaders["Content-Encoding"])
+	assert.Equal(t, "application/x-protobuf", gotHeaders["Content-Type"])
+
+	errEditor := func(context.Context, map[string]string, []byte) (map[string]string, error) {
+		return nil, assert.AnError
+	}
+	errExp, err := New(ctx,
+		WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithInsecure(),
+		WithRequestEditor(errEditor),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, errExp.Shutdown(ctx)) })
+
+	assert.ErrorIs(t, errExp.Export(ctx, &metricdata.ResourceMetrics{}), assert.AnError)
+	mu.Lock()
+	assert.Equal(t, 1, received, "export sent after editor error")
+	mu.Unlock()
+}
+
+func TestTLSServerName(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		sni string
+	)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			mu.Lock()
+			sni = hello.ServerName
+			mu.Unlock()
+			return nil, nil
+		},
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	// The server is dialed by IP and presents a certificate for example.com.
+	ctx := context.Background()
+	exp, err := New(ctx,
+		WithEndpoint(strings.TrimPrefix(srv.URL, "https://")),
+		WithTLSClientConfig(&tls.Config{RootCAs: pool}),
+		WithTLSServerName("example.com"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	mu.Lock()
+	assert.Equal(t, "example.com", sni)
+	mu.Unlock()
+}
+
+func TestMarshalErrorNotRetried(t *testing.T) {
+	var attempts atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	exp, err := New(ctx,
+		WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithInsecure(),
+		WithRetry(RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Nanosecond,
+			MaxInterval:     time.Millisecond,
+			MaxElapsedTime:  time.Minute,
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	// Strings with invalid UTF-8 cannot be marshaled.
+	rm := &metricdata.ResourceMetrics{
+		Resource: resource.NewSchemaless(attribute.String("service.name", "\xff")),
+	}
+	assert.ErrorContains(t, exp.Export(ctx, rm), "failed to marshal metrics")
+	assert.Equal(t, int64(0), attempts.Load(), "request sent")
+}
+
+func TestProtoMarshalOptions(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies [][]byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	exp, err := New(ctx,
+		WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithInsecure(),
+		WithProtoMarshalOptions(proto.MarshalOptions{Deterministic: true}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	rm := &metricdata.ResourceMetrics{
+		Resource: resource.NewSchemaless(
+			attribute.String("service.name", "test"),
+			attribute.Int("instance", 1),
+		),
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{{
+				Name: "requests",
+				Data: metricdata.Sum[int64]{
+					Temporality: metricdata.CumulativeTemporality,
+					IsMonotonic: true,
+					DataPoints: []metricdata.DataPoint[int64]{{
+						Attributes: attribute.NewSet(attribute.String("method", "GET")),
+						Value:      1,
+					}},
+				},
+			}},
+		}},
+	}
+	require.NoError(t, exp.Export(ctx, rm))
+	require.NoError(t, exp.Export(ctx, rm))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, bodies, 2)
+	assert.NotEmpty(t, bodies[0])
+	assert.Equal(t, bodies[0], bodies[1], "deterministic payloads differ")
+}
+
+func TestHTTPResponseHandler(t *testing.T) {
+	var attempts atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A gateway reporting a failure with a 200 status code.
+		n := attempts.Add(1)
+		w.WriteHeader(http.StatusOK)
+		if n == 1 {
+			_, _ = w.Write([]byte(`{"error":"backend unavailable"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	errSoft := errors.New("soft failure")
+	handler := func(retryable bool) func(int, []byte) error {
+		return func(status int, body []byte) error {
+			if status != http.StatusOK || !bytes.Contains(body, []byte(`"error"`)) {
+				return nil
+			}
+			if retryable {
+				return fmt.Errorf("%w: backend unavailable", ErrRetryableResponse)
+			}
+			return errSoft
+		}
+	}
+	newExp := func(t *testing.T, retryable bool) metric.Exporter {
+		ctx := context.Background()
+		exp, err := New(ctx,
+			WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+			WithInsecure(),
+			WithHTTPResponseHandler(handler(retryable)),
+			WithRetry(RetryConfig{
+				Enabled:         true,
+				InitialInterval: time.Nanosecond,
+				MaxInterval:     time.Millisecond,
+				MaxElapsedTime:  time.Minute,
+			}),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		return exp
+	}
+
+	t.Run("Failure", func(t *testing.T) {
+		attempts.Store(0)
+		exp := newExp(t, false)
+		err := exp.Export(context.Background(), &metricdata.ResourceMetrics{})
+		assert.ErrorIs(t, err, errSoft)
+		assert.Equal(t, int64(1), attempts.Load(), "non-retryable failure retried")
+	})
+
+	t.Run("Retryable", func(t *testing.T) {
+		attempts.Store(0)
+		exp := newExp(t, true)
+		assert.NoError(t, exp.Export(context.Background(), &metricdata.ResourceMetrics{}))
+		assert.Equal(t, int64(2), attempts.Load())
+	})
+}
+
+func TestStartupPing(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Healthy", func(t *testing.T) {
+		var received atomic.Int64
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(srv.Close)
+
+		exp, err := New(ctx,
+			WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+			WithInsecure(),
+			WithStartupPing(true),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		assert.Equal(t, int64(1), received.Load(), "no ping export received")
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		require.NoError(t, ln.Close())
+
+		_, err = New(ctx,
+			WithEndpoint(addr),
+			WithInsecure(),
+			WithStartupPing(true),
+			WithRetry(RetryConfig{Enabled: false}),
+		)
+		assert.ErrorContains(t, err, "startup ping failed")
+	})
+}
+
+func TestExportError(t *testing.T) {
+	ctx := context.Background()
+	newExporter := func(t *testing.T, code int) metric.Exporter {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(io.Discard, r.Body)
+			w.WriteHeader(code)
+		}))
+		t.Cleanup(srv.Close)
+		exp, err := New(
+			ctx,
+			WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+			WithInsecure(),
+			WithRetry(RetryConfig{Enabled: false}),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		return exp
+	}
+
+	t.Run("Rejected", func(t *testing.T) {
+		err := newExporter(t, http.StatusBadRequest).Export(ctx, &metricdata.ResourceMetrics{})
+
+		var exportErr *ExportError
+		require.ErrorAs(t, err, &exportErr)
+		assert.Equal(t, "metrics", exportErr.Signal)
+		assert.Equal(t, http.StatusBadRequest, exportErr.StatusCode)
+		assert.False(t, exportErr.Retryable)
+	})
+
+	t.Run("Retryable", func(t *testing.T) {
+		err := newExporter(t, http.StatusServiceUnavailable).Export(ctx, &metricdata.ResourceMetrics{})
+
+		var exportErr *ExportError
+		require.ErrorAs(t, err, &exportErr)
+		assert.Equal(t, http.StatusServiceUnavailable, exportErr.StatusCode)
+		assert.True(t, exportErr.Retryable)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		cCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		err := newExporter(t, http.StatusOK).Export(cCtx, &metricdata.ResourceMetrics{})
+
+		var exportErr *ExportError
+		require.ErrorAs(t, err, &exportErr)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, exportErr.StatusCode)
+		assert.False(t, exportErr.Retryable)
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		l, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+		require.NoError(t, l.Close())
+
+		exp, err := New(ctx, WithEndpoint(addr), WithInsecure(), WithRetry(RetryConfig{Enabled: false}))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+		err = exp.Export(ctx, &metricdata.ResourceMetrics{})
+
+		var exportErr *ExportError
+		require.ErrorAs(t, err, &exportErr)
+		assert.Zero(t, exportErr.StatusCode)
+		var opErr *net.OpError
+		assert.ErrorAs(t, err, &opErr)
+	})
+}
+
+func TestTemporalityPerInstrument(t *testing.T) {
+	coll, err := otest.NewHTTPCollector("", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, coll.Shutdown(context.Background())) })
+
+	ctx := context.Background()
+	cumulative := func(name string) bool { return name == "requests" }
+	newReader := func(selector metric.TemporalitySelector, keep func(string) bool) metric.Reader {
+		exp, err := New(
+			ctx,
+			WithEndpoint(coll.Addr().String()),
+			WithInsecure(),
+			WithTemporalitySelector(selector),
+			WithMetricNameFilter(keep),
+		)
+		require.NoError(t, err)
+		return metric.NewPeriodicReader(exp)
+	}
+	mp := metric.NewMeterProvider(
+		metric.WithReader(newReader(CumulativeTemporality, cumulative)),
+		metric.WithReader(newReader(DeltaTemporality, func(name string) bool { return !cumulative(name) })),
+	)
+	t.Cleanup(func() { require.NoError(t, mp.Shutdown(ctx)) })
+
+	meter := mp.Meter("test")
+	for _, name := range []string{"requests", "bytes"} {
+		counter, err := meter.Int64Counter(name)
+		require.NoError(t, err)
+		counter.Add(ctx, 1)
+	}
+	require.NoError(t, mp.ForceFlush(ctx))
+
+	got := make(map[string]mpb.AggregationTemporality)
+	for _, rm := range coll.Collect().Dump() {
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				got[m.Name] = m.GetSum().AggregationTemporality
+			}
+		}
+	}
+	assert.Equal(t, map[string]mpb.AggregationTemporality{
+		"requests": mpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+		"bytes":    mpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+	}, got)
+}
+
+func TestSelfMetricsPrefix(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	r := metric.NewManualReader()
+	exp, err := New(
+		ctx,
+		WithEndpoint(strings.TrimPrefix(srv.URL, "http://")),
+		WithInsecure(),
+		WithSelfObservability(metric.NewMeterProvider(metric.WithReader(r))),
+		WithSelfMetricsPrefix("myapp.otlp."),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, exp.Shutdown(ctx)) })
+
+	require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, r.Collect(ctx, &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	var names []string
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		names = append(names, m.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"myapp.otlp.export.attempts",
+		"myapp.otlp.export.successes",
+		"myapp.otlp.export.duration",
+	}, names)
+}
@@ -15,12 +15,22 @@
 package otlpmetrichttp // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/url"
 	"time"
 
+	"google.golang.org/protobuf/proto"
+
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/internal/retry"
+	ominternal "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
+	otelmetric "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
 // Compression describes the compression used for payloads sent to the
@@ -36,6 +46,79 @@ const (
 	GzipCompression = Compression(oconf.GzipCompression)
 )
 
+// String returns the name of c.
+func (c Compression) String() string {
+	return oconf.Compression(c).String()
+}
+
+// MarshalText returns the name of c ("none" or "gzip"). An error is returned
+// if c is not a known Compression.
+func (c Compression) MarshalText() ([]byte, error) {
+	return oconf.Compression(c).MarshalText()
+}
+
+// UnmarshalText sets c to the Compression named by text ("none" or "gzip",
+// case-insensitive). An error is returned and c is left unchanged if text
+// does not name a known Compression.
+func (c *Compression) UnmarshalText(text []byte) error {
+	return (*oconf.Compression)(c).UnmarshalText(text)
+}
+
+// Encoding describes the encoding of payloads sent to the collector.
+type Encoding oconf.Encoding
+
+const (
+	// ProtobufEncoding tells the driver to send payloads encoded as binary
+	// protobuf messages ("http/protobuf").
+	ProtobufEncoding = Encoding(oconf.ProtobufEncoding)
+	// JSONEncoding tells the driver to send payloads encoded as OTLP/JSON
+	// ("http/json").
+	JSONEncoding = Encoding(oconf.JSONEncoding)
+)
+
+// RotationPolicy describes how successive exports are distributed across the
+// endpoints set with WithEndpoints.
+type RotationPolicy oconf.RotationPolicy
+
+const (
+	// NoRotation starts every export with the first endpoint. The other
+	// endpoints are only used if the first cannot be reached.
+	NoRotation = RotationPolicy(oconf.NoRotation)
+	// RoundRobin starts each successive export with the next endpoint in
+	// order.
+	RoundRobin = RotationPolicy(oconf.RoundRobinRotation)
+	// Random starts each export with a randomly chosen endpoint.
+	Random = RotationPolicy(oconf.RandomRotation)
+)
+
+// BackpressurePolicy describes what is done with an export when the limit set
+// with WithMaxInFlightBytes has been reached.
+type BackpressurePolicy oconf.BackpressurePolicy
+
+const (
+	// Block makes an export wait for in-flight exports to complete.
+	Block = BackpressurePolicy(oconf.BlockBackpressure)
+	// Drop makes an export fail immediately, dropping its metric data.
+	Drop = BackpressurePolicy(oconf.DropBackpressure)
+)
+
+// MonotonicViolationPolicy describes how a cumulative monotonic sum data
+// point with a value less than the previously exported value of its series is
+// handled.
+type MonotonicViolationPolicy ominternal.MonotonicViolationPolicy
+
+const (
+	// PassThrough exports the decreased value.
+	PassThrough = MonotonicViolationPolicy(ominternal.PassThroughMonotonicViolation)
+	// DropViolation does not export the data point with the decreased value.
+	// A metric left without data points, and a scope left without metrics,
+	// are not exported either.
+	DropViolation = MonotonicViolationPolicy(ominternal.DropMonotonicViolation)
+	// ClampToPrevious exports the previously exported value of the series
+	// instead of the decreased value.
+	ClampToPrevious = MonotonicViolationPolicy(ominternal.ClampMonotonicViolation)
+)
+
 // Option applies an option to the Exporter.
 type Option interface {
 	applyHTTPOption(oconf.Config) oconf.Config
@@ -62,8 +145,23 @@ func (w wrappedOption) applyHTTPOption(cfg oconf.Config) oconf.Config {
 }
 
 // WithEndpoint sets the target endpoint the Exporter will connect to. This
-// endpoint is specified as a host and optional port, no path or scheme should
-// be included (see WithInsecure and WithURLPath).
+// endpoint is specified as a host and optional port. If no port is included,
+// the default OTLP/HTTP port 4318 is used. IPv6 addresses need to be enclosed
+// in square brackets (e.g. "[::1]:4318" or "[fe80::1%eth0]"), unbracketed
+// IPv6 addresses are ambiguous and ignored with an error logged.
+//
+// The endpoint may be followed by a base URL path. Metrics are then sent to
+// the "/v1/metrics" path relative to it, like for the
+// OTEL_EXPORTER_OTLP_ENDPOINT environment variable. A trailing slash of the
+// base path is ignored: "collector/base/" and "collector/base" both send
+// metrics to "/base/v1/metrics". Use WithURLPath to set the full URL path
+// instead.
+//
+// The endpoint may start with an "http://" or "https://" scheme. An explicit
+// scheme determines the client security and takes precedence over
+// WithInsecure regardless of the order the options are passed in. If
+// WithInsecure is also passed with an "https://" endpoint, an error is logged
+// and client security is used.
 //
 // If the OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_METRICS_ENDPOINT
 // environment variable is set, and this option is not passed, that variable
@@ -76,6 +174,74 @@ func WithEndpoint(endpoint string) Option {
 	return wrappedOption{oconf.WithEndpoint(endpoint)}
 }
 
+// WithEndpoints sets multiple target endpoints the Exporter will send
+// exports to. Each endpoint is specified as a host and optional port, no path
+// or scheme should be included (see WithInsecure and WithURLPath). If no port
+// is included, the default OTLP/HTTP port 4318 is used. Endpoints that include
+// a scheme or path, or are otherwise invalid, are ignored with an error
+// logged.
+//
+// If an endpoint cannot be reached, the export is sent to the next endpoint
+// in order, wrapping around to the first, until it has been tried with all
+// endpoints. Which endpoint an export is first sent to is determined by the
+// RotationPolicy set with WithEndpointRotation.
+//
+// This option and WithEndpoint override each other, the last one passed is
+// used.
+func WithEndpoints(endpoints ...string) Option {
+	return wrappedOption{oconf.WithEndpoints(endpoints...)}
+}
+
+// WithEndpointRotation sets the RotationPolicy used to distribute exports
+// across the endpoints set with WithEndpoints.
+//
+// By default, if this option is not passed, NoRotation is used.
+func WithEndpointRotation(policy RotationPolicy) Option {
+	return wrappedOption{oconf.WithEndpointRotation(oconf.RotationPolicy(policy))}
+}
+
+// WithStartupPing sets if New makes an empty export to check the target
+// endpoint can be reached. If the export fails, New returns an error instead
+// of the problem being discovered when the first metric data is exported.
+// The export is bounded by the context passed to New and is retried like any
+// other export.
+//
+// The collector receives this export like any other. It contains no metric
+// data, but receiving backends may still record it.
+//
+// By default, if this option is not passed, no export is made by New.
+func WithStartupPing(enabled bool) Option {
+	return wrappedOption{oconf.WithStartupPing(enabled)}
+}
+
+// WithHTTPEncoding sets the encoding the Exporter will use to send payloads.
+// Payloads encoded with JSONEncoding are sent with the "application/json"
+// Content-Type, and those encoded with ProtobufEncoding with the
+// "application/x-protobuf" Content-Type.
+//
+// If the OTEL_EXPORTER_OTLP_PROTOCOL or OTEL_EXPORTER_OTLP_METRICS_PROTOCOL
+// environment variable is set to "http/json" or "http/protobuf", and this
+// option is not passed, the matching encoding will be used. If both are set,
+// OTEL_EXPORTER_OTLP_METRICS_PROTOCOL will take precedence.
+//
+// By default, if an environment variable is not set, and this option is not
+// passed, ProtobufEncoding will be used.
+func WithHTTPEncoding(enc Encoding) Option {
+	return wrappedOption{oconf.WithHTTPEncoding(oconf.Encoding(enc))}
+}
+
+// WithProtoMarshalOptions sets the options used to marshal payloads sent with
+// the ProtobufEncoding. For example, pass proto.MarshalOptions{Deterministic:
+// true} to produce the same bytes for the same metric data, as needed to sign
+// or cache requests. The options have no effect on payloads sent with the
+// JSONEncoding.
+//
+// By default, if this option is not passed, the zero proto.MarshalOptions
+// are used.
+func WithProtoMarshalOptions(opts proto.MarshalOptions) Option {
+	return wrappedOption{oconf.WithProtoMarshalOptions(opts)}
+}
+
 // WithCompression sets the compression strategy the Exporter will use to
 // compress the HTTP body.
 //
@@ -91,7 +257,23 @@ func WithCompression(compression Compression) Option {
 	return wrappedOption{oconf.WithCompression(oconf.Compression(compression))}
 }
 
-// WithURLPath sets the URL path the Exporter will send requests to.
+// WithGzipLevel sets the level the Exporter compresses the HTTP body at when
+// GzipCompression is used. The level must be between gzip.BestSpeed and
+// gzip.BestCompression (from the compress/gzip package), otherwise New will
+// return an error. Lower levels compress faster at the cost of larger
+// requests.
+//
+// By default, if this option is not passed, gzip.DefaultCompression is used.
+func WithGzipLevel(level int) Option {
+	return wrappedOption{oconf.WithGzipLevel(level)}
+}
+
+// WithURLPath sets the URL path the Exporter will send requests to. The path
+// may include a query string (e.g. "/v1/metrics?tenant=a") that is sent with
+// each request as is.
+//
+// This is the generic form of WithMetricsURLPath, which is preferred. The
+// path passed here is only cleaned when the Exporter is created.
 //
 // If the OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_METRICS_ENDPOINT
 // environment variable is set, and this option is not passed, the path
@@ -104,21 +286,132 @@ func WithURLPath(urlPath string) Option {
 	return wrappedOption{oconf.WithURLPath(urlPath)}
 }
 
+// WithMetricsURLPath sets the URL path the Exporter will send metric data to.
+// The path may include a query string (e.g. "/v1/metrics?tenant=a") that is
+// sent with each request as is.
+//
+// The path is cleaned when this option is applied: surrounding spaces and
+// redundant separators are removed, ".." and "." segments are resolved, and
+// it is made absolute. If the cleaned path is empty, "/v1/metrics" is used.
+// Paths set with an environment variable are cleaned the same way.
+//
+// If the OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_METRICS_ENDPOINT
+// environment variable is set, and this option is not passed, the path
+// contained in that variable value will be used. If both are set,
+// OTEL_EXPORTER_OTLP_METRICS_ENDPOINT will take precedence.
+//
+// By default, if an environment variable is not set, and this option is not
+// passed, "/v1/metrics" will be used.
+func WithMetricsURLPath(urlPath string) Option {
+	return wrappedOption{oconf.WithMetricsURLPath(urlPath)}
+}
+
+// WithStrictURLPath makes the Exporter reject a URL path containing a ".."
+// segment. An error is logged and the default path "/v1/metrics" is used
+// instead. This applies to paths set with WithURLPath or an environment
+// variable, and to paths set with WithMetricsURLPath after this option.
+//
+// By default, if this option is not passed, ".." segments are resolved when
+// the path is cleaned (e.g. "dir/../other" is sent to "/other").
+func WithStrictURLPath() Option {
+	return wrappedOption{oconf.WithStrictURLPath()}
+}
+
 // WithTLSClientConfig sets the TLS configuration the Exporter will use for
 // HTTP requests.
 //
 // If the OTEL_EXPORTER_OTLP_CERTIFICATE or
 // OTEL_EXPORTER_OTLP_METRICS_CERTIFICATE environment variable is set, and
 // this option is not passed, that variable value will be used. The value will
-// be parsed the filepath of the TLS certificate chain to use. If both are
-// set, OTEL_EXPORTER_OTLP_METRICS_CERTIFICATE will take precedence.
+// be parsed the filepath of the TLS certificate chain to use. If the value is
+// the path of a directory, the certificates of all PEM encoded files in the
+// directory are used. If both are set,
+// OTEL_EXPORTER_OTLP_METRICS_CERTIFICATE will take precedence.
+//
+// If the OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE and
+// OTEL_EXPORTER_OTLP_CLIENT_KEY, or the
+// OTEL_EXPORTER_OTLP_METRICS_CLIENT_CERTIFICATE and
+// OTEL_EXPORTER_OTLP_METRICS_CLIENT_KEY, environment variables are set, and
+// this option is not passed, those variable values will be parsed as the
+// filepaths of the client certificate and key to use for mTLS. If both pairs
+// are set, the OTEL_EXPORTER_OTLP_METRICS_ pair will take precedence.
 //
 // By default, if an environment variable is not set, and this option is not
 // passed, the system default configuration is used.
+//
+// Passing a nil tlsCfg has no effect, an error is logged instead.
 func WithTLSClientConfig(tlsCfg *tls.Config) Option {
 	return wrappedOption{oconf.WithTLSClientConfig(tlsCfg)}
 }
 
+// WithTLSMinVersion sets the minimum TLS version the Exporter uses to
+// version, one of the version constants of the crypto/tls package (e.g.
+// tls.VersionTLS13). It is applied to the TLS configuration set with
+// WithTLSClientConfig or by an environment variable, or to a new one if none
+// is set. An unknown version is reported to the global error handler and
+// ignored.
+//
+// By default, if this option is not passed, the minimum version of the TLS
+// configuration is used.
+func WithTLSMinVersion(version uint16) Option {
+	return wrappedOption{oconf.WithTLSMinVersion(version)}
+}
+
+// WithTLSServerName sets the server name the Exporter uses to verify the
+// certificate of the endpoint, and sends for SNI, to name. This allows the
+// endpoint to be an IP address while the certificate presented is for a host
+// name. It is applied to the TLS configuration set with WithTLSClientConfig
+// or by an environment variable, or to a new one if none is set.
+//
+// By default, if this option is not passed, the server name of the TLS
+// configuration is used, or the host of the endpoint if it is empty.
+func WithTLSServerName(name string) Option {
+	return wrappedOption{oconf.WithTLSServerName(name)}
+}
+
+// WithTLSCipherSuites sets the cipher suites the Exporter enables to suites,
+// cipher suite IDs defined in the crypto/tls package. It is applied to the
+// TLS configuration set with WithTLSClientConfig or by an environment
+// variable, or to a new one if none is set. Unknown cipher suites are
+// reported to the global error handler and ignored.
+//
+// The cipher suites of TLS 1.3 are not configurable. Use this option with
+// WithTLSMinVersion(tls.VersionTLS12) or lower to restrict the cipher suites
+// negotiated with older TLS versions.
+//
+// By default, if this option is not passed, the cipher suites of the TLS
+// configuration are used.
+func WithTLSCipherSuites(suites []uint16) Option {
+	return wrappedOption{oconf.WithTLSCipherSuites(suites)}
+}
+
+// WithTLSCertPool sets the certificate authorities used to verify the
+// certificate of the endpoint to all PEM encoded certificates found in the
+// files of dir and its subdirectories. Files that do not contain a PEM encoded
+// certificate are skipped.
+//
+// If no certificate is found, an error is logged and the TLS configuration is
+// left unchanged.
+//
+// Any other setting of a TLS configuration set with WithTLSClientConfig is
+// preserved.
+func WithTLSCertPool(dir string) Option {
+	return wrappedOption{oconf.WithTLSCertPool(dir)}
+}
+
+// WithTLSClientCertFromFile sets the TLS configuration the Exporter will use
+// for HTTP requests to one loaded from files. The PEM encoded client certificate
+// and key at certPath and keyPath are presented to the server, and the PEM
+// encoded CA certificate at caPath is used to verify the server certificate.
+// If both certPath and keyPath are empty no client certificate is used, and
+// if caPath is empty the host's root CA set is used.
+//
+// If any of the files cannot be read or the certificate and key do not form a
+// valid pair, an error is logged and the TLS configuration is left unchanged.
+func WithTLSClientCertFromFile(certPath, keyPath, caPath string) Option {
+	return wrappedOption{oconf.WithTLSClientCertFromFile(certPath, keyPath, caPath)}
+}
+
 // WithInsecure disables client transport security for the Exporter's HTTP
 // connection.
 //
@@ -128,12 +421,49 @@ func WithTLSClientConfig(tlsCfg *tls.Config) Option {
 // scheme of "http" or "unix" client security will be disabled. If both are
 // set, OTEL_EXPORTER_OTLP_METRICS_ENDPOINT will take precedence.
 //
+// If the OTEL_EXPORTER_OTLP_INSECURE or OTEL_EXPORTER_OTLP_METRICS_INSECURE
+// environment variable is set to "true" or "false" (case-insensitive), and
+// this option is not passed, that variable value will be used to determine
+// client security instead of the endpoint scheme. If both are set,
+// OTEL_EXPORTER_OTLP_METRICS_INSECURE will take precedence.
+//
+// This option has no effect if the endpoint passed to WithEndpoint starts
+// with an "http://" or "https://" scheme. The scheme determines the client
+// security instead.
+//
 // By default, if an environment variable is not set, and this option is not
 // passed, client security will be used.
 func WithInsecure() Option {
 	return wrappedOption{oconf.WithInsecure()}
 }
 
+// WithProxy sets the function the Exporter will use to determine the proxy
+// an HTTP request is sent through. If fn returns a nil URL, no proxy is used.
+// See the Proxy field of http.Transport for more information.
+//
+// By default, if this option is not passed, the proxy is determined by the
+// HTTPS_PROXY and NO_PROXY environment variables (see
+// http.ProxyFromEnvironment).
+func WithProxy(fn func(*http.Request) (*url.URL, error)) Option {
+	return wrappedOption{oconf.WithProxy(fn)}
+}
+
+// WithHTTPClient sets the client the Exporter will use to send HTTP
+// requests. This can be used to share a client, or to use one with a custom
+// transport.
+//
+// If this option is passed, the TLS configuration set with
+// WithTLSClientConfig (or an environment variable) and the proxy set with
+// WithProxy are not used, the transport of c is responsible for them instead.
+// The timeout set with WithTimeout is still applied to each export attempt in
+// addition to any timeout of c.
+//
+// By default, if this option is not passed, a client with a transport based
+// on http.DefaultTransport is used.
+func WithHTTPClient(c *http.Client) Option {
+	return wrappedOption{oconf.WithHTTPClient(c)}
+}
+
 // WithHeaders will send the provided headers with each HTTP requests.
 //
 // If the OTEL_EXPORTER_OTLP_HEADERS or OTEL_EXPORTER_OTLP_METRICS_HEADERS
@@ -142,6 +472,13 @@ func WithInsecure() Option {
 // These pairs are expected to be in the W3C Correlation-Context format
 // without additional semi-colon delimited metadata (i.e. "k1=v1,k2=v2"). If
 // both are set, OTEL_EXPORTER_OTLP_METRICS_HEADERS will take precedence.
+// Header values read from these environment variables may reference other
+// environment variables using the "${VAR}" syntax (use "$$" for a literal
+// "$"). A header referencing an unset variable is not sent.
+//
+// The headers are merged with any headers set by an environment variable or
+// previously passed option, with the values in headers taking precedence. Use
+// WithHeadersReplace to replace these headers instead.
 //
 // By default, if an environment variable is not set, and this option is not
 // passed, no user headers will be set.
@@ -149,11 +486,119 @@ func WithHeaders(headers map[string]string) Option {
 	return wrappedOption{oconf.WithHeaders(headers)}
 }
 
-// WithTimeout sets the max amount of time an Exporter will attempt an export.
+// WithHeader sends a header with key and value with each HTTP request. It is
+// merged with the headers set by an environment variable or previously passed
+// option, replacing any value previously set for key. For example, a tenant
+// can be set with:
+//
+//	WithHeader("X-Scope-OrgID", "team-a")
+//
+// This is equivalent to WithHeaders(map[string]string{key: value}).
+func WithHeader(key, value string) Option {
+	return wrappedOption{oconf.WithHeader(key, value)}
+}
+
+// WithHeadersFromFile reads headers from the file at path and merges them
+// like WithHeaders. This allows headers to be read from a mounted secret.
+// Each line of the file defines one header as a "key: value" or "key=value"
+// pair, for example:
 //
-// This takes precedence over any retry settings defined by WithRetry. Once
-// this time limit has been reached the export is abandoned and the metric
-// data is dropped.
+//	# Collector credentials.
+//	Authorization: Bearer dG9rZW4=
+//	X-Scope-OrgID=team-a
+//
+// Blank lines and lines starting with "#" are ignored. The file is read once,
+// when the Exporter is created. If it cannot be read or a line is malformed,
+// no header is read from it and an error is logged.
+func WithHeadersFromFile(path string) Option {
+	return wrappedOption{oconf.WithHeadersFromFile(path)}
+}
+
+// WithHeadersReplace is like WithHeaders, but replaces any headers set by an
+// environment variable or previously passed option with headers instead of
+// merging with them.
+func WithHeadersReplace(headers map[string]string) Option {
+	return wrappedOption{oconf.WithHeadersReplace(headers)}
+}
+
+// WithHeadersFunc sets fn to be called for each export to get the headers
+// sent with it. This allows headers, like authentication tokens, to be
+// refreshed without creating a new Exporter. If set, the headers returned
+// from fn are sent instead of any set with WithHeaders or an environment
+// variable.
+//
+// The fn is called synchronously once for every export, so it should be
+// cheap (e.g. return a cached value refreshed elsewhere). It may be called
+// concurrently and needs to be safe to do so. The returned map is not
+// modified.
+func WithHeadersFunc(fn func() map[string]string) Option {
+	return wrappedOption{oconf.WithHeadersFunc(fn)}
+}
+
+// WithContextHeaders sets fn to be called with the context passed to each
+// export to get headers sent with it. This allows values only known when
+// metric data is exported, like a tenant ID stored in the context by
+// middleware, to be forwarded to the receiving endpoint.
+//
+// The headers returned from fn are merged with the headers set by
+// WithHeaders, WithHeadersFunc, or an environment variable. The headers
+// returned from fn take precedence.
+//
+// The fn is called synchronously once for every export. It may be called
+// concurrently with different contexts and needs to be safe to do so. The
+// returned map is not modified.
+func WithContextHeaders(fn func(context.Context) map[string]string) Option {
+	return wrappedOption{oconf.WithContextHeaders(fn)}
+}
+
+// WithRequestEditor sets a function that is called for each export with the
+// headers and the body of the HTTP request sent. The body passed is the
+// encoded metric data after any compression is applied, exactly as it is
+// sent. The headers fn returns are sent with the request in addition to, and
+// overriding, all other headers. This can be used to sign the request body.
+//
+// The headers passed to fn are a copy, modifying them has no effect. The
+// body must not be modified. If fn returns an error, the export is aborted
+// and the error is returned. The function is called once for each request,
+// retries of the request send the same headers.
+func WithRequestEditor(fn func(ctx context.Context, headers map[string]string, body []byte) (map[string]string, error)) Option {
+	return wrappedOption{oconf.WithRequestEditor(fn)}
+}
+
+// ErrRetryableResponse can be wrapped by the error returned from a function
+// set with WithHTTPResponseHandler to have the failed export retried.
+var ErrRetryableResponse = errors.New("retry-able response")
+
+// WithHTTPResponseHandler sets a function that is called with the status code
+// and the body of each response received for an export. If the body is gzip
+// encoded, it is decompressed before being passed to fn. This can be used
+// with gateways that respond to a failed export with a 2xx status code.
+//
+// If fn returns an error, the export fails with that error. The export is
+// retried, if retries are enabled, only if the error wraps
+// ErrRetryableResponse (e.g. fmt.Errorf("%w: busy", ErrRetryableResponse)).
+// If fn returns nil, the response is handled by the Exporter as it would be
+// without fn, based on its status code.
+//
+// The fn is called synchronously once for every response. It may be called
+// concurrently and needs to be safe to do so. The body must not be modified.
+//
+// By default, if this option is not passed, responses are handled based on
+// their status code only.
+func WithHTTPResponseHandler(fn func(status int, body []byte) error) Option {
+	return wrappedOption{oconf.WithHTTPResponseHandler(fn)}
+}
+
+// WithTimeout sets the max amount of time each attempt of an export can take.
+//
+// If retries are enabled with WithRetry, an attempt that reaches this time
+// limit is retried. The max amount of time spent on an export, including all
+// of its attempts, is limited by the MaxElapsedTime of the RetryConfig
+// instead. If retries are not enabled, or the time limit of the export is
+// reached, the export is abandoned and the metric data is dropped.
+//
+// If the context passed to Export has an earlier deadline, that deadline is
+// used for the attempt instead.
 //
 // If the OTEL_EXPORTER_OTLP_TIMEOUT or OTEL_EXPORTER_OTLP_METRICS_TIMEOUT
 // environment variable is set, and this option is not passed, that variable
@@ -167,6 +612,42 @@ func WithTimeout(duration time.Duration) Option {
 	return wrappedOption{oconf.WithTimeout(duration)}
 }
 
+// WithForceFlushTimeout sets the max amount of time ForceFlush of the
+// Exporter waits for the export in progress, if any, to complete. If the
+// context passed to ForceFlush has an earlier deadline, that deadline is used
+// instead. When d elapses, ForceFlush returns context.DeadlineExceeded and the
+// export in progress continues.
+//
+// By default, if this option is not passed, or d is not positive, the export
+// timeout set with WithTimeout is used.
+func WithForceFlushTimeout(d time.Duration) Option {
+	return wrappedOption{oconf.WithForceFlushTimeout(d)}
+}
+
+// WithMinAttemptWindow sets the minimum amount of time that needs to remain
+// before the deadline of the context passed to Export for the Exporter to
+// attempt the export. If less time remains, the export is not attempted and
+// an error is returned immediately.
+//
+// By default, if this option is not passed, or d is not positive, an export
+// is attempted regardless of the time remaining.
+func WithMinAttemptWindow(d time.Duration) Option {
+	return wrappedOption{oconf.WithMinAttemptWindow(d)}
+}
+
+// WithUserAgent appends suffix to the User-Agent the Exporter sends with
+// exports. The User-Agent of the Exporter, identifying the OpenTelemetry
+// exporter and its version, is always sent first. The suffix should be one
+// or more product identifiers separated by spaces (e.g. "myapp/1.2.3").
+//
+// If this option is passed multiple times, the last suffix is used. A suffix
+// containing line breaks is ignored and an error is logged.
+//
+// A User-Agent header set with WithHeaders replaces the whole User-Agent.
+func WithUserAgent(suffix string) Option {
+	return wrappedOption{oconf.WithUserAgent(suffix)}
+}
+
 // WithRetry sets the retry policy for transient retryable errors that are
 // returned by the target endpoint.
 //
@@ -177,23 +658,329 @@ func WithTimeout(duration time.Duration) Option {
 // If unset, the default retry policy will be used. It will retry the export
 // 5 seconds after receiving a retryable error and increase exponentially
 // after each error for no more than a total time of 1 minute.
+//
+// If retries are enabled and the MaxElapsedTime of the policy is shorter
+// than its InitialInterval, no retry is ever made. A warning is logged for
+// this combination.
+//
+// Each backoff interval is randomized to avoid many clients retrying at the
+// same time. Use WithRetryRandomizationFactor to change by how much.
 func WithRetry(rc RetryConfig) Option {
 	return wrappedOption{oconf.WithRetry(retry.Config(rc))}
 }
 
+// WithRetryableStatusFunc sets the function that reports if an export that
+// failed with the HTTP status code is retried. It replaces the default
+// classification, where only 429 (Too Many Requests) and 503 (Service
+// Unavailable) are retried.
+//
+// Exports are only retried if retries are enabled, see WithRetry.
+//
+// By default, if this option is not passed, or fn is nil, the default
+// classification is used.
+func WithRetryableStatusFunc(fn func(code int) bool) Option {
+	return wrappedOption{oconf.WithRetryableStatusFunc(fn)}
+}
+
+// WithRetryDisabled disables retrying exports that failed with a retryable
+// error. A failed export returns its error immediately after the first
+// attempt and the metric data is dropped. This is useful when dropping
+// metric data is preferred over blocking the export for retries.
+//
+// If WithRetry is passed after this option, it overrides this setting.
+func WithRetryDisabled() Option {
+	return wrappedOption{oconf.WithRetryDisabled()}
+}
+
+// WithRetryInitialInterval sets the time to wait after the first failed
+// attempt of an export before retrying it. Only this setting of the retry
+// policy is changed, the rest are left as set by WithRetry or the defaults.
+//
+// If WithRetry is passed after this option, it overrides this setting.
+func WithRetryInitialInterval(d time.Duration) Option {
+	return wrappedOption{oconf.WithRetryInitialInterval(d)}
+}
+
+// WithRetryMaxInterval sets the upper bound of the time to wait between
+// attempts of an export. Only this setting of the retry policy is changed,
+// the rest are left as set by WithRetry or the defaults.
+//
+// If WithRetry is passed after this option, it overrides this setting.
+func WithRetryMaxInterval(d time.Duration) Option {
+	return wrappedOption{oconf.WithRetryMaxInterval(d)}
+}
+
+// WithRetryMultiplier sets the factor the time to wait between attempts of an
+// export is multiplied by after each failed attempt. The multiplier is not
+// part of the RetryConfig and is not changed by WithRetry.
+//
+// The multiplier must be at least 1. If a smaller value is passed, an error
+// is logged and the multiplier is not changed.
+//
+// By default, if this option is not passed, a multiplier of 1.5 is used.
+func WithRetryMultiplier(m float64) Option {
+	return wrappedOption{oconf.WithRetryMultiplier(m)}
+}
+
+// WithRetryRandomizationFactor sets the factor the time to wait between
+// attempts of an export is randomized by. This spreads out the retries of
+// many clients failing at the same time. The time waited is chosen at random
+// in the range [interval*(1-f), interval*(1+f)]. The factor is not part of
+// the RetryConfig and is not changed by WithRetry.
+//
+// A negative factor means the time waited is not randomized. A factor greater
+// than 1 is reduced to 1.
+//
+// By default, if this option is not passed, or f is zero, a factor of 0.5 is
+// used.
+func WithRetryRandomizationFactor(f float64) Option {
+	return wrappedOption{oconf.WithRetryRandomizationFactor(f)}
+}
+
+// WithFileStream makes the Exporter append all exports to the file at path
+// instead of sending them to an OTLP endpoint. The file is created if it does
+// not exist. Each export is written as an OTLP ExportMetricsServiceRequest
+// protobuf message prefixed with its varint encoded length, making the file a
+// single stream of requests that can later be bulk uploaded.
+//
+// The file is synced to stable storage after every fsyncEvery exports, and
+// when the Exporter is flushed or shutdown. If fsyncEvery is not positive the
+// file is only synced when the Exporter is flushed or shutdown.
+//
+// All endpoint, TLS, header, compression, and retry options are ignored when
+// this option is used.
+func WithFileStream(path string, fsyncEvery int) Option {
+	return wrappedOption{oconf.WithFileStream(path, fsyncEvery)}
+}
+
+// WithSchemaTransform sets a function that is applied to all resource and
+// data point attributes of the metric data the Exporter exports. The
+// attribute returned by fn is exported instead of the passed one, unless fn
+// returns false in which case the attribute is dropped. This can be used to
+// translate attributes between versions of semantic conventions.
+//
+// The metric data held by the SDK is not modified, only the exported data.
+func WithSchemaTransform(fn func(attribute.KeyValue) (attribute.KeyValue, bool)) Option {
+	return wrappedOption{oconf.WithSchemaTransform(fn)}
+}
+
+// WithMetricNameFilter sets a function that reports if a metric with the
+// name is exported. The metrics keep returns false for are dropped from the
+// exported data, and so are the instrumentation scopes left without any
+// metric. This can be used to avoid exporting the metrics of instrumentation
+// that cannot be changed.
+//
+// The filter is applied before any other transformation of the exported
+// data. The metric data held by the SDK is not modified, only the exported
+// data.
+func WithMetricNameFilter(keep func(name string) bool) Option {
+	return wrappedOption{oconf.WithMetricNameFilter(keep)}
+}
+
+// WithResourceAttributeFilter sets a function that reports if a resource
+// attribute is exported. The resource attributes keep returns false for are
+// dropped from the exported data. This can be used to avoid sending internal
+// attributes to a receiving endpoint.
+//
+// The filter is applied after any other transformation of the exported data.
+// The resource held by the SDK is not modified, only the exported data.
+func WithResourceAttributeFilter(keep func(attribute.KeyValue) bool) Option {
+	return wrappedOption{oconf.WithResourceAttributeFilter(keep)}
+}
+
+// WithCardinalityLimit limits the number of distinct attribute sets the
+// Exporter exports for each metric to n. The data points of the first n
+// attribute sets seen for a metric are exported as is. The data points of
+// any other attribute set are aggregated into a single overflow data point
+// with the otel.metric.overflow=true attribute instead of being dropped:
+// gauge values are replaced by the latest value, sum values are added, and
+// histogram buckets are merged.
+//
+// The limit is applied to the metric data being exported, after it has been
+// aggregated by the SDK. It bounds the series sent to the receiving endpoint,
+// not the memory used by the SDK to aggregate measurements.
+//
+// If the OTEL_EXPORTER_OTLP_METRICS_CARDINALITY_LIMIT environment variable is
+// set to a positive integer, and this option is not passed, that value will
+// be used.
+//
+// By default, if the environment variable is not set, and this option is not
+// passed, or n is not positive, the number of data points is not limited.
+func WithCardinalityLimit(n int) Option {
+	return wrappedOption{oconf.WithCardinalityLimit(n)}
+}
+
+// WithMonotonicViolationPolicy sets how the Exporter handles a cumulative
+// monotonic sum data point with a value less than the value it last exported
+// for the same series. Such a decrease is usually caused by a faulty
+// instrument and is rejected by some backends. Regardless of policy, a
+// warning is logged the first time a series decreases, and again only after
+// the series has increased or been reset. Decreases are not reported in any other way.
+//
+// A data point with a different start time than the one last exported for
+// the series is a reset of the series, not a decrease.
+//
+// By default, if this option is not passed, the PassThrough policy is used
+// and the last exported values are not tracked.
+func WithMonotonicViolationPolicy(policy MonotonicViolationPolicy) Option {
+	return wrappedOption{oconf.WithMonotonicViolationPolicy(ominternal.MonotonicViolationPolicy(policy))}
+}
+
+// WithMaxInFlightBytes limits the serialized size of all exports in flight
+// to n bytes. This bounds the memory held by exports waiting on a slow
+// endpoint. When an export would exceed the limit, policy determines whether
+// the export waits for in-flight exports to complete or is dropped with an
+// error. An export larger than n is still attempted if no other export is in
+// flight.
+//
+// By default, if this option is not passed, or n is not positive, the bytes
+// in flight are not limited.
+func WithMaxInFlightBytes(n int64, policy BackpressurePolicy) Option {
+	return wrappedOption{oconf.WithMaxInFlightBytes(n, oconf.BackpressurePolicy(policy))}
+}
+
+// WithMaxPayloadBytes limits the serialized size of a single export request
+// to n bytes. Exports larger than n are split into multiple requests that are
+// sent in order, each containing the resource and the scopes of the exported
+// metric data. A data point that exceeds n bytes on its own is dropped and an
+// error is reported to the global error handler.
+//
+// By default, if this option is not passed, or n is not positive, exports
+// are not split.
+func WithMaxPayloadBytes(n int) Option {
+	return wrappedOption{oconf.WithMaxPayloadBytes(n)}
+}
+
+// WithMaxConcurrentExports allows the Exporter to have up to n exports in
+// flight and to send their requests concurrently. An export that would exceed
+// the limit waits for another export to complete, or for its context to be
+// done in which case the context error is returned.
+//
+// By default, if this option is not passed, or n is not positive, the number
+// of exports in flight is not limited, but their requests are sent one at a
+// time.
+func WithMaxConcurrentExports(n int) Option {
+	return wrappedOption{oconf.WithMaxConcurrentExports(n)}
+}
+
+// WithSelfMetricsPrefix sets the prefix of the names of the metrics the
+// Exporter records about itself. This can be used to avoid these names
+// colliding with application metric names when they are exported through the
+// same pipeline.
+//
+// The prefix must start with a letter and only contain letters, digits, '_',
+// '.', or '-'. If an invalid prefix is passed, an error is logged and the
+// prefix is not changed.
+//
+// The self-metrics are only recorded if they are enabled with
+// WithSelfObservability.
+//
+// If this option is not used, the prefix "otel.exporter." is used.
+func WithSelfMetricsPrefix(prefix string) Option {
+	return wrappedOption{oconf.WithSelfMetricsPrefix(prefix)}
+}
+
+// WithSelfObservability makes the Exporter record metrics about the exports
+// it makes with mp. The Exporter records the number of export attempts, the
+// number of successful exports, the number of failed exports by the reason
+// they failed, and the duration of exports.
+//
+// The mp should not be the MeterProvider whose metric data is exported by the
+// Exporter. Otherwise, every export records measurements that are themselves
+// exported by the next export. Use a separate MeterProvider instead.
+//
+// If this option is not used, or mp is nil, no self-metrics are recorded and
+// exports are not instrumented.
+func WithSelfObservability(mp otelmetric.MeterProvider) Option {
+	return wrappedOption{oconf.WithSelfObservability(mp)}
+}
+
+// WithProducers adds producers of external metrics to the options. This
+// allows a single list of options to configure both the Exporter and the
+// metric.Reader it is paired with.
+//
+// The producers are not used by the Exporter. They only produce metrics if
+// they are registered with the reader the Exporter is paired with. Use
+// Producers to get them from the options and register each of them with the
+// reader using its RegisterProducer method.
+//
+// This option may be used multiple times, the producers are appended.
+func WithProducers(producers ...metric.Producer) Option {
+	return wrappedOption{oconf.WithProducers(producers...)}
+}
+
+// Producers returns the producers added to opts with WithProducers, in the
+// order they were added.
+func Producers(opts ...Option) []metric.Producer {
+	return oconf.NewHTTPConfig(asHTTPOptions(opts)...).Metrics.Producers
+}
+
 // WithTemporalitySelector sets the TemporalitySelector the client will use to
-// determine the Temporality of an instrument based on its kind. If this option
-// is not used, the client will use the DefaultTemporalitySelector from the
+// determine the Temporality of an instrument based on its kind.
+//
+// If the OTEL_EXPORTER_OTLP_METRICS_TEMPORALITY_PREFERENCE environment
+// variable is set to "cumulative", "delta", or "lowmemory", and this option is
+// not passed, the CumulativeTemporality, DeltaTemporality, or
+// LowMemoryTemporality selector respectively will be used.
+//
+// By default, if the environment variable is not set, and this option is not
+// passed, the client will use the DefaultTemporalitySelector from the
 // go.opentelemetry.io/otel/sdk/metric package.
+//
+// A TemporalitySelector only knows the kind of an instrument, not its name,
+// so the temporality cannot be selected per instrument. To export some
+// instruments with a different temporality than the others, pair a reader
+// with an Exporter for each temporality, and use WithMetricNameFilter so that
+// each Exporter only exports the instruments that use its temporality.
 func WithTemporalitySelector(selector metric.TemporalitySelector) Option {
 	return wrappedOption{oconf.WithTemporalitySelector(selector)}
 }
 
+// CumulativeTemporality is a TemporalitySelector that selects the cumulative
+// temporality for all instrument kinds. It can be passed to
+// WithTemporalitySelector.
+func CumulativeTemporality(ik metric.InstrumentKind) metricdata.Temporality {
+	return oconf.CumulativeTemporality(ik)
+}
+
+// DeltaTemporality is a TemporalitySelector that selects the delta
+// temporality for counter, observable counter, and histogram instruments, and
+// the cumulative temporality for up-down counter, observable up-down counter,
+// and observable gauge instruments. It can be passed to
+// WithTemporalitySelector.
+func DeltaTemporality(ik metric.InstrumentKind) metricdata.Temporality {
+	return oconf.DeltaTemporality(ik)
+}
+
+// LowMemoryTemporality is a TemporalitySelector that selects the delta
+// temporality for counter and histogram instruments, and the cumulative
+// temporality for observable counter, up-down counter, observable up-down
+// counter, and observable gauge instruments. It can be passed to
+// WithTemporalitySelector.
+func LowMemoryTemporality(ik metric.InstrumentKind) metricdata.Temporality {
+	return oconf.LowMemoryTemporality(ik)
+}
+
 // WithAggregationSelector sets the AggregationSelector the client will use to
 // determine the aggregation to use for an instrument based on its kind. If
 // this option is not used, the reader will use the DefaultAggregationSelector
 // from the go.opentelemetry.io/otel/sdk/metric package, or the aggregation
 // explicitly passed for a view matching an instrument.
+//
+// If the OTEL_EXPORTER_OTLP_METRICS_DEFAULT_HISTOGRAM_AGGREGATION environment
+// variable is set to "explicit_bucket_histogram" the explicit bucket histogram
+// aggregation is used for histogram instruments. The
+// "base2_exponential_bucket_histogram" value is not yet supported and is
+// ignored. This option overrides the environment variable.
 func WithAggregationSelector(selector metric.AggregationSelector) Option {
 	return wrappedOption{oconf.WithAggregationSelector(selector)}
 }
+
+// WithAggregationSelectorStrict is like WithAggregationSelector, but
+// validates the aggregation selector returns for every instrument kind when
+// the Exporter is created. If any of them are invalid, New returns an error
+// describing all the invalid aggregations instead of using the default
+// aggregation in their place.
+func WithAggregationSelectorStrict(selector metric.AggregationSelector) Option {
+	return wrappedOption{oconf.WithAggregationSelectorStrict(selector)}
+}
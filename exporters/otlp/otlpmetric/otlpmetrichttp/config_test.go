@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlpmetrichttp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestCompressionText(t *testing.T) {
+	type config struct {
+		Compression Compression `json:"compression"`
+	}
+
+	for _, c := range []Compression{NoCompression, GzipCompression} {
+		b, err := json.Marshal(config{Compression: c})
+		require.NoError(t, err)
+
+		var got config
+		require.NoError(t, json.Unmarshal(b, &got))
+		assert.Equal(t, c, got.Compression)
+	}
+
+	var got config
+	require.NoError(t, json.Unmarshal([]byte(`{"compression":"gzip"}`), &got))
+	assert.Equal(t, GzipCompression, got.Compression)
+	assert.Equal(t, "gzip", got.Compression.String())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"compression":"garbage"}`), &got))
+}
+
+type producer []metricdata.ScopeMetrics
+
+func (p producer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	return p, nil
+}
+
+func TestProducers(t *testing.T) {
+	assert.Empty(t, Producers(WithInsecure()))
+
+	scope := instrumentation.Scope{Name: "external"}
+	p0 := producer{{Scope: scope}}
+	p1 := producer{}
+	opts := []Option{WithProducers(p0), WithInsecure(), WithProducers(p1)}
+	got := Producers(opts...)
+	require.Len(t, got, 2)
+	assert.Equal(t, p0, got[0])
+	assert.Equal(t, p1, got[1])
+
+	reader := metric.NewManualReader()
+	for _, p := range got {
+		reader.RegisterProducer(p)
+	}
+	_ = metric.NewMeterProvider(metric.WithReader(reader))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	assert.Equal(t, scope, rm.ScopeMetrics[0].Scope)
+}
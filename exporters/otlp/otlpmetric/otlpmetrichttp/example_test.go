@@ -40,3 +40,38 @@ func Example() {
 	// From here, the meterProvider can be used by instrumentation to collect
 	// telemetry.
 }
+
+func ExampleWithTemporalitySelector() {
+	ctx := context.Background()
+
+	// The "requests" instrument is exported with the cumulative temporality,
+	// all other instruments with the delta temporality.
+	cumulative := map[string]bool{"requests": true}
+	cumulativeExp, err := otlpmetrichttp.New(
+		ctx,
+		otlpmetrichttp.WithTemporalitySelector(otlpmetrichttp.CumulativeTemporality),
+		otlpmetrichttp.WithMetricNameFilter(func(name string) bool { return cumulative[name] }),
+	)
+	if err != nil {
+		panic(err)
+	}
+	deltaExp, err := otlpmetrichttp.New(
+		ctx,
+		otlpmetrichttp.WithTemporalitySelector(otlpmetrichttp.DeltaTemporality),
+		otlpmetrichttp.WithMetricNameFilter(func(name string) bool { return !cumulative[name] }),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	meterProvider := metric.NewMeterProvider(
+		metric.WithReader(metric.NewPeriodicReader(cumulativeExp)),
+		metric.WithReader(metric.NewPeriodicReader(deltaExp)),
+	)
+	defer func() {
+		if err := meterProvider.Shutdown(ctx); err != nil {
+			panic(err)
+		}
+	}()
+	otel.SetMeterProvider(meterProvider)
+}
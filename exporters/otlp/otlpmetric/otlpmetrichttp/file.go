@@ -0,0 +1,134 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlpmetrichttp // import "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/internal/oconf"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// fileClient appends uploads to a file as length-delimited OTLP requests.
+type fileClient struct {
+	// fileMu ensures each request is written as a single contiguous frame.
+	fileMu     sync.Mutex
+	file       *os.File
+	fsyncEvery int
+	unsynced   int
+
+	temporalitySelector metric.TemporalitySelector
+	aggregationSelector metric.AggregationSelector
+}
+
+// newFileClient creates a new client that writes to the file stream path
+// defined in cfg.
+func newFileClient(cfg oconf.Config) (*fileClient, error) {
+	f, err := os.OpenFile(cfg.Metrics.FileStreamPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileClient{
+		file:       f,
+		fsyncEvery: cfg.Metrics.FileStreamFsyncEvery,
+
+		temporalitySelector: cfg.Metrics.TemporalitySelector,
+		aggregationSelector: cfg.Metrics.AggregationSelector,
+	}, nil
+}
+
+// Temporality returns the Temporality to use for an instrument kind.
+func (c *fileClient) Temporality(k metric.InstrumentKind) metricdata.Temporality {
+	return c.temporalitySelector(k)
+}
+
+// Aggregation returns the Aggregation to use for an instrument kind.
+func (c *fileClient) Aggregation(k metric.InstrumentKind) aggregation.Aggregation {
+	return c.aggregationSelector(k)
+}
+
+// UploadMetrics appends protoMetrics to the file stream.
+func (c *fileClient) UploadMetrics(ctx context.Context, protoMetrics *metricpb.ResourceMetrics) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	pbRequest := &colmetricpb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricpb.ResourceMetrics{protoMetrics},
+	}
+	// Frame the request before writing so a partial frame is never written
+	// because of a marshaling error.
+	var buf bytes.Buffer
+	if _, err := protodelim.MarshalTo(&buf, pbRequest); err != nil {
+		return err
+	}
+
+	c.fileMu.Lock()
+	defer c.fileMu.Unlock()
+
+	if _, err := c.file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	c.unsynced++
+	if c.fsyncEvery > 0 && c.unsynced >= c.fsyncEvery {
+		return c.sync()
+	}
+	return nil
+}
+
+// sync flushes the file to stable storage. The fileMu must be held.
+func (c *fileClient) sync() error {
+	if c.unsynced == 0 {
+		return nil
+	}
+	c.unsynced = 0
+	return c.file.Sync()
+}
+
+// ForceFlush syncs all written exports to stable storage.
+func (c *fileClient) ForceFlush(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.fileMu.Lock()
+	defer c.fileMu.Unlock()
+	return c.sync()
+}
+
+// Shutdown syncs all written exports to stable storage and closes the file.
+func (c *fileClient) Shutdown(ctx context.Context) error {
+	c.fileMu.Lock()
+	defer c.fileMu.Unlock()
+
+	err := c.sync()
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// A context error takes precedence over these errors.
+		err = ctxErr
+	}
+	return err
+}
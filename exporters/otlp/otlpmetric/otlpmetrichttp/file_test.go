@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlpmetrichttp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+)
+
+func readFileStream(t *testing.T, path string) []*colmetricpb.ExportMetricsServiceRequest {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	var reqs []*colmetricpb.ExportMetricsServiceRequest
+	r := bufio.NewReader(f)
+	for {
+		req := new(colmetricpb.ExportMetricsServiceRequest)
+		err := protodelim.UnmarshalFrom(r, req)
+		if errors.Is(err, io.EOF) {
+			return reqs
+		}
+		require.NoError(t, err)
+		reqs = append(reqs, req)
+	}
+}
+
+func TestFileStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.otlp")
+	ctx := context.Background()
+	exp, err := New(ctx, WithFileStream(path, 2))
+	require.NoError(t, err)
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res := resource.NewSchemaless(attribute.Int("export", i))
+			assert.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{Resource: res}))
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, exp.Shutdown(ctx))
+
+	reqs := readFileStream(t, path)
+	require.Len(t, reqs, n)
+	seen := make(map[int64]bool, n)
+	for _, req := range reqs {
+		require.Len(t, req.ResourceMetrics, 1)
+		attrs := req.ResourceMetrics[0].Resource.Attributes
+		require.Len(t, attrs, 1)
+		seen[attrs[0].Value.GetIntValue()] = true
+	}
+	assert.Len(t, seen, n, "exports lost or duplicated")
+}
+
+func TestFileStreamAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.otlp")
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		exp, err := New(ctx, WithFileStream(path, 0))
+		require.NoError(t, err)
+		require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+		require.NoError(t, exp.ForceFlush(ctx))
+		require.NoError(t, exp.Shutdown(ctx))
+	}
+	assert.Len(t, readFileStream(t, path), 2)
+}
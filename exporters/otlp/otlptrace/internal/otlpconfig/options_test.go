@@ -289,6 +289,15 @@ func TestConfigs(t *testing.T) {
 				assert.Equal(t, map[string]string{"h1": "v1", "h2": "v2"}, c.Traces.Headers)
 			},
 		},
+		{
+			name: "Test Environment Signal Specific Headers Decoding",
+			env: map[string]string{
+				"OTEL_EXPORTER_OTLP_TRACES_HEADERS": " a+b = c+d , e=%20f ",
+			},
+			asserts: func(t *testing.T, c *otlpconfig.Config, grpcOption bool) {
+				assert.Equal(t, map[string]string{"a+b": "c+d", "e": " f"}, c.Traces.Headers)
+			},
+		},
 		{
 			name: "Test Mixed Environment and With Headers",
 			env:  map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "h1=v1,h2=v2"},
@@ -16,10 +16,13 @@ package global // import "go.opentelemetry.io/otel/internal/global"
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 	"sync"
 	"sync/atomic"
 
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -114,6 +117,15 @@ func MeterProvider() metric.MeterProvider {
 	return globalMeterProvider.Load().(meterProviderHolder).mp
 }
 
+// IsMeterProviderSet is the internal implementation for
+// global.IsMeterProviderSet. The default delegating MeterProvider and No-op
+// MeterProviders are not considered set.
+func IsMeterProviderSet() bool {
+	mp := MeterProvider()
+	_, isDefault := mp.(*meterProvider)
+	return !isDefault && !isNoopMeterProvider(mp)
+}
+
 // SetMeterProvider is the internal implementation for global.SetMeterProvider.
 func SetMeterProvider(mp metric.MeterProvider) {
 	current := MeterProvider()
@@ -129,6 +141,14 @@ func SetMeterProvider(mp metric.MeterProvider) {
 		}
 	}
 
+	if replacesMeterProvider(current, mp) {
+		Warn(
+			"Replacing the global MeterProvider. Meters already created from it will not be updated",
+			"previous", fmt.Sprintf("%T", current),
+			"new", fmt.Sprintf("%T", mp),
+		)
+	}
+
 	delegateMeterOnce.Do(func() {
 		if def, ok := current.(*meterProvider); ok {
 			def.setDelegate(mp)
@@ -137,6 +157,32 @@ func SetMeterProvider(mp metric.MeterProvider) {
 	globalMeterProvider.Store(meterProviderHolder{mp: mp})
 }
 
+// replacesMeterProvider returns if setting mp as the global MeterProvider
+// replaces current, a MeterProvider previously set by the user. The default
+// delegating MeterProvider and No-op MeterProviders are not considered.
+func replacesMeterProvider(current, mp metric.MeterProvider) bool {
+	if _, ok := current.(*meterProvider); ok {
+		return false
+	}
+	if isNoopMeterProvider(current) || isNoopMeterProvider(mp) {
+		return false
+	}
+	// Non-comparable types cannot be checked for equality. Only the same
+	// comparable value is known to not be a replacement.
+	if reflect.TypeOf(current) == reflect.TypeOf(mp) && reflect.TypeOf(mp).Comparable() {
+		return current != mp
+	}
+	return true
+}
+
+func isNoopMeterProvider(mp metric.MeterProvider) bool {
+	switch mp.(type) {
+	case noop.MeterProvider, *noop.MeterProvider:
+		return true
+	}
+	return false
+}
+
 func defaultTracerValue() *atomic.Value {
 	v := &atomic.Value{}
 	v.Store(tracerProviderHolder{tp: &tracerProvider{}})
@@ -15,6 +15,8 @@
 package global
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -175,6 +177,18 @@ func TestSetMeterProvider(t *testing.T) {
 		}
 	})
 
+	t.Run("IsMeterProviderSet", func(t *testing.T) {
+		ResetForTest(t)
+
+		assert.False(t, IsMeterProviderSet(), "before Set()")
+		SetMeterProvider(MeterProvider())
+		assert.False(t, IsMeterProviderSet(), "after setting the default")
+		SetMeterProvider(noop.NewMeterProvider())
+		assert.False(t, IsMeterProviderSet(), "after setting a No-op")
+		SetMeterProvider(&testMeterProvider{})
+		assert.True(t, IsMeterProviderSet(), "after Set()")
+	})
+
 	t.Run("non-comparable types should not panic", func(t *testing.T) {
 		ResetForTest(t)
 
@@ -182,4 +196,26 @@ func TestSetMeterProvider(t *testing.T) {
 		SetMeterProvider(mp)
 		assert.NotPanics(t, func() { SetMeterProvider(mp) })
 	})
+
+	t.Run("Replacing a set MeterProvider should warn", func(t *testing.T) {
+		ResetForTest(t)
+		var buf bytes.Buffer
+		orig := getLogger()
+		t.Cleanup(func() { SetLogger(orig) })
+		SetLogger(newBuffLogger(&buf, 1))
+
+		SetMeterProvider(noop.NewMeterProvider())
+		assert.Empty(t, buf.String(), "replacing a noop should not warn")
+
+		first := &testMeterProvider{}
+		SetMeterProvider(first)
+		assert.Empty(t, buf.String(), "first set should not warn")
+
+		SetMeterProvider(first)
+		assert.Empty(t, buf.String(), "setting the same provider should not warn")
+
+		SetMeterProvider(&testMeterProvider{})
+		assert.Contains(t, buf.String(), "Replacing the global MeterProvider")
+		assert.Equal(t, 1, strings.Count(buf.String(), `"level"=1`), "single warning")
+	})
 }
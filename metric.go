@@ -47,7 +47,19 @@ func GetMeterProvider() metric.MeterProvider {
 	return global.MeterProvider()
 }
 
+// IsMeterProviderSet returns if a global MeterProvider other than a No-op
+// MeterProvider has been registered with SetMeterProvider. If false,
+// GetMeterProvider returns the default MeterProvider that does not record any
+// telemetry until one is registered, or a registered No-op MeterProvider.
+func IsMeterProviderSet() bool {
+	return global.IsMeterProviderSet()
+}
+
 // SetMeterProvider registers mp as the global MeterProvider.
+//
+// A warning is logged if this replaces a MeterProvider previously registered
+// with a different, non-No-op, MeterProvider. Meters already created from the
+// previously registered MeterProvider are not updated.
 func SetMeterProvider(mp metric.MeterProvider) {
 	global.SetMeterProvider(mp)
 }
@@ -41,3 +41,11 @@ func TestMultipleGlobalMeterProvider(t *testing.T) {
 	got := GetMeterProvider()
 	assert.Equal(t, p2, got)
 }
+
+func TestIsMeterProviderSet(t *testing.T) {
+	SetMeterProvider(&testMeterProvider{})
+	assert.True(t, IsMeterProviderSet())
+
+	SetMeterProvider(noop.NewMeterProvider())
+	assert.False(t, IsMeterProviderSet(), "No-op MeterProvider set")
+}